@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+var bitFieldTypeRegexp = regexp.MustCompile(`^(i([1-9]|[1-5][0-9]|6[0-4])|u([1-9]|[1-5][0-9]|6[0-3]))$`)
+
+// BitFieldOp is a BITFIELD sub-operation, built with BitFieldGet, BitFieldSet
+// or BitFieldIncrBy. Types are "i<bits>" for signed integers of up to 64 bits
+// and "u<bits>" for unsigned integers of up to 63 bits. Offsets are in bits.
+type BitFieldOp struct {
+	op     string
+	typ    string
+	offset int64
+	value  int64
+}
+
+// BitFieldGet reads the integer of type typ at offset
+func BitFieldGet(typ string, offset int64) BitFieldOp {
+	return BitFieldOp{op: "GET", typ: typ, offset: offset}
+}
+
+// BitFieldSet writes value as an integer of type typ at offset and returns
+// the previous value
+func BitFieldSet(typ string, offset int64, value int64) BitFieldOp {
+	return BitFieldOp{op: "SET", typ: typ, offset: offset, value: value}
+}
+
+// BitFieldIncrBy increments the integer of type typ at offset by incr and
+// returns the new value. Overflows wrap around.
+func BitFieldIncrBy(typ string, offset int64, incr int64) BitFieldOp {
+	return BitFieldOp{op: "INCRBY", typ: typ, offset: offset, value: incr}
+}
+
+// BitField runs the sub-operations on the string stored at key in a single
+// BITFIELD command and returns one result per operation
+func (r *redis) BitField(key string, ops ...BitFieldOp) ([]int64, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("redis: BitField needs at least one operation")
+	}
+
+	args := []interface{}{"bitfield", key}
+	for _, op := range ops {
+		if !bitFieldTypeRegexp.MatchString(op.typ) {
+			return nil, fmt.Errorf("redis: invalid bitfield type %q", op.typ)
+		}
+		args = append(args, op.op, op.typ, op.offset)
+		if op.op != "GET" {
+			args = append(args, op.value)
+		}
+	}
+
+	cl, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+	cmd := redisLib.NewCmd(args...)
+	if err := cl.Process(cmd); err != nil {
+		return nil, err
+	}
+	res, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	replies, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected BITFIELD reply %T", res)
+	}
+	values := make([]int64, len(replies))
+	for i, reply := range replies {
+		n, ok := reply.(int64)
+		if !ok {
+			return nil, fmt.Errorf("redis: unexpected BITFIELD reply %T", reply)
+		}
+		values[i] = n
+	}
+	return values, nil
+}
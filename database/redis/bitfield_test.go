@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBitFieldCounters(t *testing.T) {
+	r := realRedis(t)
+	key := "test:bitfield"
+	r.GetUniversalClient().Del(key)
+	defer r.GetUniversalClient().Del(key)
+
+	// Two unsigned 16 bit counters packed in one string
+	res, err := r.BitField(key, BitFieldIncrBy("u16", 0, 3), BitFieldIncrBy("u16", 16, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(res) != "[3 10]" {
+		t.Fatalf("INCRBY = %v, want [3 10]", res)
+	}
+	if _, err := r.BitField(key, BitFieldIncrBy("u16", 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	res, err = r.BitField(key, BitFieldGet("u16", 0), BitFieldGet("u16", 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(res) != "[4 10]" {
+		t.Fatalf("GET = %v, want [4 10], the counters must be independent", res)
+	}
+
+	res, err = r.BitField(key, BitFieldSet("i8", 32, -5), BitFieldGet("i8", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(res) != "[0 -5]" {
+		t.Fatalf("SET, GET = %v, want [0 -5]", res)
+	}
+}
+
+func TestBitFieldValidation(t *testing.T) {
+	r := NewRedis(RedisConfig{Host: "127.0.0.1:1"})
+	if _, err := r.BitField("k"); err == nil {
+		t.Error("BitField without operations succeeded")
+	}
+	for _, typ := range []string{"", "u64", "i65", "i0", "x8", "u"} {
+		if _, err := r.BitField("k", BitFieldGet(typ, 0)); err == nil || err == ErrNotConnected {
+			t.Errorf("type %q: err = %v, want a validation error", typ, err)
+		}
+	}
+	for _, typ := range []string{"i1", "i64", "u1", "u63"} {
+		if _, err := r.BitField("k", BitFieldGet(typ, 0)); err != ErrNotConnected {
+			t.Errorf("type %q: err = %v, want it to pass validation", typ, err)
+		}
+	}
+}
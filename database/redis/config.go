@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	defaultPoolSize    = 64
+	defaultReadTimeout = 10 * time.Second
+	defaultLoadTimeout = 30 * time.Second
+)
+
+// SentinelConfig locates the master through Redis Sentinel
+type SentinelConfig struct {
+	// MasterName is the name of the master monitored by the Sentinels
+	MasterName string
+	// SentinelAddrs are the host:port addresses of the Sentinels
+	SentinelAddrs []string
+	// SentinelPassword authenticates to the Sentinels. go-redis v6 connects
+	// to them without password, so setting it fails the validation until
+	// the client is upgraded.
+	SentinelPassword string
+}
+
+// enabled reports whether the Sentinel section is populated
+func (c SentinelConfig) enabled() bool {
+	return c.MasterName != "" || len(c.SentinelAddrs) > 0 || c.SentinelPassword != ""
+}
+
+// RedisConfig holds the settings of a Redis client. Zero values get the
+// defaults of ApplyDefaults.
+type RedisConfig struct {
+	// Host is the host:port address of the server, left empty with Sentinel
+	Host string
+	// Sentinel connects to the current master of a Sentinel deployment
+	// instead of Host, following its failovers
+	Sentinel SentinelConfig
+	// Username authenticates as a Redis 6 ACL user together with Password.
+	// When empty, Password authenticates as the default user as before.
+	Username    string
+	Password    string
+	DB          int
+	PoolSize    int
+	ReadTimeout time.Duration
+	// PoolTimeout is how long an operation waits for a free connection when
+	// the pool is exhausted before failing with a pool timeout error.
+	// Defaults to ReadTimeout + 1s.
+	PoolTimeout time.Duration
+	// ScanMinCount and ScanMaxCount make the SCAN based helpers (KeysWithoutTTL,
+	// Export, MigrateKeys) tune the SCAN COUNT hint: it starts at ScanMinCount
+	// and doubles, up to ScanMaxCount, while calls return few matches. This
+	// walks large, sparsely matching keyspaces in fewer round trips while
+	// bounding the work of each call. Adaptive mode is off when ScanMaxCount
+	// is zero, the helpers then use the count they are given.
+	ScanMinCount int64
+	ScanMaxCount int64
+	// MaxJSONBytes makes SetJSON and GetJSON reject the payloads over that
+	// size with ErrPayloadTooLarge, unlimited when zero
+	MaxJSONBytes int
+	// NegativeTTL makes GetOrSet cache the ErrNotFound of its loaders for
+	// that long, returning ErrNotFound meanwhile. Off when zero.
+	NegativeTTL time.Duration
+	// LoadTimeout bounds the loaders of GetOrSet, which outlive the
+	// cancellation of the callers waiting for them. Defaults to 30s.
+	LoadTimeout time.Duration
+	// Lazy defers connecting until the first operation instead of InitClient.
+	// A failed connect is returned to that operation and retried on the next.
+	Lazy bool
+	// UseTLS connects over TLS 1.2 or later, verifying the server against
+	// the system roots, or the PEM certificates of CAFile when set.
+	// InsecureSkipVerify skips the verification, for tests only.
+	UseTLS             bool
+	InsecureSkipVerify bool
+	CAFile             string
+	// TLSConfig connects over TLS with that config, the other TLS settings
+	// being ignored
+	TLSConfig *tls.Config
+}
+
+// ApplyDefaults sets the default of every unset field: PoolSize 64,
+// ReadTimeout 10s and LoadTimeout 30s. PoolTimeout is left to go-redis,
+// ReadTimeout + 1s.
+func (c *RedisConfig) ApplyDefaults() {
+	if c.PoolSize == 0 {
+		c.PoolSize = defaultPoolSize
+	}
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = defaultReadTimeout
+	}
+	if c.LoadTimeout == 0 {
+		c.LoadTimeout = defaultLoadTimeout
+	}
+}
+
+// Validate reports the first invalid setting of the config
+func (c RedisConfig) Validate() error {
+	if c.Sentinel.enabled() {
+		if c.Host != "" {
+			return errors.New("redis: host and sentinel are mutually exclusive")
+		}
+		if err := c.Sentinel.validate(); err != nil {
+			return err
+		}
+		return c.validateOptions()
+	}
+	if c.Host == "" {
+		return errors.New("redis: host is required")
+	}
+	return c.validateOptions()
+}
+
+func (c SentinelConfig) validate() error {
+	switch {
+	case c.MasterName == "":
+		return errors.New("redis: sentinel master name is required")
+	case len(c.SentinelAddrs) == 0:
+		return errors.New("redis: sentinel needs at least one address")
+	case c.SentinelPassword != "":
+		return errors.New("redis: sentinel password is not supported by go-redis v6")
+	}
+	return nil
+}
+
+// validateOptions checks every setting but the address
+func (c RedisConfig) validateOptions() error {
+	switch {
+	case c.Username != "" && c.Password == "":
+		return errors.New("redis: username requires a password")
+	case c.DB < 0:
+		return errors.New("redis: DB must not be negative")
+	case c.PoolSize < 0:
+		return errors.New("redis: pool size must not be negative")
+	case c.ReadTimeout < 0 || c.PoolTimeout < 0:
+		return errors.New("redis: timeouts must not be negative")
+	case c.NegativeTTL < 0:
+		return errors.New("redis: NegativeTTL must not be negative")
+	case c.LoadTimeout < 0:
+		return errors.New("redis: LoadTimeout must not be negative")
+	case c.MaxJSONBytes < 0:
+		return errors.New("redis: max JSON bytes must not be negative")
+	case c.ScanMinCount < 0 || c.ScanMaxCount < 0:
+		return errors.New("redis: scan counts must not be negative")
+	case c.ScanMaxCount > 0 && c.ScanMinCount > c.ScanMaxCount:
+		return errors.New("redis: scan min count must not exceed the max count")
+	case (c.InsecureSkipVerify || c.CAFile != "") && !c.UseTLS && c.TLSConfig == nil:
+		return errors.New("redis: TLS settings require UseTLS")
+	}
+	return nil
+}
+
+// tlsEnabled reports whether the client connects over TLS
+func (c RedisConfig) tlsEnabled() bool {
+	return c.UseTLS || c.TLSConfig != nil
+}
+
+// tlsConfig returns the TLS config of the client, nil without TLS
+func (c RedisConfig) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig != nil {
+		return c.TLSConfig.Clone(), nil
+	}
+	if !c.UseTLS {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis: no certificate found in CA file %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// validate checks the config of r, a ring needing node addresses instead of Host
+func (r *redis) validate() error {
+	if r.ringAddrs == nil {
+		return r.config.Validate()
+	}
+	if len(r.ringAddrs) == 0 {
+		return errors.New("redis: ring needs at least one node address")
+	}
+	if r.config.Sentinel.enabled() {
+		return errors.New("redis: ring and sentinel are mutually exclusive")
+	}
+	if r.config.tlsEnabled() {
+		// go-redis v6 rings have no TLS option
+		return errors.New("redis: TLS is not supported by rings")
+	}
+	return r.config.validateOptions()
+}
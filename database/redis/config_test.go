@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	var c RedisConfig
+	c.ApplyDefaults()
+	if c.PoolSize != 64 || c.ReadTimeout != 10*time.Second || c.LoadTimeout != 30*time.Second {
+		t.Fatalf("defaults = %+v", c)
+	}
+	if c.PoolTimeout != 0 {
+		t.Fatalf("PoolTimeout = %v, want it left to go-redis", c.PoolTimeout)
+	}
+
+	c = RedisConfig{PoolSize: 8, ReadTimeout: time.Second, LoadTimeout: time.Minute}
+	c.ApplyDefaults()
+	if c.PoolSize != 8 || c.ReadTimeout != time.Second || c.LoadTimeout != time.Minute {
+		t.Fatalf("explicit settings replaced: %+v", c)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  RedisConfig
+		ok   bool
+	}{
+		{"host", RedisConfig{Host: "localhost:6379"}, true},
+		{"empty host", RedisConfig{}, false},
+		{"negative DB", RedisConfig{Host: "localhost:6379", DB: -1}, false},
+		{"negative pool size", RedisConfig{Host: "localhost:6379", PoolSize: -1}, false},
+		{"negative timeout", RedisConfig{Host: "localhost:6379", ReadTimeout: -time.Second}, false},
+		{"negative load timeout", RedisConfig{Host: "localhost:6379", LoadTimeout: -time.Second}, false},
+		{"scan bounds", RedisConfig{Host: "localhost:6379", ScanMinCount: 100, ScanMaxCount: 10}, false},
+	} {
+		err := tt.cfg.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v", tt.name, err)
+		}
+	}
+}
+
+func TestInitClientValidates(t *testing.T) {
+	if err := NewRedis(RedisConfig{}).InitClient(); err == nil {
+		t.Fatal("InitClient without host succeeded")
+	}
+}
+
+func TestNegativePoolSize(t *testing.T) {
+	cfg := RedisConfig{Host: "localhost:1", PoolSize: -1}
+	for name, r := range map[string]Redis{
+		"client": NewRedis(cfg),
+		"ring":   NewRedisRing(map[string]string{"a": "localhost:1", "b": "localhost:2"}, cfg),
+	} {
+		err := r.InitClient()
+		if err == nil || !strings.Contains(err.Error(), "pool size") {
+			t.Errorf("%s: InitClient() = %v, want the pool size error", name, err)
+		}
+	}
+
+	cfg.Lazy = true
+	err := NewRedis(cfg).Set(context.Background(), "k", "v", 0)
+	if err == nil || !strings.Contains(err.Error(), "pool size") {
+		t.Fatalf("lazy Set() = %v, want the pool size error", err)
+	}
+}
+
+func TestPasswordOptions(t *testing.T) {
+	r := NewRedis(RedisConfig{Host: "localhost:6379", Password: "secret", DB: 2}).(*redis)
+	opt, err := r.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opt.Password != "secret" || opt.DB != 2 || opt.OnConnect != nil {
+		t.Fatalf("password only options = %+v", opt)
+	}
+}
+
+func TestACLAuthentication(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireUserAuth("app", "secret")
+
+	r := NewRedis(RedisConfig{Host: s.Addr(), Username: "app", Password: "secret", DB: 2})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient with ACL credentials: %v", err)
+	}
+	defer r.Close()
+	if err := r.Set(context.Background(), "k", "v", 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.DB(2).Get("k"); v != "v" {
+		t.Fatalf("key not written to DB 2 of the ACL user: %q", v)
+	}
+
+	wrong := NewRedis(RedisConfig{Host: s.Addr(), Username: "app", Password: "wrong"})
+	if err := wrong.InitClient(); err == nil {
+		wrong.Close()
+		t.Fatal("InitClient with a wrong password succeeded")
+	}
+}
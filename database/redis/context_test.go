@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestContextSpanParenting(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	r, _ := newTestRedis(t)
+	mt.Reset()
+
+	parent, ctx := tracer.StartSpanFromContext(context.Background(), "http.request")
+	if err := r.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	parent.Finish()
+	// Without span in ctx, a trace of its own
+	if _, err := r.Get(context.Background(), "k"); err != ErrNil {
+		t.Fatal(err)
+	}
+
+	var children, roots int
+	for _, span := range mt.FinishedSpans() {
+		if span.OperationName() != "redis.command" {
+			continue
+		}
+		switch span.ParentID() {
+		case parent.Context().SpanID():
+			children++
+			if span.TraceID() != parent.Context().TraceID() {
+				t.Errorf("span %v in trace %d, want the trace of the request", span, span.TraceID())
+			}
+		case 0:
+			roots++
+		default:
+			t.Errorf("span %v has an unknown parent", span)
+		}
+	}
+	if children != 3 || roots != 1 {
+		t.Fatalf("%d child and %d root Redis spans, want 3 and 1", children, roots)
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	commands := s.CommandCount()
+	if err := r.Set(ctx, "k", "v", 0); err != context.Canceled {
+		t.Fatalf("Set = %v, want context.Canceled", err)
+	}
+	if _, err := r.Get(ctx, "k"); err != context.Canceled {
+		t.Fatalf("Get = %v, want context.Canceled", err)
+	}
+	if _, err := r.Delete(ctx, "k"); err != context.Canceled {
+		t.Fatalf("Delete = %v, want context.Canceled", err)
+	}
+	if n := s.CommandCount() - commands; n != 0 {
+		t.Fatalf("%d commands sent with a cancelled context", n)
+	}
+
+	// Nor does it connect a lazy client
+	lazy := miniredis.RunT(t)
+	lr := NewRedis(RedisConfig{Host: lazy.Addr(), Lazy: true})
+	defer lr.Close()
+	if _, err := lr.Get(ctx, "k"); err != context.Canceled {
+		t.Fatalf("lazy Get = %v, want context.Canceled", err)
+	}
+	if n := lazy.TotalConnectionCount(); n != 0 {
+		t.Fatalf("%d connections opened with a cancelled context", n)
+	}
+}
+
+// stallingServer answers PING and holds the other commands until the test
+// ends, counting them
+func stallingServer(t *testing.T) (addr string, commands *atomic.Int32) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		close(release)
+		l.Close()
+		wg.Wait()
+	})
+	commands = &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					cmd, err := readCommand(rd)
+					if err != nil {
+						return
+					}
+					if strings.EqualFold(cmd[0], "PING") {
+						io.WriteString(conn, "+PONG\r\n")
+						continue
+					}
+					commands.Add(1)
+					<-release
+					return
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), commands
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid command header %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestContextDeadline(t *testing.T) {
+	addr, commands := stallingServer(t)
+	r := NewRedis(RedisConfig{Host: addr, PoolSize: 2, ReadTimeout: 5 * time.Second})
+	if err := r.InitClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := r.Get(ctx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get = %v, want the deadline exceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Get returned after %v, want at the deadline", d)
+	}
+
+	// The abandoned command holds a slot of the pool, the second one the
+	// other, the third one waits for a slot
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	if err := r.Set(ctx2, "k", "v", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Set = %v, want the deadline exceeded", err)
+	}
+	ctx3, cancel3 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel3()
+	if _, err := r.Delete(ctx3, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Delete = %v, want the deadline exceeded", err)
+	}
+	if n := commands.Load(); n != 2 {
+		t.Fatalf("server got %d commands, want 2 with a pool of 2", n)
+	}
+}
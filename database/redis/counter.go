@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// Granularity is the time bucket a Counter starts over at
+type Granularity int
+
+const (
+	// NoBucket keeps a single counter forever
+	NoBucket Granularity = iota
+	// Hourly starts a new counter every hour, keyed <prefix>:YYYYMMDDHH
+	Hourly
+	// Daily starts a new counter every day, keyed <prefix>:YYYYMMDD
+	Daily
+)
+
+// The retention of the buckets, see SetRetention
+const (
+	defaultHourlyRetention = 7 * 24 * time.Hour
+	defaultDailyRetention  = 90 * 24 * time.Hour
+)
+
+// counterIncrScript increments KEYS[1] by ARGV[1] and, when it has no expiry
+// yet, makes it expire at ARGV[2], a unix time in milliseconds. Only the call
+// creating the bucket sets the expiry, as EXPIRE NX would.
+var counterIncrScript = redisLib.NewScript(`
+local n = redis.call("INCRBY", KEYS[1], ARGV[1])
+if redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+end
+return n
+`)
+
+// Counter is an integer counter stored with INCRBY, optionally spread over
+// time bucketed keys computed in UTC
+type Counter struct {
+	redis       Redis
+	prefix      string
+	granularity Granularity
+	retention   time.Duration
+	now         func() time.Time
+}
+
+// NewCounter is a factory that return a counter stored under prefix. The
+// Hourly buckets are kept for 7 days after they end, the Daily ones for 90
+// days, see SetRetention.
+func NewCounter(r Redis, prefix string, granularity Granularity) *Counter {
+	c := &Counter{
+		redis:       r,
+		prefix:      prefix,
+		granularity: granularity,
+		now:         time.Now,
+	}
+	switch granularity {
+	case Hourly:
+		c.retention = defaultHourlyRetention
+	case Daily:
+		c.retention = defaultDailyRetention
+	}
+	return c
+}
+
+// SetRetention keeps the buckets created from now on for d after they end,
+// the expiry being set by the increment creating the bucket. Zero or less
+// keeps them forever. The NoBucket counter never expires.
+func (c *Counter) SetRetention(d time.Duration) {
+	c.retention = d
+}
+
+// Key returns the key holding the counter for the bucket containing t
+func (c *Counter) Key(t time.Time) string {
+	switch c.granularity {
+	case Hourly:
+		return c.prefix + ":" + t.UTC().Format("2006010215")
+	case Daily:
+		return c.prefix + ":" + t.UTC().Format("20060102")
+	}
+	return c.prefix
+}
+
+// bucketEnd returns the end of the bucket containing t
+func (c *Counter) bucketEnd(t time.Time) time.Time {
+	t = t.UTC()
+	if c.granularity == Hourly {
+		return t.Truncate(time.Hour).Add(time.Hour)
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Inc increments the counter of the current bucket by one and returns its
+// new value
+func (c *Counter) Inc(ctx context.Context) (int64, error) {
+	return c.IncBy(ctx, 1)
+}
+
+// IncBy increments the counter of the current bucket by n and returns its new
+// value
+func (c *Counter) IncBy(ctx context.Context, n int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	cl, err := clientOf(c.redis)
+	if err != nil {
+		return 0, err
+	}
+	now := c.now()
+	if c.granularity == NoBucket || c.retention <= 0 {
+		return cl.IncrBy(c.Key(now), n).Result()
+	}
+	expireAt := c.bucketEnd(now).Add(c.retention).UnixNano() / int64(time.Millisecond)
+	return counterIncrScript.Run(cl, []string{c.Key(now)}, n, expireAt).Int64()
+}
+
+// Value returns the counter of the current bucket, 0 when nothing was counted
+// in it yet
+func (c *Counter) Value(ctx context.Context) (int64, error) {
+	return c.ValueAt(ctx, c.now())
+}
+
+// ValueAt returns the counter of the bucket containing t
+func (c *Counter) ValueAt(ctx context.Context, t time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	cl, err := clientOf(c.redis)
+	if err != nil {
+		return 0, err
+	}
+	v, err := cl.Get(c.Key(t)).Int64()
+	if err == redisLib.Nil {
+		return 0, nil
+	}
+	return v, err
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCounterBuckets(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+	// 23:30 in UTC+2 is 21:30 UTC
+	now := time.Date(2024, 3, 9, 23, 30, 0, 0, time.FixedZone("UTC+2", 2*3600))
+	s.SetTime(now)
+
+	daily := NewCounter(r, "signups", Daily)
+	daily.now = func() time.Time { return now }
+	hourly := NewCounter(r, "requests", Hourly)
+	hourly.now = func() time.Time { return now }
+	total := NewCounter(r, "total", NoBucket)
+
+	if n, err := daily.Inc(ctx); err != nil || n != 1 {
+		t.Fatalf("Inc = %d, %v", n, err)
+	}
+	if n, err := daily.IncBy(ctx, 4); err != nil || n != 5 {
+		t.Fatalf("IncBy = %d, %v", n, err)
+	}
+	hourly.IncBy(ctx, 2)
+	total.Inc(ctx)
+
+	for key, want := range map[string]string{
+		"signups:20240309":    "5",
+		"requests:2024030921": "2",
+		"total":               "1",
+	} {
+		if v, err := s.Get(key); err != nil || v != want {
+			t.Errorf("%s = %q, %v, want %s", key, v, err, want)
+		}
+	}
+
+	// The next day starts over
+	later := now.Add(3 * time.Hour)
+	daily.now = func() time.Time { return later }
+	if n, _ := daily.Inc(ctx); n != 1 {
+		t.Fatalf("Inc on the next day = %d, want 1", n)
+	}
+	if v, _ := s.Get("signups:20240310"); v != "1" {
+		t.Fatalf("next day bucket = %q", v)
+	}
+	if n, err := daily.Value(ctx); err != nil || n != 1 {
+		t.Fatalf("Value = %d, %v", n, err)
+	}
+	if n, err := daily.ValueAt(ctx, now); err != nil || n != 5 {
+		t.Fatalf("ValueAt of the previous day = %d, %v", n, err)
+	}
+	if n, err := daily.ValueAt(ctx, now.AddDate(0, 0, -7)); err != nil || n != 0 {
+		t.Fatalf("ValueAt of an empty bucket = %d, %v", n, err)
+	}
+}
+
+func TestCounterRetention(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+	// 21:30 UTC
+	now := time.Date(2024, 3, 9, 21, 30, 0, 0, time.UTC)
+	s.SetTime(now)
+	clock := func() time.Time { return now }
+	advance := func(d time.Duration) {
+		now = now.Add(d)
+		s.SetTime(now)
+	}
+
+	daily := NewCounter(r, "signups", Daily)
+	daily.now = clock
+	hourly := NewCounter(r, "requests", Hourly)
+	hourly.now = clock
+	total := NewCounter(r, "total", NoBucket)
+	for _, c := range []*Counter{daily, hourly, total} {
+		if _, err := c.Inc(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, want := range map[string]time.Duration{
+		"signups:20240309":    2*time.Hour + 30*time.Minute + defaultDailyRetention,
+		"requests:2024030921": 30*time.Minute + defaultHourlyRetention,
+		"total":               0,
+	} {
+		if ttl := s.TTL(key); ttl != want {
+			t.Errorf("TTL of %s = %v, want %v", key, ttl, want)
+		}
+	}
+
+	// The expiry is set once, by the increment creating the bucket
+	hourly.SetRetention(time.Hour)
+	advance(10 * time.Minute)
+	hourly.Inc(ctx)
+	if ttl := s.TTL("requests:2024030921"); ttl != 30*time.Minute+defaultHourlyRetention {
+		t.Fatalf("TTL of the existing bucket = %v, changed by an increment", ttl)
+	}
+	advance(time.Hour)
+	hourly.Inc(ctx)
+	if ttl := s.TTL("requests:2024030922"); ttl != 20*time.Minute+time.Hour {
+		t.Fatalf("TTL of the next bucket = %v, want the new retention", ttl)
+	}
+	s.FastForward(2 * time.Hour)
+	if s.Exists("requests:2024030922") {
+		t.Fatal("bucket kept past its retention")
+	}
+
+	hourly.SetRetention(0)
+	hourly.Inc(ctx)
+	if ttl := s.TTL("requests:2024030922"); ttl != 0 {
+		t.Fatalf("TTL without retention = %v, want none", ttl)
+	}
+}
+
+func TestCounterNotConnected(t *testing.T) {
+	c := NewCounter(NewRedis(RedisConfig{Host: "127.0.0.1:1"}), "c", NoBucket)
+	if _, err := c.Inc(context.Background()); err != ErrNotConnected {
+		t.Fatalf("Inc = %v, want ErrNotConnected", err)
+	}
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"sync"
+)
+
+// HandlerID identifies a handler registered on a Dispatcher
+type HandlerID uint64
+
+// Dispatcher fans the messages of each channel out to every handler
+// registered for it, over a single subscription shared by all channels
+type Dispatcher struct {
+	redis Redis
+	opts  SubscribeOptions
+
+	mu       sync.RWMutex
+	sub      *Subscription
+	handlers map[string]map[HandlerID]func(channel, payload string)
+	nextID   HandlerID
+}
+
+// NewDispatcher is a factory that return a dispatcher subscribing through r
+// with opts
+func NewDispatcher(r Redis, opts SubscribeOptions) *Dispatcher {
+	return &Dispatcher{
+		redis:    r,
+		opts:     opts,
+		handlers: make(map[string]map[HandlerID]func(channel, payload string)),
+	}
+}
+
+// Register adds handler for the messages of channel, subscribing to the
+// channel when it is its first handler. The returned ID unregisters it.
+func (d *Dispatcher) Register(channel string, handler func(channel, payload string)) (HandlerID, error) {
+	if channel == "" {
+		return 0, errNoChannels
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.handlers[channel]; !ok {
+		var err error
+		if d.sub == nil {
+			d.sub, err = d.redis.Subscribe(d.dispatch, d.opts, channel)
+		} else {
+			err = d.sub.Add(channel)
+		}
+		if err != nil {
+			return 0, err
+		}
+		d.handlers[channel] = make(map[HandlerID]func(channel, payload string))
+	}
+
+	d.nextID++
+	d.handlers[channel][d.nextID] = handler
+	return d.nextID, nil
+}
+
+// Unregister removes the handler registered with id for channel, unsubscribing
+// from the channel when it was its last handler
+func (d *Dispatcher) Unregister(channel string, id HandlerID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	handlers, ok := d.handlers[channel]
+	if !ok {
+		return nil
+	}
+	delete(handlers, id)
+	if len(handlers) > 0 {
+		return nil
+	}
+
+	delete(d.handlers, channel)
+	if len(d.handlers) == 0 {
+		err := d.sub.Close()
+		d.sub = nil
+		return err
+	}
+	return d.sub.Remove(channel)
+}
+
+// Close unregisters every handler and stops the subscription
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers = make(map[string]map[HandlerID]func(channel, payload string))
+	if d.sub == nil {
+		return nil
+	}
+	err := d.sub.Close()
+	d.sub = nil
+	return err
+}
+
+func (d *Dispatcher) dispatch(channel, payload string) {
+	d.mu.RLock()
+	handlers := make([]func(channel, payload string), 0, len(d.handlers[channel]))
+	for _, handler := range d.handlers[channel] {
+		handlers = append(handlers, handler)
+	}
+	d.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(channel, payload)
+	}
+}
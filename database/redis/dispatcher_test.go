@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// waitSubscribers waits until channel has n subscribers on s
+func waitSubscribers(t *testing.T, s *miniredis.Miniredis, channel string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.PubSubNumSub(channel)[channel] != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d subscribers, want %d", channel, s.PubSubNumSub(channel)[channel], n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	r, s := newTestRedis(t)
+	d := NewDispatcher(r, SubscribeOptions{})
+	defer d.Close()
+
+	billing, audit, other := make(chan string, 10), make(chan string, 10), make(chan string, 10)
+	billingID, err := d.Register("orders", func(_, payload string) { billing <- payload })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Register("orders", func(_, payload string) { audit <- payload }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Register("users", func(channel, payload string) { other <- channel + ":" + payload }); err != nil {
+		t.Fatal(err)
+	}
+	// A single subscription carries every handler and channel
+	waitSubscribers(t, s, "orders", 1)
+	waitSubscribers(t, s, "users", 1)
+
+	if err := r.Publish("orders", "created"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, billing); msg != "created" {
+		t.Fatalf("billing got %q", msg)
+	}
+	if msg := receive(t, audit); msg != "created" {
+		t.Fatalf("audit got %q", msg)
+	}
+	if err := r.Publish("users", "joined"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, other); msg != "users:joined" {
+		t.Fatalf("users handler got %q", msg)
+	}
+
+	if err := d.Unregister("orders", billingID); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Publish("orders", "paid"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, audit); msg != "paid" {
+		t.Fatalf("audit got %q", msg)
+	}
+	select {
+	case msg := <-billing:
+		t.Fatalf("unregistered handler got %q", msg)
+	default:
+	}
+}
+
+func TestDispatcherUnsubscribesLastHandler(t *testing.T) {
+	r, s := newTestRedis(t)
+	d := NewDispatcher(r, SubscribeOptions{})
+	defer d.Close()
+
+	orders, err := d.Register("orders", func(_, _ string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := d.Register("users", func(_, _ string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, "users", 1)
+
+	if err := d.Unregister("users", users); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, "users", 0)
+	waitSubscribers(t, s, "orders", 1)
+
+	if err := d.Unregister("orders", orders); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, s, "orders", 0)
+}
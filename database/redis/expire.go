@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"errors"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+var (
+	// ErrNoExpiry is returned when the key exists but has no expiry set
+	ErrNoExpiry = errors.New("redis: key has no expiry")
+	// ErrKeyNotFound is returned when the key does not exist
+	ErrKeyNotFound = errors.New("redis: key does not exist")
+)
+
+// ExpireTime returns the absolute time, with second precision, at which key
+// expires. It requires Redis 7.0 or later.
+func (r *redis) ExpireTime(key string) (time.Time, error) {
+	n, err := r.expireTime("expiretime", key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(n, 0), nil
+}
+
+// PExpireTime is like ExpireTime with millisecond precision
+func (r *redis) PExpireTime(key string) (time.Time, error) {
+	n, err := r.expireTime("pexpiretime", key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n*int64(time.Millisecond)), nil
+}
+
+func (r *redis) expireTime(command, key string) (int64, error) {
+	cl, err := r.conn()
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := redisLib.NewIntCmd(command, key)
+	cl.Process(cmd)
+	n, err := cmd.Result()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case -1:
+		return 0, ErrNoExpiry
+	case -2:
+		return 0, ErrKeyNotFound
+	}
+	return n, nil
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpireTime(t *testing.T) {
+	r := realRedis(t)
+	ctx := context.Background()
+	r.Delete(ctx, "test:expiring", "test:persistent", "test:missing")
+	if err := r.Set(ctx, "test:expiring", "v", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "test:persistent", "v", 0); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Delete(ctx, "test:expiring", "test:persistent")
+	want := time.Now().Add(time.Hour)
+
+	at, err := r.ExpireTime("test:expiring")
+	if err != nil {
+		t.Fatalf("ExpireTime: %v", err)
+	}
+	if d := at.Sub(want); d < -2*time.Second || d > 2*time.Second {
+		t.Fatalf("ExpireTime = %v, want about %v", at, want)
+	}
+	at, err = r.PExpireTime("test:expiring")
+	if err != nil {
+		t.Fatalf("PExpireTime: %v", err)
+	}
+	if d := at.Sub(want); d < -time.Second || d > time.Second {
+		t.Fatalf("PExpireTime = %v, want about %v", at, want)
+	}
+
+	for _, get := range []func(string) (time.Time, error){r.ExpireTime, r.PExpireTime} {
+		if _, err := get("test:persistent"); err != ErrNoExpiry {
+			t.Fatalf("expiry of a persistent key: %v, want ErrNoExpiry", err)
+		}
+		if _, err := get("test:missing"); err != ErrKeyNotFound {
+			t.Fatalf("expiry of a missing key: %v, want ErrKeyNotFound", err)
+		}
+	}
+}
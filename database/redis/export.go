@@ -0,0 +1,131 @@
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// exportScanCount is the SCAN COUNT hint used by Export
+const exportScanCount = 100
+
+// exportRecord is one line of the Export output
+type exportRecord struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	TTL   int64       `json:"ttl_ms"`
+	Value interface{} `json:"value"`
+}
+
+// exportMember is a sorted set member with its score
+type exportMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// Export streams every key matching pattern to w as JSON lines of the form
+// {"key":..,"type":..,"ttl_ms":..,"value":..}. Values are read according to
+// their type and ttl_ms is -1 for keys without expiry. Keys are processed one
+// SCAN batch at a time so the keyspace is never loaded in memory at once.
+// It returns the number of keys written.
+func (r *redis) Export(pattern string, w io.Writer) (int64, error) {
+	var count int64
+	enc := json.NewEncoder(w)
+	err := r.scan(pattern, exportScanCount, func(keys []string) error {
+		records, err := r.exportBatch(keys)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (r *redis) exportBatch(keys []string) ([]exportRecord, error) {
+	cl, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := cl.Pipeline()
+	types := make([]*redisLib.StatusCmd, len(keys))
+	ttls := make([]*redisLib.DurationCmd, len(keys))
+	for i, key := range keys {
+		types[i] = pipe.Type(key)
+		ttls[i] = pipe.PTTL(key)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	pipe = cl.Pipeline()
+	values := make([]redisLib.Cmder, len(keys))
+	for i, key := range keys {
+		switch types[i].Val() {
+		case "string":
+			values[i] = pipe.Get(key)
+		case "list":
+			values[i] = pipe.LRange(key, 0, -1)
+		case "set":
+			values[i] = pipe.SMembers(key)
+		case "zset":
+			values[i] = pipe.ZRangeWithScores(key, 0, -1)
+		case "hash":
+			values[i] = pipe.HGetAll(key)
+		case "stream":
+			values[i] = pipe.XRange(key, "-", "+")
+		}
+	}
+	// Keys deleted between SCAN and GET make the pipeline return redis.Nil,
+	// they are skipped below
+	if _, err := pipe.Exec(); err != nil && err != redisLib.Nil {
+		return nil, err
+	}
+
+	records := make([]exportRecord, 0, len(keys))
+	for i, key := range keys {
+		if values[i] == nil {
+			if t := types[i].Val(); t != "none" {
+				return nil, fmt.Errorf("redis: cannot export key %q of type %s", key, t)
+			}
+			continue
+		}
+		if values[i].Err() == redisLib.Nil {
+			continue
+		}
+
+		ttl := int64(-1)
+		if d := ttls[i].Val(); d > 0 {
+			ttl = int64(d / time.Millisecond)
+		}
+		rec := exportRecord{Key: key, Type: types[i].Val(), TTL: ttl}
+
+		switch cmd := values[i].(type) {
+		case *redisLib.StringCmd:
+			rec.Value = cmd.Val()
+		case *redisLib.StringSliceCmd:
+			rec.Value = cmd.Val()
+		case *redisLib.StringStringMapCmd:
+			rec.Value = cmd.Val()
+		case *redisLib.ZSliceCmd:
+			members := make([]exportMember, 0, len(cmd.Val()))
+			for _, z := range cmd.Val() {
+				members = append(members, exportMember{Member: fmt.Sprint(z.Member), Score: z.Score})
+			}
+			rec.Value = members
+		case *redisLib.XMessageSliceCmd:
+			rec.Value = cmd.Val()
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	r, s := newTestRedis(t)
+	s.Set("str", "hello")
+	s.SetTTL("str", time.Minute)
+	s.RPush("list", "a", "b")
+	s.SAdd("set", "x")
+	s.ZAdd("zset", 1.5, "m")
+	s.HSet("hash", "f", "v")
+	s.Set("ignored", "not matched")
+
+	var buf bytes.Buffer
+	n, err := r.Export("[slzh]*", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("Export = %d keys, want 5", n)
+	}
+
+	records := make(map[string]map[string]interface{})
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		var rec map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		records[rec["key"].(string)] = rec
+	}
+	if lines != 5 {
+		t.Fatalf("%d lines, want 5", lines)
+	}
+
+	str := records["str"]
+	if str["type"] != "string" || str["value"] != "hello" {
+		t.Errorf("str = %v", str)
+	}
+	if ttl, _ := str["ttl_ms"].(float64); ttl <= 0 || ttl > float64(time.Minute/time.Millisecond) {
+		t.Errorf("str ttl_ms = %v, want the remaining TTL", str["ttl_ms"])
+	}
+	if list := records["list"]; list["type"] != "list" || list["ttl_ms"] != float64(-1) {
+		t.Errorf("list = %v", list)
+	}
+	if values, _ := records["list"]["value"].([]interface{}); len(values) != 2 || values[0] != "a" {
+		t.Errorf("list value = %v", records["list"]["value"])
+	}
+	if set := records["set"]; set["type"] != "set" {
+		t.Errorf("set = %v", set)
+	}
+	members, _ := records["zset"]["value"].([]interface{})
+	if len(members) != 1 || members[0].(map[string]interface{})["score"] != 1.5 {
+		t.Errorf("zset = %v", records["zset"])
+	}
+	if hash, _ := records["hash"]["value"].(map[string]interface{}); hash["f"] != "v" {
+		t.Errorf("hash = %v", records["hash"])
+	}
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by the loaders of GetOrSet for the values that do
+// not exist, cached for RedisConfig.NegativeTTL when set
+var ErrNotFound = errors.New("redis: not found")
+
+// notFoundMarker is the value caching an ErrNotFound of a loader
+const notFoundMarker = "\x00redis:not-found"
+
+// GetOrSet returns the value of key, loading it with loader on a miss and
+// storing it with ttl before returning. Concurrent misses of a key in the
+// process share a single call of loader and its result. Loader errors are
+// returned to every caller waiting on that call and not cached, except
+// ErrNotFound when RedisConfig.NegativeTTL is set. A failure to store the
+// loaded value is logged only.
+//
+// Every caller stops waiting when its own ctx is done, while the loader runs
+// on, so the ones left still get the value. Its context carries the values
+// of the ctx of the first caller, without its cancellation, and times out
+// after RedisConfig.LoadTimeout.
+func (r *redis) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (string, error)) (string, error) {
+	val, err := r.Get(ctx, key)
+	if err == nil {
+		if val == notFoundMarker {
+			return "", ErrNotFound
+		}
+		return val, nil
+	}
+	if !errors.Is(err, ErrNil) {
+		return "", err
+	}
+
+	return r.flights.do(ctx, key, func() (string, error) {
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, r.config.LoadTimeout)
+		defer cancel()
+		val, err := loader(ctx)
+		if errors.Is(err, ErrNotFound) && r.config.NegativeTTL > 0 {
+			if err := r.Set(ctx, key, notFoundMarker, r.config.NegativeTTL); err != nil {
+				logger.Warnf("Failed to cache the miss of %s: %v", key, err)
+			}
+			return "", err
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := r.Set(ctx, key, val, ttl); err != nil {
+			logger.Warnf("Failed to cache the value of %s: %v", key, err)
+		}
+		return val, nil
+	})
+}
+
+// GetOrSetJSON is GetOrSet for values stored as JSON, see SetJSON
+func GetOrSetJSON[T any](ctx context.Context, r Redis, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var v T
+	payload, err := r.GetOrSet(ctx, key, ttl, func(ctx context.Context) (string, error) {
+		loaded, err := loader(ctx)
+		if err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(loaded)
+		if err != nil {
+			return "", fmt.Errorf("redis: failed to marshal %s: %w", key, err)
+		}
+		return string(b), nil
+	})
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal([]byte(payload), &v); err != nil {
+		var zero T
+		return zero, fmt.Errorf("redis: invalid JSON under %s: %w", key, err)
+	}
+	return v, nil
+}
+
+// flightGroup runs a single call per key at a time, the callers of a key in
+// flight waiting for its result
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	val  string
+	err  error
+}
+
+// do starts fn in the background unless a call of key is in flight, and
+// waits for the result of the call, giving up when ctx is done
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (string, error)) (string, error) {
+	g.mu.Lock()
+	f, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = make(map[string]*flight)
+		}
+		f = &flight{done: make(chan struct{})}
+		g.calls[key] = f
+		go g.run(key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// run calls fn for f, a panic failing the call rather than the process as
+// nobody can recover it
+func (g *flightGroup) run(key string, f *flight, fn func() (string, error)) {
+	defer func() {
+		if p := recover(); p != nil {
+			f.err = fmt.Errorf("redis: loader of %s panicked: %v", key, p)
+		}
+		g.finish(key, f)
+	}()
+	f.val, f.err = fn()
+}
+
+// finish removes f, the next callers of key starting a new call, and wakes up
+// its waiters
+func (g *flightGroup) finish(key string, f *flight) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// detachedContext keeps the values of its parent but not its deadline and
+// cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,260 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestGetOrSetColdKey(t *testing.T) {
+	r, s := newTestRedis(t)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "loaded", nil
+	}
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			v, err := r.GetOrSet(context.Background(), "cold", time.Minute, loader)
+			if err == nil && v != "loaded" {
+				err = errors.New("got " + v)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("GetOrSet: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("loader ran %d times, want once", n)
+	}
+	if v, _ := s.Get("cold"); v != "loaded" || s.TTL("cold") != time.Minute {
+		t.Fatalf("cached %q with TTL %v", v, s.TTL("cold"))
+	}
+
+	// Served from the cache afterwards
+	if v, err := r.GetOrSet(context.Background(), "cold", time.Minute, loader); err != nil || v != "loaded" || calls.Load() != 1 {
+		t.Fatalf("GetOrSet of a cached key = %q, %v, loader ran %d times", v, err, calls.Load())
+	}
+}
+
+func TestGetOrSetLoaderError(t *testing.T) {
+	r, s := newTestRedis(t)
+	errDB := errors.New("database down")
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "", errDB
+	}
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.GetOrSet(context.Background(), "k", time.Minute, loader)
+			results <- err
+		}()
+	}
+	// Lets every waiter join the call in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+	for err := range results {
+		if err != errDB {
+			t.Errorf("waiter got %v, want the loader error", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("loader ran %d times, want once", calls.Load())
+	}
+	if s.Exists("k") {
+		t.Fatal("loader error cached")
+	}
+	if _, err := r.GetOrSet(context.Background(), "k", time.Minute, loader); err != errDB || calls.Load() != 2 {
+		t.Fatalf("GetOrSet after an error = %v, loader ran %d times, want it run again", err, calls.Load())
+	}
+}
+
+func TestGetOrSetNegativeTTL(t *testing.T) {
+	notFound := func(calls *atomic.Int32) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			calls.Add(1)
+			return "", ErrNotFound
+		}
+	}
+
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr(), NegativeTTL: 10 * time.Second})
+	if err := r.InitClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var calls atomic.Int32
+	for i := 0; i < 3; i++ {
+		if _, err := r.GetOrSet(context.Background(), "user:1", time.Minute, notFound(&calls)); err != ErrNotFound {
+			t.Fatalf("GetOrSet = %v, want ErrNotFound", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("loader ran %d times, want the miss cached", calls.Load())
+	}
+	if ttl := s.TTL("user:1"); ttl != 10*time.Second {
+		t.Fatalf("miss cached with TTL %v, want NegativeTTL", ttl)
+	}
+	// Found once the miss expired
+	s.FastForward(10 * time.Second)
+	v, err := r.GetOrSet(context.Background(), "user:1", time.Minute, func(context.Context) (string, error) { return "alice", nil })
+	if err != nil || v != "alice" {
+		t.Fatalf("GetOrSet after the miss expired = %q, %v", v, err)
+	}
+
+	// Not cached without NegativeTTL
+	plain, _ := newTestRedis(t)
+	calls.Store(0)
+	for i := 0; i < 2; i++ {
+		if _, err := plain.GetOrSet(context.Background(), "user:1", time.Minute, notFound(&calls)); err != ErrNotFound {
+			t.Fatalf("GetOrSet = %v, want ErrNotFound", err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("loader ran %d times, want the miss not cached", calls.Load())
+	}
+}
+
+type tenantKey struct{}
+
+func TestGetOrSetWaiterCancelled(t *testing.T) {
+	r, s := newTestRedis(t)
+
+	release := make(chan struct{})
+	loaderErr := make(chan error, 1)
+	tenant := make(chan interface{}, 1)
+	loader := func(ctx context.Context) (string, error) {
+		tenant <- ctx.Value(tenantKey{})
+		<-release
+		loaderErr <- ctx.Err()
+		return "loaded", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), tenantKey{}, "acme"))
+	first := make(chan error, 1)
+	go func() {
+		_, err := r.GetOrSet(ctx, "k", time.Minute, loader)
+		first <- err
+	}()
+	if v := <-tenant; v != "acme" {
+		t.Fatalf("loader context value = %v, want the one of the first caller", v)
+	}
+	second := make(chan string, 1)
+	go func() {
+		v, _ := r.GetOrSet(context.Background(), "k", time.Minute, loader)
+		second <- v
+	}()
+
+	cancel()
+	select {
+	case err := <-first:
+		if err != context.Canceled {
+			t.Fatalf("cancelled waiter got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelled waiter still waiting for the loader")
+	}
+
+	close(release)
+	if err := <-loaderErr; err != nil {
+		t.Fatalf("loader context done with %v after the first caller gave up", err)
+	}
+	if v := <-second; v != "loaded" {
+		t.Fatalf("remaining waiter got %q", v)
+	}
+	if v, _ := s.Get("k"); v != "loaded" {
+		t.Fatalf("cached %q, want the value of the detached loader", v)
+	}
+}
+
+func TestGetOrSetLoadTimeout(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr(), LoadTimeout: 50 * time.Millisecond})
+	if err := r.InitClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	_, err := r.GetOrSet(context.Background(), "k", time.Minute, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("GetOrSet = %v, want the loader timed out", err)
+	}
+}
+
+func TestGetOrSetPanic(t *testing.T) {
+	r, _ := newTestRedis(t)
+
+	_, err := r.GetOrSet(context.Background(), "k", time.Minute, func(context.Context) (string, error) {
+		panic("nil map")
+	})
+	if err == nil || !strings.Contains(err.Error(), "loader of k panicked: nil map") {
+		t.Fatalf("GetOrSet = %v, want the panic as error", err)
+	}
+	v, err := r.GetOrSet(context.Background(), "k", time.Minute, func(context.Context) (string, error) {
+		return "recovered", nil
+	})
+	if err != nil || v != "recovered" {
+		t.Fatalf("GetOrSet after a panic = %q, %v", v, err)
+	}
+}
+
+func TestGetOrSetJSON(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	loader := func(context.Context) (order, error) {
+		calls.Add(1)
+		return order{ID: 42, Items: []string{"book"}}, nil
+	}
+	for i := 0; i < 2; i++ {
+		v, err := GetOrSetJSON(ctx, r, "order:42", time.Minute, loader)
+		if err != nil || v.ID != 42 || len(v.Items) != 1 {
+			t.Fatalf("GetOrSetJSON = %+v, %v", v, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("loader ran %d times, want once", calls.Load())
+	}
+	if v, _ := s.Get("order:42"); v != `{"id":42,"items":["book"],"paid":false}` {
+		t.Fatalf("cached %s", v)
+	}
+
+	s.Set("order:43", "{corrupted")
+	if _, err := GetOrSetJSON(ctx, r, "order:43", time.Minute, loader); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Fatalf("GetOrSetJSON of a corrupted value = %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"strings"
+)
+
+// DBSize returns the number of keys in the selected DB, summed over the
+// nodes of a ring
+func (r *redis) DBSize() (int64, error) {
+	shards, err := r.shards()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, shard := range shards {
+		n, err := shard.DBSize().Result()
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
+
+// ServerInfo returns the fields of the INFO reply for section, e.g. "memory"
+// or "clients". An empty section returns the default set of sections.
+// Section headers and blank lines are skipped.
+func (r *redis) ServerInfo(section string) (map[string]string, error) {
+	cl, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []string
+	if section != "" {
+		sections = append(sections, section)
+	}
+	raw, err := cl.Info(sections...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(raw), nil
+}
+
+func parseInfo(raw string) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.IndexByte(line, ':'); i != -1 {
+			info[line[:i]] = line[i+1:]
+		}
+	}
+	return info
+}
@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestDBSize(t *testing.T) {
+	r, s := newTestRedis(t)
+	for i := 0; i < 5; i++ {
+		s.Set(fmt.Sprintf("key:%d", i), "v")
+	}
+	n, err := r.DBSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("DBSize = %d, want 5", n)
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	r, _ := newTestRedis(t)
+	info, err := r.ServerInfo("clients")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients, err := strconv.Atoi(info["connected_clients"])
+	if err != nil || clients < 1 {
+		t.Fatalf("connected_clients = %q, want a positive number", info["connected_clients"])
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	raw := "# Server\r\nredis_version:7.2.0\r\nos:Linux 6.1 x86_64\r\n\r\n# Memory\r\nused_memory:1024\r\nmaxmemory_policy:noeviction\r\n"
+	info := parseInfo(raw)
+	want := map[string]string{
+		"redis_version":    "7.2.0",
+		"os":               "Linux 6.1 x86_64",
+		"used_memory":      "1024",
+		"maxmemory_policy": "noeviction",
+	}
+	if len(info) != len(want) {
+		t.Fatalf("parseInfo = %v, want %v", info, want)
+	}
+	for k, v := range want {
+		if info[k] != v {
+			t.Errorf("%s = %q, want %q", k, info[k], v)
+		}
+	}
+}
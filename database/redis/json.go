@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPayloadTooLarge is returned by SetJSON and GetJSON for the payloads over
+// RedisConfig.MaxJSONBytes
+var ErrPayloadTooLarge = errors.New("redis: JSON payload too large")
+
+// SetJSON stores the JSON encoding of v under key, see Set
+func (r *redis) SetJSON(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("redis: failed to marshal %s: %w", key, err)
+	}
+	if err := r.checkJSONSize(key, len(payload)); err != nil {
+		return err
+	}
+	return r.Set(ctx, key, string(payload), ttl)
+}
+
+// GetJSON decodes the JSON value of key into dest. found is false, with a
+// nil error, when key is not set, dest being left untouched. A value that is
+// not valid JSON is an error.
+func (r *redis) GetJSON(ctx context.Context, key string, dest interface{}) (bool, error) {
+	payload, err := r.Get(ctx, key)
+	if errors.Is(err, ErrNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := r.checkJSONSize(key, len(payload)); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(payload), dest); err != nil {
+		return false, fmt.Errorf("redis: invalid JSON under %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (r *redis) checkJSONSize(key string, n int) error {
+	if limit := r.config.MaxJSONBytes; limit > 0 && n > limit {
+		return fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrPayloadTooLarge, key, n, limit)
+	}
+	return nil
+}
+
+// GetJSONValue is GetJSON returning the value decoded as a T, the zero T
+// when found is false
+func GetJSONValue[T any](ctx context.Context, r Redis, key string) (T, bool, error) {
+	var v T
+	found, err := r.GetJSON(ctx, key, &v)
+	if err != nil || !found {
+		var zero T
+		return zero, false, err
+	}
+	return v, true, nil
+}
@@ -0,0 +1,158 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type order struct {
+	ID    int      `json:"id"`
+	Items []string `json:"items"`
+	Paid  bool     `json:"paid"`
+}
+
+func TestJSONStruct(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+
+	want := order{ID: 42, Items: []string{"book", "pen"}, Paid: true}
+	if err := r.SetJSON(ctx, "order:42", want, time.Minute); err != nil {
+		t.Fatalf("SetJSON: %v", err)
+	}
+	if v, _ := s.Get("order:42"); v != `{"id":42,"items":["book","pen"],"paid":true}` {
+		t.Fatalf("stored %s", v)
+	}
+	if ttl := s.TTL("order:42"); ttl != time.Minute {
+		t.Fatalf("TTL = %v, want 1m", ttl)
+	}
+
+	var got order
+	found, err := r.GetJSON(ctx, "order:42", &got)
+	if err != nil || !found || !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetJSON = %+v, %v, %v, want %+v", got, found, err, want)
+	}
+	value, found, err := GetJSONValue[order](ctx, r, "order:42")
+	if err != nil || !found || !reflect.DeepEqual(value, want) {
+		t.Fatalf("GetJSONValue = %+v, %v, %v", value, found, err)
+	}
+	ptr, found, err := GetJSONValue[*order](ctx, r, "order:42")
+	if err != nil || !found || ptr == nil || !reflect.DeepEqual(*ptr, want) {
+		t.Fatalf("GetJSONValue of a pointer = %+v, %v, %v", ptr, found, err)
+	}
+}
+
+func TestJSONSlice(t *testing.T) {
+	r, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	want := []order{{ID: 1}, {ID: 2, Items: []string{"mug"}}}
+	if err := r.SetJSON(ctx, "orders", want, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, found, err := GetJSONValue[[]order](ctx, r, "orders")
+	if err != nil || !found || !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetJSONValue = %+v, %v, %v, want %+v", got, found, err, want)
+	}
+
+	// An empty slice is a hit, not a miss
+	if err := r.SetJSON(ctx, "none", []order{}, 0); err != nil {
+		t.Fatal(err)
+	}
+	got, found, err = GetJSONValue[[]order](ctx, r, "none")
+	if err != nil || !found || got == nil || len(got) != 0 {
+		t.Fatalf("GetJSONValue of an empty slice = %#v, %v, %v", got, found, err)
+	}
+}
+
+func TestJSONMiss(t *testing.T) {
+	r, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	dest := order{ID: 7}
+	found, err := r.GetJSON(ctx, "missing", &dest)
+	if err != nil || found {
+		t.Fatalf("GetJSON of a missing key = %v, %v, want a miss", found, err)
+	}
+	if dest.ID != 7 {
+		t.Fatalf("dest changed on a miss: %+v", dest)
+	}
+	v, found, err := GetJSONValue[order](ctx, r, "missing")
+	if err != nil || found || !reflect.DeepEqual(v, order{}) {
+		t.Fatalf("GetJSONValue of a missing key = %+v, %v, %v", v, found, err)
+	}
+}
+
+func TestJSONCorrupted(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+
+	for key, payload := range map[string]string{
+		"empty":     "",
+		"truncated": `{"id":42,"items":["bo`,
+		"text":      "not json",
+		"type":      `{"id":"forty-two"}`,
+	} {
+		s.Set(key, payload)
+		v, found, err := GetJSONValue[order](ctx, r, key)
+		if err == nil || found || !strings.Contains(err.Error(), "invalid JSON under "+key) {
+			t.Errorf("%s: GetJSONValue = %+v, %v, %v, want an invalid JSON error", key, v, found, err)
+		}
+		if !reflect.DeepEqual(v, order{}) {
+			t.Errorf("%s: partially decoded value %+v returned", key, v)
+		}
+	}
+
+	if err := r.SetJSON(ctx, "nan", math.NaN(), 0); err == nil || !strings.Contains(err.Error(), "failed to marshal nan") {
+		t.Fatalf("SetJSON of NaN = %v, want a marshal error", err)
+	}
+	if s.Exists("nan") {
+		t.Fatal("value stored despite the marshal error")
+	}
+}
+
+func TestJSONMaxBytes(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr(), MaxJSONBytes: 64})
+	if err := r.InitClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ctx := context.Background()
+
+	if err := r.SetJSON(ctx, "small", order{ID: 1}, 0); err != nil {
+		t.Fatalf("SetJSON under the limit: %v", err)
+	}
+	large := order{ID: 2, Items: []string{strings.Repeat("x", 100)}}
+	if err := r.SetJSON(ctx, "large", large, 0); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("SetJSON over the limit = %v, want ErrPayloadTooLarge", err)
+	}
+	if s.Exists("large") {
+		t.Fatal("payload over the limit stored")
+	}
+
+	// Written by another client
+	s.Set("large", `{"id":2,"items":["`+strings.Repeat("x", 100)+`"]}`)
+	if _, _, err := GetJSONValue[order](ctx, r, "large"); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("GetJSONValue over the limit = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+func TestJSONConnectionFailure(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+	s.Close()
+
+	if err := r.SetJSON(ctx, "k", order{}, 0); err == nil {
+		t.Fatal("SetJSON succeeded with the server down")
+	}
+	if found, err := r.GetJSON(ctx, "k", &order{}); err == nil || found {
+		t.Fatalf("GetJSON = %v, %v, want a connection error", found, err)
+	}
+}
@@ -0,0 +1,24 @@
+package redis
+
+import (
+	"errors"
+)
+
+// LTrim trims the list stored at key to the elements between start and stop,
+// both inclusive. Negative indexes count from the end of the list.
+func (r *redis) LTrim(key string, start, stop int64) error {
+	cl, err := r.conn()
+	if err != nil {
+		return err
+	}
+	return cl.LTrim(key, start, stop).Err()
+}
+
+// CapList keeps only the last maxLen entries of the list stored at key, the
+// most recent ones when entries are appended with RPUSH
+func (r *redis) CapList(key string, maxLen int64) error {
+	if maxLen <= 0 {
+		return errors.New("redis: maxLen must be positive")
+	}
+	return r.LTrim(key, -maxLen, -1)
+}
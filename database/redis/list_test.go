@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestCapList(t *testing.T) {
+	r, s := newTestRedis(t)
+	for i := 0; i < 100; i++ {
+		s.RPush("events", strconv.Itoa(i))
+	}
+
+	if err := r.CapList("events", 10); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"90", "91", "92", "93", "94", "95", "96", "97", "98", "99"}
+	if fmt.Sprint(list) != fmt.Sprint(want) {
+		t.Fatalf("list = %v, want %v", list, want)
+	}
+
+	// A shorter list is left as is
+	if err := r.CapList("events", 50); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List("events"); len(list) != 10 {
+		t.Fatalf("list has %d entries, want 10", len(list))
+	}
+}
+
+func TestCapListInvalid(t *testing.T) {
+	r, _ := newTestRedis(t)
+	if err := r.CapList("events", 0); err == nil {
+		t.Fatal("CapList(0) succeeded")
+	}
+}
+
+func TestLTrim(t *testing.T) {
+	r, s := newTestRedis(t)
+	s.RPush("list", "a", "b", "c", "d")
+	if err := r.LTrim("list", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := s.List("list"); fmt.Sprint(list) != "[b c]" {
+		t.Fatalf("list = %v, want [b c]", list)
+	}
+}
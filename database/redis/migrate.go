@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"strings"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// PTTL replies -2 for missing keys and -1 for keys without expiry, which
+// go-redis scales to milliseconds
+const (
+	keyMissing    = -2 * time.Millisecond
+	keyPersistent = -time.Millisecond
+)
+
+// MigrateKeys copies every key matching pattern to dst using DUMP and RESTORE,
+// which preserves the type and the remaining TTL of each key. Keys are read
+// and written batchSize at a time. Keys already present in dst are replaced
+// when overwrite is set and skipped otherwise. It returns the number of keys
+// written to dst.
+func (r *redis) MigrateKeys(dst Redis, pattern string, batchSize int64, overwrite bool) (int64, error) {
+	dstClient, err := clientOf(dst)
+	if err != nil {
+		return 0, err
+	}
+
+	var migrated int64
+	err = r.scan(pattern, batchSize, func(keys []string) error {
+		cl, err := r.conn()
+		if err != nil {
+			return err
+		}
+
+		pipe := cl.Pipeline()
+		dumps := make([]*redisLib.StringCmd, len(keys))
+		ttls := make([]*redisLib.DurationCmd, len(keys))
+		for i, key := range keys {
+			dumps[i] = pipe.Dump(key)
+			ttls[i] = pipe.PTTL(key)
+		}
+		// Keys expiring between SCAN and DUMP reply nil, they are skipped
+		if _, err := pipe.Exec(); err != nil && err != redisLib.Nil {
+			return err
+		}
+
+		pipe = dstClient.Pipeline()
+		restores := make([]*redisLib.StatusCmd, 0, len(keys))
+		for i, key := range keys {
+			switch err := dumps[i].Err(); {
+			case err == redisLib.Nil:
+				continue
+			case err != nil:
+				return err
+			}
+			if err := ttls[i].Err(); err != nil {
+				return err
+			}
+			ttl := ttls[i].Val()
+			switch ttl {
+			case keyMissing:
+				// Expired between DUMP and PTTL
+				continue
+			case keyPersistent:
+				// RESTORE takes 0 for keys without expiry
+				ttl = 0
+			}
+			if overwrite {
+				restores = append(restores, pipe.RestoreReplace(key, ttl, dumps[i].Val()))
+			} else {
+				restores = append(restores, pipe.Restore(key, ttl, dumps[i].Val()))
+			}
+		}
+		if len(restores) == 0 {
+			return nil
+		}
+		_, err = pipe.Exec()
+
+		for _, cmd := range restores {
+			err := cmd.Err()
+			if err == nil {
+				migrated++
+				continue
+			}
+			if !overwrite && isBusyKey(err) {
+				continue
+			}
+			return err
+		}
+		if err != nil && !(!overwrite && isBusyKey(err)) {
+			return err
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// isBusyKey reports whether err is the reply of RESTORE for a key that
+// already exists
+func isBusyKey(err error) bool {
+	return strings.HasPrefix(err.Error(), "BUSYKEY")
+}
@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+func TestMigrateKeys(t *testing.T) {
+	src, dst := realRedisDB(t, 14), realRedisDB(t, 15)
+	s, d := src.GetUniversalClient(), dst.GetUniversalClient()
+	for _, cl := range []redisLib.UniversalClient{s, d} {
+		if err := cl.FlushDB().Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Set("m:string", "v", time.Hour)
+	s.RPush("m:list", "a", "b", "c")
+	s.HSet("m:hash", "f", "v")
+	s.SAdd("m:set", "a", "b")
+	s.ZAdd("m:zset", redisLib.Z{Score: 1, Member: "a"}, redisLib.Z{Score: 2, Member: "b"})
+	s.Set("other", "v", 0)
+	// Present in dst: kept without overwrite
+	d.Set("m:hash", "old", 0)
+
+	n, err := src.MigrateKeys(dst, "m:*", 2, false)
+	if err != nil {
+		t.Fatalf("MigrateKeys: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("migrated %d keys, want 4", n)
+	}
+	if v := d.Get("m:hash").Val(); v != "old" {
+		t.Fatalf("existing key replaced without overwrite: %q", v)
+	}
+	if d.Exists("other").Val() != 0 {
+		t.Fatal("key not matching the pattern migrated")
+	}
+
+	n, err = src.MigrateKeys(dst, "m:*", 2, true)
+	if err != nil || n != 5 {
+		t.Fatalf("MigrateKeys with overwrite = %d, %v, want 5", n, err)
+	}
+	for key, get := range map[string]func(redisLib.UniversalClient) interface{}{
+		"m:string": func(cl redisLib.UniversalClient) interface{} { return cl.Get("m:string").Val() },
+		"m:list":   func(cl redisLib.UniversalClient) interface{} { return cl.LRange("m:list", 0, -1).Val() },
+		"m:hash":   func(cl redisLib.UniversalClient) interface{} { return cl.HGetAll("m:hash").Val() },
+		"m:set":    func(cl redisLib.UniversalClient) interface{} { return len(cl.SMembers("m:set").Val()) },
+		"m:zset":   func(cl redisLib.UniversalClient) interface{} { return cl.ZRangeWithScores("m:zset", 0, -1).Val() },
+	} {
+		if want, got := get(s), get(d); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+		if want, got := s.Type(key).Val(), d.Type(key).Val(); got != want {
+			t.Errorf("%s is a %s, want %s", key, got, want)
+		}
+	}
+	if ttl := d.TTL("m:string").Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("m:string TTL = %v, want about an hour", ttl)
+	}
+	if ttl := d.TTL("m:list").Val(); ttl != -time.Second {
+		t.Errorf("m:list TTL = %v, want none", ttl)
+	}
+}
+
+func TestMigrateKeysNotConnected(t *testing.T) {
+	src, _ := newTestRedis(t)
+	dst := NewRedis(RedisConfig{Host: "127.0.0.1:1"})
+	if _, err := src.MigrateKeys(dst, "*", 10, false); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("MigrateKeys to an unconnected client = %v, want ErrNotConnected", err)
+	}
+}
@@ -0,0 +1,318 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// defaultKeepalive is the ping interval of idle subscriptions
+const defaultKeepalive = 30 * time.Second
+
+var errNoChannels = errors.New("redis: at least one channel is required")
+
+// SubscribeOptions configures a subscription started by Subscribe
+type SubscribeOptions struct {
+	// Keepalive is the interval at which an idle subscription connection is
+	// pinged, so load balancers do not drop it. When a ping fails or is not
+	// answered before the next interval the subscription reconnects and
+	// subscribes again. Defaults to 30s.
+	Keepalive time.Duration
+	// Filter, when set, is called for every message and only the messages it
+	// returns true for are delivered to the handler
+	Filter func(channel, payload string) bool
+	// OnFiltered, when set, is called for every message discarded by Filter,
+	// e.g. to increment a metric
+	OnFiltered func(channel string)
+}
+
+// Subscription is a subscription started by Subscribe
+type Subscription struct {
+	channels  []string
+	subscribe func(channels ...string) *redisLib.PubSub
+	keepalive time.Duration
+	filter    func(channel, payload string) bool
+	filtered  func(channel string)
+
+	mu     sync.Mutex
+	pubsub *redisLib.PubSub
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Subscribe subscribes to channels and calls handler with every message
+// received, from a single goroutine. The subscription is kept alive and
+// reconnected as configured by opts until it is closed.
+//
+// Subscriptions are not supported on rings: go-redis routes every channel of a
+// ring subscription to the shard of its first channel, while publishers reach
+// the shard of each channel, so messages would be lost.
+func (r *redis) Subscribe(handler func(channel, payload string), opts SubscribeOptions, channels ...string) (*Subscription, error) {
+	if len(channels) == 0 {
+		return nil, errNoChannels
+	}
+	c, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	if c.ring != nil {
+		return nil, errors.New("redis: subscriptions are not supported on rings")
+	}
+	cl := c.cmd
+	if opts.Keepalive <= 0 {
+		opts.Keepalive = defaultKeepalive
+	}
+
+	s := &Subscription{
+		channels:  channels,
+		subscribe: cl.Subscribe,
+		keepalive: opts.Keepalive,
+		filter:    opts.Filter,
+		filtered:  opts.OnFiltered,
+		pubsub:    cl.Subscribe(channels...),
+		done:      make(chan struct{}),
+	}
+	if _, err := s.pubsub.Receive(); err != nil {
+		s.pubsub.Close()
+		return nil, err
+	}
+
+	go s.run(handler)
+	return s, nil
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		err = s.pubsub.Close()
+	})
+	return err
+}
+
+// Add subscribes to more channels
+func (s *Subscription) Add(channels ...string) error {
+	if len(channels) == 0 {
+		return errNoChannels
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.pubsub.Subscribe(channels...); err != nil {
+		return err
+	}
+	s.channels = append(s.channels, channels...)
+	return nil
+}
+
+// Remove unsubscribes from channels
+func (s *Subscription) Remove(channels ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.pubsub.Unsubscribe(channels...); err != nil {
+		return err
+	}
+	kept := s.channels[:0]
+	for _, ch := range s.channels {
+		if !containsString(channels, ch) {
+			kept = append(kept, ch)
+		}
+	}
+	s.channels = kept
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) run(handler func(channel, payload string)) {
+	var pinged bool
+	var failures int
+	for {
+		s.mu.Lock()
+		pubsub := s.pubsub
+		channels := append([]string(nil), s.channels...)
+		s.mu.Unlock()
+
+		msg, err := pubsub.ReceiveTimeout(s.keepalive)
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() && !pinged {
+				// Idle for a whole interval, the pong has until the next
+				// one to arrive
+				pinged = true
+				if err = pubsub.Ping(); err == nil {
+					continue
+				}
+			}
+			failures++
+			logger.Warnf("Redis subscription to %v lost, reconnecting: %v", channels, err)
+			if !s.reconnect(failures) {
+				return
+			}
+			pinged = false
+			continue
+		}
+
+		pinged = false
+		failures = 0
+		if m, ok := msg.(*redisLib.Message); ok {
+			if s.filter != nil && !s.filter(m.Channel, m.Payload) {
+				if s.filtered != nil {
+					s.filtered(m.Channel)
+				}
+				continue
+			}
+			handler(m.Channel, m.Payload)
+		}
+	}
+}
+
+// reconnect replaces the connection of the subscription after a backoff
+// growing with the number of consecutive failures. It returns false when the
+// subscription was closed meanwhile.
+func (s *Subscription) reconnect(failures int) bool {
+	backoff := time.Duration(failures) * 100 * time.Millisecond
+	if backoff > 5*time.Second {
+		backoff = 5 * time.Second
+	}
+	select {
+	case <-s.done:
+		return false
+	case <-time.After(backoff):
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	s.pubsub.Close()
+	// Remove may have left no channel, the next Add subscribes on the new
+	// connection then
+	s.pubsub = s.subscribe(s.channels...)
+	return true
+}
+
+// OnExpired subscribes to the expired keyevent notifications
+// (__keyevent@<db>__:expired) of the configured DB and calls handler with
+// every expired key that matches pattern. Pattern uses the Redis glob syntax.
+//
+// Redis only publishes these events when keyspace notifications are enabled
+// on the server, e.g. `CONFIG SET notify-keyspace-events Ex` or the matching
+// redis.conf entry. Without it the subscription succeeds but never fires.
+//
+// The returned function stops the subscription.
+func (r *redis) OnExpired(pattern string, handler func(key string)) (func() error, error) {
+	channel := fmt.Sprintf("__keyevent@%d__:expired", r.config.DB)
+	sub, err := r.Subscribe(func(_, key string) {
+		if matchPattern(pattern, key) {
+			handler(key)
+		}
+	}, SubscribeOptions{}, channel)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Close, nil
+}
+
+// matchPattern reports whether key matches the Redis glob pattern, supporting
+// the same `*`, `?`, `[...]` and `\` escapes as KEYS, SCAN and PSUBSCRIBE.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+			if not {
+				pattern = pattern[1:]
+			}
+			match := false
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					if pattern[0] == key[0] {
+						match = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					lo, hi := pattern[0], pattern[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					if key[0] >= lo && key[0] <= hi {
+						match = true
+					}
+					pattern = pattern[2:]
+				default:
+					if pattern[0] == key[0] {
+						match = true
+					}
+				}
+				pattern = pattern[1:]
+			}
+			if not {
+				match = !match
+			}
+			if !match {
+				return false
+			}
+			key = key[1:]
+			if len(pattern) == 0 {
+				return len(key) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(key) == 0
+}
@@ -0,0 +1,228 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// realRedis connects to the server at REDIS_ADDR, for the features the
+// in-memory server lacks, and skips the test when it is not set
+func realRedis(t *testing.T) Redis {
+	t.Helper()
+	return realRedisDB(t, 0)
+}
+
+// realRedisDB is realRedis on database db
+func realRedisDB(t *testing.T, db int) Redis {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	r := NewRedis(RedisConfig{Host: addr, DB: db})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestOnExpired(t *testing.T) {
+	r := realRedis(t)
+	cl := r.GetUniversalClient()
+	if err := cl.ConfigSet("notify-keyspace-events", "Ex").Err(); err != nil {
+		t.Fatalf("CONFIG SET: %v", err)
+	}
+
+	expired := make(chan string, 10)
+	stop, err := r.OnExpired("test:expire:*", func(key string) {
+		expired <- key
+	})
+	if err != nil {
+		t.Fatalf("OnExpired: %v", err)
+	}
+	defer stop()
+
+	ctx := context.Background()
+	if err := r.Set(ctx, "test:other", "v", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "test:expire:1", "v", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// Expired keys are only noticed when accessed or by the periodic
+	// sampling of the server
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case key := <-expired:
+			if key != "test:expire:1" {
+				t.Fatalf("got expiry of %q", key)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+			cl.Exists("test:expire:1", "test:other")
+		case <-deadline:
+			t.Fatal("expiry not notified")
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"*", "anything", true},
+		{"session:*", "session:42", true},
+		{"session:*", "user:42", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a**b", "ab", true},
+		{"", "", true},
+		{"", "a", false},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+// receive waits for the next message of ch
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestSubscribeKeepalive(t *testing.T) {
+	r, _ := newTestRedis(t)
+	got := make(chan string, 10)
+	sub, err := r.Subscribe(func(channel, payload string) {
+		got <- channel + ":" + payload
+	}, SubscribeOptions{Keepalive: 20 * time.Millisecond}, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Idle for several keepalive intervals
+	time.Sleep(150 * time.Millisecond)
+	if err := r.Publish("events", "after idle"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, got); msg != "events:after idle" {
+		t.Fatalf("got %q", msg)
+	}
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	r, s := newTestRedis(t)
+	got := make(chan string, 10)
+	sub, err := r.Subscribe(func(_, payload string) {
+		got <- payload
+	}, SubscribeOptions{Keepalive: 20 * time.Millisecond}, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	s.Close()
+	time.Sleep(50 * time.Millisecond)
+	if err := s.Restart(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish until the subscription is back
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		r.Publish("events", "back")
+		select {
+		case msg := <-got:
+			if msg != "back" {
+				t.Fatalf("got %q", msg)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	t.Fatal("subscription not restored")
+}
+
+func TestSubscribeNoChannels(t *testing.T) {
+	r, _ := newTestRedis(t)
+	if _, err := r.Subscribe(func(_, _ string) {}, SubscribeOptions{}); err == nil {
+		t.Fatal("Subscribe without channels succeeded")
+	}
+
+	sub, err := r.Subscribe(func(_, _ string) {}, SubscribeOptions{}, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if err := sub.Add(); err == nil {
+		t.Fatal("Add without channels succeeded")
+	}
+
+	d := NewDispatcher(r, SubscribeOptions{})
+	if _, err := d.Register("", func(_, _ string) {}); err == nil {
+		t.Fatal("Register of an empty channel succeeded")
+	}
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	r, _ := newTestRedis(t)
+	got := make(chan string, 10)
+	var filtered int32
+	sub, err := r.Subscribe(func(_, payload string) {
+		got <- payload
+	}, SubscribeOptions{
+		Filter: func(_, payload string) bool {
+			return strings.HasPrefix(payload, "keep")
+		},
+		OnFiltered: func(channel string) {
+			if channel == "events" {
+				atomic.AddInt32(&filtered, 1)
+			}
+		},
+	}, "events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	for _, payload := range []string{"drop 1", "keep 1", "drop 2", "keep 2"} {
+		if err := r.Publish("events", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"keep 1", "keep 2"} {
+		if msg := receive(t, got); msg != want {
+			t.Fatalf("got %q, want %s", msg, want)
+		}
+	}
+	// Messages are handled in order, both drops were seen by now
+	if n := atomic.LoadInt32(&filtered); n != 2 {
+		t.Fatalf("%d messages counted as filtered, want 2", n)
+	}
+	select {
+	case msg := <-got:
+		t.Fatalf("filtered message %q delivered", msg)
+	default:
+	}
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"errors"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// tokenBucketScript refills the bucket stored at KEYS[1] for the time elapsed
+// since the last call and takes ARGV[3] tokens from it when enough are left.
+// ARGV: capacity, refill per second, tokens to take. The time is the one of
+// the server, so the clocks of the clients don't matter.
+var tokenBucketScript = redisLib.NewScript(`
+redis.replicate_commands()
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local requested = tonumber(ARGV[3])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + elapsed * rate / 1000)
+
+local allowed = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate * 1000) + 1000)
+return allowed
+`)
+
+// TokenBucket is a distributed token bucket rate limiter backed by Redis
+type TokenBucket struct {
+	redis Redis
+}
+
+// NewTokenBucket is a factory that return a token bucket limiter using the given client
+func NewTokenBucket(r Redis) *TokenBucket {
+	return &TokenBucket{redis: r}
+}
+
+// Take atomically takes n tokens from the bucket stored at key. The bucket
+// holds at most capacity tokens and refills at refillPerSec tokens per second.
+// It reports whether the tokens were available.
+func (t *TokenBucket) Take(key string, capacity int, refillPerSec float64, n int) (bool, error) {
+	if capacity <= 0 || refillPerSec <= 0 || n <= 0 {
+		return false, errors.New("redis: capacity, refill rate and tokens must be positive")
+	}
+
+	cl, err := clientOf(t.redis)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tokenBucketScript.Run(cl, []string{key}, capacity, refillPerSec, n).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	r, s := newTestRedis(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.SetTime(now)
+	tb := NewTokenBucket(r)
+
+	take := func(n int) bool {
+		t.Helper()
+		ok, err := tb.Take("bucket", 3, 2, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	for i := 0; i < 3; i++ {
+		if !take(1) {
+			t.Fatalf("take %d denied within capacity", i+1)
+		}
+	}
+	if take(1) {
+		t.Fatal("take beyond capacity allowed")
+	}
+
+	// 2 tokens per second, half a second refills one
+	s.SetTime(now.Add(500 * time.Millisecond))
+	if !take(1) {
+		t.Fatal("take denied after refill")
+	}
+	if take(1) {
+		t.Fatal("refill gave more than one token")
+	}
+
+	// The bucket never holds more than its capacity
+	s.SetTime(now.Add(time.Hour))
+	if !take(3) {
+		t.Fatal("full bucket denied its capacity")
+	}
+	if take(1) {
+		t.Fatal("bucket refilled past its capacity")
+	}
+}
+
+func TestTokenBucketMoreThanCapacity(t *testing.T) {
+	r, _ := newTestRedis(t)
+	ok, err := NewTokenBucket(r).Take("bucket", 3, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("took more tokens than the capacity")
+	}
+}
+
+func TestTokenBucketInvalid(t *testing.T) {
+	r, _ := newTestRedis(t)
+	tb := NewTokenBucket(r)
+	for _, args := range [][3]float64{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}} {
+		if _, err := tb.Take("bucket", int(args[0]), args[1], int(args[2])); err == nil {
+			t.Errorf("Take(%v) succeeded", args)
+		}
+	}
+}
+
+func TestTokenBucketNotConnected(t *testing.T) {
+	tb := NewTokenBucket(NewRedis(RedisConfig{Host: "127.0.0.1:1"}))
+	if _, err := tb.Take("bucket", 1, 1, 1); err != ErrNotConnected {
+		t.Fatalf("err = %v, want ErrNotConnected", err)
+	}
+}
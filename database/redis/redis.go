@@ -1,6 +1,15 @@
 package redis
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	redisLib "github.com/go-redis/redis"
@@ -9,103 +18,559 @@ import (
 )
 
 var (
-	logger = logs.NewCommonLog()
+	logger logs.Logger = logs.NewCommonLog()
+
+	// ErrNotConnected is returned by operations issued before InitClient
+	// succeeded
+	ErrNotConnected = errors.New("redis: client is not connected")
+
+	// ErrNil is returned by Get for missing keys, telling a miss apart from
+	// a failure. It is the redis.Nil of go-redis.
+	ErrNil = redisLib.Nil
 )
 
+// SetLogger replaces the logger of the package, e.g. with the mock of the
+// logs/mocks package in tests. Call it before InitClient.
+func SetLogger(l logs.Logger) {
+	logger = l
+}
+
 type Redis interface {
 	InitClient() error
+	Set(ctx context.Context, key, payload string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, keys ...string) (int64, error)
+	SetJSON(ctx context.Context, key string, v interface{}, ttl time.Duration) error
+	GetJSON(ctx context.Context, key string, dest interface{}) (found bool, err error)
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (string, error)) (string, error)
+	// Deprecated: use Set, which returns the error
 	SetRedisValue(key string, payload string, ttl time.Duration)
+	// Deprecated: use Get, which returns the error
 	GetRedisValue(key string) string
+	// Deprecated: use Delete, which returns the error
 	DeleteRedisValue(key string) int64
+	GetSet(key, value string) (old string, existed bool, err error)
+	SetKeepTTL(key, value string) error
+	Rotate(key, newValue string, ttl time.Duration) (old string, err error)
 	GetClient() *redisTraceLib.Client
+	GetUniversalClient() redisLib.UniversalClient
+	ExpireTime(key string) (time.Time, error)
+	PExpireTime(key string) (time.Time, error)
+	LTrim(key string, start, stop int64) error
+	CapList(key string, maxLen int64) error
+	BitField(key string, ops ...BitFieldOp) ([]int64, error)
+	Subscribe(handler func(channel, payload string), opts SubscribeOptions, channels ...string) (*Subscription, error)
+	Publish(channel, payload string) error
+	PublishReliable(channel, payload string) error
+	SubscribeReliable(channel, group, consumer string, handler func(channel, payload string) error) (*ReliableSubscription, error)
+	OnExpired(pattern string, handler func(key string)) (func() error, error)
+	KeysWithoutTTL(pattern string, count int64) ([]string, error)
+	DBSize() (int64, error)
+	ServerInfo(section string) (map[string]string, error)
+	Export(pattern string, w io.Writer) (int64, error)
+	MigrateKeys(dst Redis, pattern string, batchSize int64, overwrite bool) (int64, error)
+	Close() error
 }
 
-type RedisConfig struct {
-	Host        string
-	Password    string
-	DB          int
-	PoolSize    int
-	ReadTimeout time.Duration
+type redis struct {
+	config    RedisConfig
+	ringAddrs map[string]string
+
+	mu     sync.Mutex
+	client atomic.Pointer[connection]
+
+	// flights dedupes the loads of GetOrSet
+	flights flightGroup
+	// inflight holds a slot per command run by do, sized to the pool as
+	// commands can't run faster than the connections allow. It is made by the
+	// first connect, once the pool size is validated, and kept across
+	// reconnections as abandoned commands release their slots into it.
+	inflight chan struct{}
 }
 
-type redis struct {
-	host        string
-	password    string
-	db          int
-	poolSize    int
-	readTimeout time.Duration
-	client      *redisTraceLib.Client
+// connection is an established client: the traced single node client, or a
+// ring sharding over several nodes
+type connection struct {
+	cmd    redisLib.UniversalClient
+	traced *redisTraceLib.Client
+	ring   *redisLib.Ring
 }
 
 // NewRedis is a factory that return interface of its implementation
 func NewRedis(config RedisConfig) Redis {
-	return &redis{
-		host:        config.Host,
-		password:    config.Password,
-		db:          config.DB,
-		poolSize:    config.PoolSize,
-		readTimeout: config.ReadTimeout,
-	}
+	config.ApplyDefaults()
+	return &redis{config: config}
+}
+
+// NewRedisRing is a factory that return a client sharding keys over the named
+// nodes in addrs by consistent hashing, e.g. {"shard1": "10.0.0.1:6379"}.
+// Host is ignored, the other settings of config apply to every node.
+// Unlike NewRedis, ring commands are not traced and GetClient returns nil.
+func NewRedisRing(addrs map[string]string, config RedisConfig) Redis {
+	r := NewRedis(config).(*redis)
+	r.ringAddrs = addrs
+	return r
 }
 
 func (r *redis) InitClient() error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if r.config.Lazy {
+		logger.Info("Redis connection will be opened on first use...")
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.connect()
+}
+
+// Close closes the connection pool. Commands issued afterwards fail with
+// ErrNotConnected, unless the client is lazy and reconnects on first use.
+func (r *redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.client.Swap(nil)
+	if c == nil {
+		return nil
+	}
+	logger.Info("Closing redis connection...")
+	return c.cmd.Close()
+}
 
+// conn returns the connected client, connecting first in lazy mode
+func (r *redis) conn() (redisLib.UniversalClient, error) {
+	c, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	return c.cmd, nil
+}
+
+// connCtx returns the connected client bound to ctx, so the spans of its
+// commands are children of the span of ctx, or ctx.Err() without connecting
+// when ctx is already done
+func (r *redis) connCtx(ctx context.Context) (redisLib.Cmdable, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c.traced != nil:
+		return c.traced.WithContext(ctx), nil
+	case c.ring != nil:
+		return c.ring.WithContext(ctx), nil
+	}
+	return c.cmd, nil
+}
+
+// do runs cmd and returns its error, or ctx.Err() as soon as ctx is done,
+// go-redis having no way to abort a command in flight. The command then
+// completes in the background, its results ignored.
+//
+// Each command runs in its own goroutine, so at most PoolSize of them run at
+// once, abandoned ones included, the next ones waiting for a slot until ctx
+// is done. A stuck server thus can't pile up goroutines.
+func (r *redis) do(ctx context.Context, cmd func() error) error {
+	if ctx.Done() == nil {
+		return cmd()
+	}
+	select {
+	case r.inflight <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-r.inflight }()
+		done <- cmd()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shards returns the client of every node commands are spread over
+func (r *redis) shards() ([]redisLib.Cmdable, error) {
+	c, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	if c.ring == nil {
+		return []redisLib.Cmdable{c.cmd}, nil
+	}
+
+	var mu sync.Mutex
+	var shards []redisLib.Cmdable
+	err = c.ring.ForEachShard(func(client *redisLib.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		shards = append(shards, client)
+		return nil
+	})
+	return shards, err
+}
+
+func (r *redis) connection() (*connection, error) {
+	if c := r.client.Load(); c != nil {
+		return c, nil
+	}
+	if !r.config.Lazy {
+		return nil, ErrNotConnected
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c := r.client.Load(); c != nil {
+		return c, nil
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	if err := r.connect(); err != nil {
+		return nil, err
+	}
+	return r.client.Load(), nil
+}
+
+// connect opens and pings a new client, r.config must be validated and r.mu
+// held
+func (r *redis) connect() error {
 	logger.Info("Start open redis connection...")
 
-	redisOpt := &redisLib.Options{
-		Addr:     r.host,
-		Password: r.password,
+	if r.inflight == nil {
+		slots := r.config.PoolSize
+		// The pool size applies to every node
+		if len(r.ringAddrs) > 1 {
+			slots *= len(r.ringAddrs)
+		}
+		r.inflight = make(chan struct{}, slots)
 	}
 
-	if r.db != 0 {
-		redisOpt.DB = r.db
-	} else {
-		redisOpt.DB = 0
+	if r.ringAddrs != nil {
+		return r.connectRing()
 	}
 
-	if r.poolSize != 0 {
-		redisOpt.PoolSize = r.poolSize
+	opt, err := r.options()
+	if err != nil {
+		return err
+	}
+	addr := opt.Addr
+	var cl *redisTraceLib.Client
+	if r.config.Sentinel.enabled() {
+		addr = "the master " + r.config.Sentinel.MasterName
+		cl = redisTraceLib.WrapClient(redisLib.NewFailoverClient(failoverOptions(r.config.Sentinel, opt)))
 	} else {
-		redisOpt.PoolSize = 64
+		cl = redisTraceLib.NewClient(opt)
 	}
 
-	if r.readTimeout != 0 {
-		redisOpt.ReadTimeout = r.readTimeout
-	} else {
-		redisOpt.ReadTimeout = 10 * time.Second
+	_, err = cl.Ping().Result()
+	if err != nil {
+		cl.Close()
+		return r.connectError(addr, err)
 	}
+	r.client.Store(&connection{cmd: cl, traced: cl})
 
-	cl := redisTraceLib.NewClient(redisOpt)
+	return nil
+}
+
+// connectError explains the failure of the first command sent to addr,
+// telling a failed TLS handshake apart
+func (r *redis) connectError(addr string, err error) error {
+	if !r.config.tlsEnabled() {
+		return err
+	}
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) || errors.As(err, &invalid) ||
+		errors.As(err, &recordHeader) || strings.HasPrefix(err.Error(), "tls: ") {
+		return fmt.Errorf("redis: TLS handshake with %s failed: %w", addr, err)
+	}
+	return err
+}
 
-	_, err := cl.Ping().Result()
+// options builds the go-redis options of the single node client
+func (r *redis) options() (*redisLib.Options, error) {
+	tlsConfig, err := r.config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	opt := &redisLib.Options{
+		Addr:        r.config.Host,
+		Password:    r.config.Password,
+		DB:          r.config.DB,
+		PoolSize:    r.config.PoolSize,
+		ReadTimeout: r.config.ReadTimeout,
+		PoolTimeout: r.config.PoolTimeout,
+		TLSConfig:   tlsConfig,
+	}
+
+	if r.config.Username != "" {
+		// go-redis v6 only sends AUTH <password>, and SELECT before the
+		// OnConnect hook, so both are issued here with the ACL username
+		username, password, db := r.config.Username, r.config.Password, r.config.DB
+		opt.Password = ""
+		opt.DB = 0
+		opt.OnConnect = func(cn *redisLib.Conn) error {
+			auth := redisLib.NewStatusCmd("auth", username, password)
+			cn.Process(auth)
+			if err := auth.Err(); err != nil {
+				return err
+			}
+			if db > 0 {
+				return cn.Select(db).Err()
+			}
+			return nil
+		}
+	}
+
+	return opt, nil
+}
+
+// failoverOptions builds the options of a client following the master of
+// sentinel, with the settings of opt
+func failoverOptions(sentinel SentinelConfig, opt *redisLib.Options) *redisLib.FailoverOptions {
+	return &redisLib.FailoverOptions{
+		MasterName:    sentinel.MasterName,
+		SentinelAddrs: sentinel.SentinelAddrs,
+		OnConnect:     opt.OnConnect,
+		Password:      opt.Password,
+		DB:            opt.DB,
+		PoolSize:      opt.PoolSize,
+		ReadTimeout:   opt.ReadTimeout,
+		PoolTimeout:   opt.PoolTimeout,
+		TLSConfig:     opt.TLSConfig,
+	}
+}
+
+// connectRing opens a ring over r.ringAddrs and pings every node, r.mu must be held
+func (r *redis) connectRing() error {
+	opt, err := r.options()
 	if err != nil {
 		return err
 	}
-	r.client = cl
+	ringOpt := &redisLib.RingOptions{
+		Addrs:       r.ringAddrs,
+		OnConnect:   opt.OnConnect,
+		Password:    opt.Password,
+		DB:          opt.DB,
+		PoolSize:    opt.PoolSize,
+		ReadTimeout: opt.ReadTimeout,
+		PoolTimeout: opt.PoolTimeout,
+	}
+
+	ring := redisLib.NewRing(ringOpt)
+	err = ring.ForEachShard(func(shard *redisLib.Client) error {
+		if err := shard.Ping().Err(); err != nil {
+			return fmt.Errorf("redis: ring node %s: %w", shard.Options().Addr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		ring.Close()
+		return err
+	}
+	r.client.Store(&connection{cmd: ring, ring: ring})
 
 	return nil
 }
 
-func (r *redis) SetRedisValue(key string, payload string, ttl time.Duration) {
-	r.client.Set(key, payload, ttl)
+// Set sets the value of key, expiring after ttl when positive. The command
+// is traced as a child of the span of ctx and given up when ctx is done.
+func (r *redis) Set(ctx context.Context, key, payload string, ttl time.Duration) error {
+	cl, err := r.connCtx(ctx)
+	if err != nil {
+		return err
+	}
+	return r.do(ctx, func() error {
+		return cl.Set(key, payload, ttl).Err()
+	})
 }
 
-func (r *redis) GetRedisValue(key string) string {
-	val, err := r.client.Get(key).Result()
+// Get returns the value of key, or ErrNil when it is not set, see Set for
+// ctx
+func (r *redis) Get(ctx context.Context, key string) (string, error) {
+	cl, err := r.connCtx(ctx)
 	if err != nil {
-		return ""
+		return "", err
+	}
+	var val string
+	err = r.do(ctx, func() (err error) {
+		val, err = cl.Get(key).Result()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// Delete deletes keys and returns the number of keys removed, see Set for
+// ctx. On a ring every key is deleted on its own node.
+func (r *redis) Delete(ctx context.Context, keys ...string) (int64, error) {
+	cl, err := r.connCtx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = r.do(ctx, func() (err error) {
+		if _, ok := cl.(*redisLib.Ring); ok && len(keys) > 1 {
+			n, err = deleteEach(cl, keys)
+			return err
+		}
+		n, err = cl.Del(keys...).Result()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// deleteEach deletes keys one DEL per key in a pipeline, so that a ring
+// routes each of them to its node instead of the node of the first key.
+func deleteEach(cl redisLib.Cmdable, keys []string) (int64, error) {
+	cmds := make([]*redisLib.IntCmd, len(keys))
+	_, err := cl.Pipelined(func(pipe redisLib.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Del(key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	return n, nil
+}
+
+// SetRedisValue is Set, logging the error.
+//
+// Deprecated: use Set, which returns the error.
+func (r *redis) SetRedisValue(key string, payload string, ttl time.Duration) {
+	if err := r.Set(context.Background(), key, payload, ttl); err != nil {
+		logger.Errorf("Failed to set redis value: %v", err)
 	}
+}
+
+// GetRedisValue is Get, returning an empty value on errors and missing keys
+// alike.
+//
+// Deprecated: use Get, which tells them apart.
+func (r *redis) GetRedisValue(key string) string {
+	val, _ := r.Get(context.Background(), key)
 	return val
 }
 
+// DeleteRedisValue is Delete, returning 0 on errors.
+//
+// Deprecated: use Delete, which returns the error.
 func (r *redis) DeleteRedisValue(key string) int64 {
-	val, err := r.client.Del(key).Result()
+	val, _ := r.Delete(context.Background(), key)
+	return val
+}
+
+// GetSet atomically replaces the value of key and returns the previous one.
+// existed is false when the key was not set before.
+func (r *redis) GetSet(key, value string) (string, bool, error) {
+	cl, err := r.conn()
 	if err != nil {
-		return 0
+		return "", false, err
 	}
-	return val
+	old, err := cl.GetSet(key, value).Result()
+	if err == redisLib.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return old, true, nil
 }
 
+// rotateScript sets KEYS[1] to ARGV[1], expiring in ARGV[2] milliseconds when
+// positive, and returns its previous value
+var rotateScript = redisLib.NewScript(`
+local old = redis.call("GET", KEYS[1])
+if tonumber(ARGV[2]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return old
+`)
+
+// Rotate atomically replaces the value of key with newValue, expiring after
+// ttl when positive, and returns the previous value in one round trip.
+// old is empty when the key was not set before.
+func (r *redis) Rotate(key, newValue string, ttl time.Duration) (string, error) {
+	cl, err := r.conn()
+	if err != nil {
+		return "", err
+	}
+	old, err := rotateScript.Run(cl, []string{key}, newValue, int64(ttl/time.Millisecond)).String()
+	if err == redisLib.Nil {
+		return "", nil
+	}
+	return old, err
+}
+
+// SetKeepTTL updates the value of key without clearing its expiry, unlike
+// Set. It requires Redis 6.0 or later.
+func (r *redis) SetKeepTTL(key, value string) error {
+	cl, err := r.conn()
+	if err != nil {
+		return err
+	}
+	cmd := redisLib.NewStatusCmd("set", key, value, "keepttl")
+	cl.Process(cmd)
+	return cmd.Err()
+}
+
+// GetClient returns the underlying traced client, or nil when it is not
+// connected and, in lazy mode, connecting fails
 func (r *redis) GetClient() *redisTraceLib.Client {
-	return r.client
+	c, err := r.connection()
+	if err != nil {
+		logger.Errorf("Failed to get redis client: %v", err)
+		return nil
+	}
+	return c.traced
+}
+
+// GetUniversalClient returns the client commands are issued on, the traced
+// client or the ring, or nil when it is not connected
+func (r *redis) GetUniversalClient() redisLib.UniversalClient {
+	cl, err := r.conn()
+	if err != nil {
+		logger.Errorf("Failed to get redis client: %v", err)
+		return nil
+	}
+	return cl
+}
+
+// clientOf returns the connected client of r, or the connection error without
+// logging it as GetUniversalClient does, for helpers issuing a command per
+// call
+func clientOf(r Redis) (redisLib.UniversalClient, error) {
+	if rr, ok := r.(*redis); ok {
+		return rr.conn()
+	}
+	if cl := r.GetUniversalClient(); cl != nil {
+		return cl, nil
+	}
+	return nil, ErrNotConnected
 }
@@ -0,0 +1,310 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/rohanchauhan02/common/logs/mocks"
+)
+
+func TestMain(m *testing.M) {
+	SetLogger(logs.NewNoopLogger())
+	os.Exit(m.Run())
+}
+
+// newTestRedis returns a client connected to a new in-memory server, both
+// closed at the end of the test
+func newTestRedis(t *testing.T) (Redis, *miniredis.Miniredis) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr()})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r, s
+}
+
+func TestSetGetDelete(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := r.Set(ctx, "session", "v1", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := s.TTL("session"); ttl != time.Minute {
+		t.Fatalf("TTL = %v, want 1m", ttl)
+	}
+	if err := r.Set(ctx, "persistent", "", 0); err != nil {
+		t.Fatalf("Set without TTL: %v", err)
+	}
+	if s.TTL("persistent") != 0 {
+		t.Fatal("key without TTL expires")
+	}
+
+	if v, err := r.Get(ctx, "session"); err != nil || v != "v1" {
+		t.Fatalf("Get = %q, %v, want the hit", v, err)
+	}
+	// An empty value is a hit too
+	if v, err := r.Get(ctx, "persistent"); err != nil || v != "" {
+		t.Fatalf("Get of an empty value = %q, %v", v, err)
+	}
+	if v, err := r.Get(ctx, "missing"); err != ErrNil || v != "" {
+		t.Fatalf("Get of a missing key = %q, %v, want ErrNil", v, err)
+	}
+	s.Lpush("list", "a")
+	if _, err := r.Get(ctx, "list"); err == nil || err == ErrNil || !strings.Contains(err.Error(), "WRONGTYPE") {
+		t.Fatalf("Get of a list = %v, want WRONGTYPE", err)
+	}
+
+	if n, err := r.Delete(ctx, "session", "persistent", "missing"); err != nil || n != 2 {
+		t.Fatalf("Delete = %d, %v, want 2", n, err)
+	}
+	if s.Exists("session") || s.Exists("persistent") {
+		t.Fatal("keys not deleted")
+	}
+}
+
+func TestSetGetDeleteConnectionFailure(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+	s.Close()
+
+	if err := r.Set(ctx, "k", "v", 0); err == nil {
+		t.Fatal("Set succeeded with the server down")
+	}
+	if _, err := r.Get(ctx, "k"); err == nil || err == ErrNil {
+		t.Fatalf("Get = %v, want a connection error distinct from ErrNil", err)
+	}
+	if _, err := r.Delete(ctx, "k"); err == nil {
+		t.Fatal("Delete succeeded with the server down")
+	}
+
+	down := NewRedis(RedisConfig{Host: "127.0.0.1:1"})
+	if err := down.Set(ctx, "k", "v", 0); err != ErrNotConnected {
+		t.Fatalf("Set = %v, want ErrNotConnected", err)
+	}
+	if _, err := down.Get(ctx, "k"); err != ErrNotConnected {
+		t.Fatalf("Get = %v, want ErrNotConnected", err)
+	}
+	if _, err := down.Delete(ctx, "k"); err != ErrNotConnected {
+		t.Fatalf("Delete = %v, want ErrNotConnected", err)
+	}
+}
+
+func TestDeprecatedValueMethods(t *testing.T) {
+	log := mocks.NewLogger()
+	SetLogger(log)
+	defer SetLogger(logs.NewNoopLogger())
+	r, s := newTestRedis(t)
+
+	r.SetRedisValue("k", "v", time.Minute)
+	if v := r.GetRedisValue("k"); v != "v" {
+		t.Fatalf("GetRedisValue = %q", v)
+	}
+	if v := r.GetRedisValue("missing"); v != "" {
+		t.Fatalf("GetRedisValue of a missing key = %q", v)
+	}
+	if n := r.DeleteRedisValue("k"); n != 1 {
+		t.Fatalf("DeleteRedisValue = %d, want 1", n)
+	}
+
+	s.Close()
+	r.SetRedisValue("k", "v", 0)
+	if n := log.Count("Errorf", "Failed to set redis value"); n != 1 {
+		t.Fatalf("Errorf called %d times, want the failed set logged: %+v", n, log.Calls(""))
+	}
+	if v, n := r.GetRedisValue("k"), r.DeleteRedisValue("k"); v != "" || n != 0 {
+		t.Fatalf("GetRedisValue = %q, DeleteRedisValue = %d with the server down", v, n)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	r, s := newTestRedis(t)
+
+	old, existed, err := r.GetSet("fresh", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existed || old != "" {
+		t.Fatalf("GetSet on a fresh key = %q, %v, want \"\", false", old, existed)
+	}
+
+	old, existed, err = r.GetSet("fresh", "v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existed || old != "v1" {
+		t.Fatalf("GetSet on overwrite = %q, %v, want \"v1\", true", old, existed)
+	}
+	if got, _ := s.Get("fresh"); got != "v2" {
+		t.Fatalf("value = %q, want v2", got)
+	}
+}
+
+func TestGetSetEmptyValue(t *testing.T) {
+	r, s := newTestRedis(t)
+	s.Set("empty", "")
+
+	old, existed, err := r.GetSet("empty", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existed || old != "" {
+		t.Fatalf("GetSet = %q, %v, want \"\", true", old, existed)
+	}
+}
+
+func TestGetSetNotConnected(t *testing.T) {
+	r := NewRedis(RedisConfig{Host: "127.0.0.1:1"})
+	if _, _, err := r.GetSet("k", "v"); err != ErrNotConnected {
+		t.Fatalf("err = %v, want ErrNotConnected", err)
+	}
+}
+
+func TestLazyConnect(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr(), Lazy: true})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	defer r.Close()
+	if s.CurrentConnectionCount() != 0 {
+		t.Fatal("lazy InitClient connected")
+	}
+
+	ctx := context.Background()
+	if err := r.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("first Set: %v", err)
+	}
+	if got, err := r.Get(ctx, "k"); err != nil || got != "v" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+}
+
+func TestLazyConnectError(t *testing.T) {
+	s := miniredis.RunT(t)
+	addr := s.Addr()
+	s.Close()
+
+	r := NewRedis(RedisConfig{Host: addr, Lazy: true})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	defer r.Close()
+
+	ctx := context.Background()
+	err := r.Set(ctx, "k", "v", 0)
+	if err == nil || err == ErrNotConnected {
+		t.Fatalf("Set = %v, want the connect error", err)
+	}
+
+	// The connect is retried on the next operation
+	if err := s.StartAddr(addr); err != nil {
+		t.Skipf("could not restart the server: %v", err)
+	}
+	if err := r.Set(ctx, "k", "v", 0); err != nil {
+		t.Fatalf("Set after the server came back: %v", err)
+	}
+}
+
+func TestPoolTimeout(t *testing.T) {
+	s := miniredis.RunT(t)
+	r := NewRedis(RedisConfig{Host: s.Addr(), PoolSize: 1, PoolTimeout: 100 * time.Millisecond})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	defer r.Close()
+	cl := r.GetUniversalClient()
+
+	// Holds the only connection until something is pushed
+	held := make(chan error, 1)
+	go func() {
+		held <- cl.BLPop(5*time.Second, "queue").Err()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	_, err := r.Get(context.Background(), "k")
+	elapsed := time.Since(start)
+	if err == nil || !strings.Contains(err.Error(), "pool timeout") {
+		t.Fatalf("Get = %v, want a pool timeout", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("pool timeout took %v", elapsed)
+	}
+
+	s.Lpush("queue", "done")
+	if err := <-held; err != nil {
+		t.Fatalf("BLPOP: %v", err)
+	}
+}
+
+func TestSetKeepTTL(t *testing.T) {
+	r, s := newTestRedis(t)
+	ctx := context.Background()
+	if err := r.Set(ctx, "session", "v1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetKeepTTL("session", "v2"); err != nil {
+		t.Fatalf("SetKeepTTL: %v", err)
+	}
+	if v := s.TTL("session"); v != time.Hour {
+		t.Fatalf("TTL after SetKeepTTL = %v, want 1h", v)
+	}
+	if v, _ := s.Get("session"); v != "v2" {
+		t.Fatalf("value = %q, want v2", v)
+	}
+
+	if err := r.Set(ctx, "session", "v3", 0); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.TTL("session"); v != 0 {
+		t.Fatalf("TTL after Set = %v, want none", v)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	r, s := newTestRedis(t)
+
+	old, err := r.Rotate("token", "v1", time.Minute)
+	if err != nil || old != "" {
+		t.Fatalf("first Rotate = %q, %v, want no previous value", old, err)
+	}
+	old, err = r.Rotate("token", "v2", time.Hour)
+	if err != nil || old != "v1" {
+		t.Fatalf("Rotate = %q, %v, want v1", old, err)
+	}
+	if v, _ := s.Get("token"); v != "v2" {
+		t.Fatalf("value = %q, want v2", v)
+	}
+	if ttl := s.TTL("token"); ttl != time.Hour {
+		t.Fatalf("TTL = %v, want 1h", ttl)
+	}
+
+	if old, err := r.Rotate("token", "v3", 0); err != nil || old != "v2" {
+		t.Fatalf("Rotate without ttl = %q, %v", old, err)
+	}
+	if ttl := s.TTL("token"); ttl != 0 {
+		t.Fatalf("TTL after Rotate without ttl = %v, want none", ttl)
+	}
+}
+
+func TestSetLoggerMock(t *testing.T) {
+	log := mocks.NewLogger()
+	SetLogger(log)
+	defer SetLogger(logs.NewNoopLogger())
+
+	r := NewRedis(RedisConfig{Host: "127.0.0.1:1"})
+	if cl := r.GetUniversalClient(); cl != nil {
+		t.Fatal("got a client without connection")
+	}
+	if n := log.Count("Errorf", "Failed to get redis client"); n != 1 {
+		t.Fatalf("Errorf called %d times, want 1: %+v", n, log.Calls(""))
+	}
+}
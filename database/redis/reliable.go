@@ -0,0 +1,158 @@
+package redis
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+const (
+	// reliableStreamMaxLen caps the backing stream of reliable channels
+	reliableStreamMaxLen = 10000
+	// reliableBlock is how long a reliable subscriber waits for new entries
+	// before checking whether it was closed
+	reliableBlock = 5 * time.Second
+	// reliablePayloadField is the stream entry field holding the payload
+	reliablePayloadField = "payload"
+)
+
+// reliableStream returns the key of the stream backing a reliable channel
+func reliableStream(channel string) string {
+	return channel + ":stream"
+}
+
+// Publish publishes payload on channel
+func (r *redis) Publish(channel, payload string) error {
+	cl, err := r.conn()
+	if err != nil {
+		return err
+	}
+	return cl.Publish(channel, payload).Err()
+}
+
+// PublishReliable publishes payload on channel like Publish, and also appends
+// it to the stream backing the channel (<channel>:stream, trimmed to about
+// 10000 entries) so SubscribeReliable consumers receive it even when they
+// were not connected at the time.
+func (r *redis) PublishReliable(channel, payload string) error {
+	cl, err := r.conn()
+	if err != nil {
+		return err
+	}
+
+	pipe := cl.Pipeline()
+	pipe.XAdd(&redisLib.XAddArgs{
+		Stream:       reliableStream(channel),
+		MaxLenApprox: reliableStreamMaxLen,
+		Values:       map[string]interface{}{reliablePayloadField: payload},
+	})
+	pipe.Publish(channel, payload)
+	_, err = pipe.Exec()
+	return err
+}
+
+// ReliableSubscription is a subscription started by SubscribeReliable
+type ReliableSubscription struct {
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// Close stops the subscription and waits for the handler in flight to return
+func (s *ReliableSubscription) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+// SubscribeReliable consumes the messages published with PublishReliable on
+// channel as consumer of the consumer group group, creating the group when
+// needed. The group remembers the last entry it delivered, so a consumer
+// coming back after being offline first replays what was published meanwhile.
+//
+// Delivery is at least once: an entry is acknowledged only when handler
+// returns nil, entries whose handler failed are delivered again the next
+// time the consumer subscribes.
+func (r *redis) SubscribeReliable(channel, group, consumer string, handler func(channel, payload string) error) (*ReliableSubscription, error) {
+	cl, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	stream := reliableStream(channel)
+	err = cl.XGroupCreateMkStream(stream, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	s := &ReliableSubscription{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.stopped)
+		r.consume(cl, s.done, channel, stream, group, consumer, handler)
+	}()
+	return s, nil
+}
+
+func (r *redis) consume(cl redisLib.UniversalClient, done chan struct{}, channel, stream, group, consumer string, handler func(channel, payload string) error) {
+	// Start with the entries delivered to this consumer but never
+	// acknowledged
+	id := "0"
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		streams, err := cl.XReadGroup(&redisLib.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, id},
+			Count:    100,
+			Block:    reliableBlock,
+		}).Result()
+		if err == redisLib.Nil {
+			id = ">"
+			continue
+		}
+		if err != nil {
+			logger.Warnf("Failed to read reliable channel %s: %v", channel, err)
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		var last string
+		for _, st := range streams {
+			for _, msg := range st.Messages {
+				last = msg.ID
+				payload, _ := msg.Values[reliablePayloadField].(string)
+				if err := handler(channel, payload); err != nil {
+					logger.Warnf("Failed to handle message %s of reliable channel %s: %v", msg.ID, channel, err)
+					continue
+				}
+				if err := cl.XAck(stream, group, msg.ID).Err(); err != nil {
+					logger.Warnf("Failed to acknowledge message %s of reliable channel %s: %v", msg.ID, channel, err)
+				}
+			}
+		}
+		if id != ">" {
+			// Replaying: continue after the last pending entry, then
+			// switch to new entries once none is left
+			id = last
+			if last == "" {
+				id = ">"
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubscribeReliableReplays(t *testing.T) {
+	r, _ := newTestRedis(t)
+	got := make(chan string, 10)
+	handler := func(_, payload string) error {
+		got <- payload
+		return nil
+	}
+
+	sub, err := r.SubscribeReliable("orders", "billing", "worker-1", handler)
+	if err != nil {
+		t.Fatalf("SubscribeReliable: %v", err)
+	}
+	if err := r.PublishReliable("orders", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, got); msg != "one" {
+		t.Fatalf("got %q, want one", msg)
+	}
+	sub.Close()
+
+	// Published while the consumer is offline
+	for _, payload := range []string{"two", "three"} {
+		if err := r.PublishReliable("orders", payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sub, err = r.SubscribeReliable("orders", "billing", "worker-1", handler)
+	if err != nil {
+		t.Fatalf("SubscribeReliable again: %v", err)
+	}
+	defer sub.Close()
+	for _, want := range []string{"two", "three"} {
+		if msg := receive(t, got); msg != want {
+			t.Fatalf("got %q, want %s", msg, want)
+		}
+	}
+	select {
+	case msg := <-got:
+		t.Fatalf("acknowledged message %q delivered again", msg)
+	default:
+	}
+}
+
+func TestSubscribeReliableRedeliversFailures(t *testing.T) {
+	r, _ := newTestRedis(t)
+	failed := make(chan string, 10)
+	sub, err := r.SubscribeReliable("orders", "billing", "worker-1", func(_, payload string) error {
+		failed <- payload
+		return errors.New("not now")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PublishReliable("orders", "one"); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, failed)
+	sub.Close()
+
+	got := make(chan string, 10)
+	sub, err = r.SubscribeReliable("orders", "billing", "worker-1", func(_, payload string) error {
+		got <- payload
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if msg := receive(t, got); msg != "one" {
+		t.Fatalf("got %q, want the unacknowledged one", msg)
+	}
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRing(t *testing.T, nodes ...*miniredis.Miniredis) Redis {
+	t.Helper()
+	addrs := make(map[string]string, len(nodes))
+	for i, s := range nodes {
+		addrs[fmt.Sprintf("shard%d", i)] = s.Addr()
+	}
+	r := NewRedisRing(addrs, RedisConfig{})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRing(t *testing.T) {
+	a, b := miniredis.RunT(t), miniredis.RunT(t)
+	r := newTestRing(t, a, b)
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		if err := r.Set(ctx, key, "v", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		if a.Exists(key) == b.Exists(key) {
+			t.Fatalf("%s is on both nodes or none", key)
+		}
+		if v, err := r.Get(ctx, key); err != nil || v != "v" {
+			t.Fatalf("Get(%s) = %q, %v", key, v, err)
+		}
+	}
+	if len(a.Keys()) == 0 || len(b.Keys()) == 0 {
+		t.Fatalf("keys not spread: %d and %d", len(a.Keys()), len(b.Keys()))
+	}
+
+	// Another client over the same nodes routes every key the same way
+	other := newTestRing(t, a, b)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		if v, err := other.Get(ctx, key); err != nil || v != "v" {
+			t.Fatalf("other client Get(%s) = %q, %v", key, v, err)
+		}
+	}
+
+	n, err := r.DBSize()
+	if err != nil || n != 100 {
+		t.Fatalf("DBSize = %d, %v, want 100 over the ring", n, err)
+	}
+	if n, err := r.Delete(ctx, "key:1", "key:2"); err != nil || n != 2 {
+		t.Fatalf("Delete = %d, %v", n, err)
+	}
+}
+
+func TestRingRejectsSubscriptions(t *testing.T) {
+	r := newTestRing(t, miniredis.RunT(t), miniredis.RunT(t))
+	if _, err := r.Subscribe(func(_, _ string) {}, SubscribeOptions{}, "events"); err == nil {
+		t.Fatal("ring subscription accepted")
+	}
+}
+
+func TestRingValidation(t *testing.T) {
+	if err := NewRedisRing(nil, RedisConfig{}).InitClient(); err == nil {
+		t.Fatal("ring without nodes accepted")
+	}
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// scan iterates over every key matching pattern with SCAN, on every node of a
+// ring, and calls fn once per returned batch, so callers never hold the whole
+// keyspace in memory.
+func (r *redis) scan(pattern string, count int64, fn func(keys []string) error) error {
+	shards, err := r.shards()
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range shards {
+		var cursor uint64
+		count := r.initialScanCount(count)
+		for {
+			keys, next, err := shard.Scan(cursor, pattern, count).Result()
+			if err != nil {
+				return err
+			}
+			count = r.nextScanCount(count, len(keys))
+			if len(keys) > 0 {
+				if err := fn(keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+	}
+	return nil
+}
+
+func (r *redis) initialScanCount(count int64) int64 {
+	if r.config.ScanMaxCount <= 0 {
+		return count
+	}
+	if r.config.ScanMinCount > 0 {
+		return r.config.ScanMinCount
+	}
+	if count > 0 && count < r.config.ScanMaxCount {
+		return count
+	}
+	return 10
+}
+
+// nextScanCount doubles the COUNT hint, within the configured maximum, when a
+// call matched less than a quarter of it
+func (r *redis) nextScanCount(count int64, matched int) int64 {
+	if r.config.ScanMaxCount <= 0 || int64(matched) >= count/4 {
+		return count
+	}
+	count *= 2
+	if count > r.config.ScanMaxCount {
+		count = r.config.ScanMaxCount
+	}
+	return count
+}
+
+// KeysWithoutTTL returns the keys matching pattern that have no expiry set.
+// The TTL of every scanned batch is checked in a single pipeline.
+func (r *redis) KeysWithoutTTL(pattern string, count int64) ([]string, error) {
+	var persistent []string
+	err := r.scan(pattern, count, func(keys []string) error {
+		cl, err := r.conn()
+		if err != nil {
+			return err
+		}
+		pipe := cl.Pipeline()
+		cmds := make([]*redisLib.DurationCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.TTL(key)
+		}
+		if _, err := pipe.Exec(); err != nil && err != redisLib.Nil {
+			return err
+		}
+		for i, cmd := range cmds {
+			// TTL replies -1 for keys without expiry, which go-redis
+			// scales to -1s
+			if cmd.Val() == -time.Second {
+				persistent = append(persistent, keys[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return persistent, nil
+}
@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeysWithoutTTL(t *testing.T) {
+	r, s := newTestRedis(t)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("session:%d", i)
+		s.Set(key, "v")
+		if i%4 != 0 {
+			s.SetTTL(key, time.Hour)
+		}
+	}
+	s.Set("other:persistent", "v")
+
+	keys, err := r.KeysWithoutTTL("session:*", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	want := []string{"session:0", "session:12", "session:16", "session:4", "session:8"}
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+}
+
+func TestKeysWithoutTTLNoMatch(t *testing.T) {
+	r, s := newTestRedis(t)
+	s.Set("a", "v")
+	s.SetTTL("a", time.Minute)
+
+	keys, err := r.KeysWithoutTTL("*", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("keys = %v, want none", keys)
+	}
+}
+
+func TestScanCountTuning(t *testing.T) {
+	fixed := &redis{}
+	if n := fixed.initialScanCount(50); n != 50 {
+		t.Fatalf("fixed initial count = %d, want the given 50", n)
+	}
+	if n := fixed.nextScanCount(50, 0); n != 50 {
+		t.Fatalf("fixed next count = %d, want 50", n)
+	}
+
+	adaptive := &redis{config: RedisConfig{ScanMinCount: 20, ScanMaxCount: 100}}
+	for _, tt := range []struct {
+		count   int64
+		matched int
+		want    int64
+	}{
+		{20, 0, 40},
+		{20, 4, 40},
+		{20, 5, 20},
+		{40, 50, 40},
+		{80, 1, 100},
+		{100, 0, 100},
+	} {
+		if n := adaptive.nextScanCount(tt.count, tt.matched); n != tt.want {
+			t.Errorf("nextScanCount(%d, %d) = %d, want %d", tt.count, tt.matched, n, tt.want)
+		}
+	}
+	if n := adaptive.initialScanCount(500); n != 20 {
+		t.Fatalf("initial count = %d, want ScanMinCount", n)
+	}
+	noMin := &redis{config: RedisConfig{ScanMaxCount: 100}}
+	for count, want := range map[int64]int64{0: 10, 50: 50, 500: 10} {
+		if n := noMin.initialScanCount(count); n != want {
+			t.Errorf("initialScanCount(%d) without minimum = %d, want %d", count, n, want)
+		}
+	}
+}
+
+// scanServer answers PING, and SCAN over keys as a server does: each call
+// examines COUNT keys from the cursor, returning those matching. It returns
+// the COUNT of every SCAN received.
+func scanServer(t *testing.T, keys []string) (addr string, counts func() []int64) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	var (
+		mu   sync.Mutex
+		seen []int64
+	)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					cmd, err := readCommand(rd)
+					if err != nil {
+						return
+					}
+					if !strings.EqualFold(cmd[0], "SCAN") {
+						io.WriteString(conn, "+PONG\r\n")
+						continue
+					}
+					cursor, _ := strconv.Atoi(cmd[1])
+					pattern, count := "*", int64(10)
+					for i := 2; i+1 < len(cmd); i += 2 {
+						switch strings.ToUpper(cmd[i]) {
+						case "MATCH":
+							pattern = cmd[i+1]
+						case "COUNT":
+							count, _ = strconv.ParseInt(cmd[i+1], 10, 64)
+						}
+					}
+					mu.Lock()
+					seen = append(seen, count)
+					mu.Unlock()
+
+					end, next := cursor+int(count), cursor+int(count)
+					if end >= len(keys) {
+						end, next = len(keys), 0
+					}
+					var found []string
+					for _, k := range keys[cursor:end] {
+						if ok, _ := path.Match(pattern, k); ok {
+							found = append(found, k)
+						}
+					}
+					var b strings.Builder
+					cur := strconv.Itoa(next)
+					fmt.Fprintf(&b, "*2\r\n$%d\r\n%s\r\n", len(cur), cur)
+					fmt.Fprintf(&b, "*%d\r\n", len(found))
+					for _, k := range found {
+						fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(k), k)
+					}
+					io.WriteString(conn, b.String())
+				}
+			}()
+		}
+	}()
+	return l.Addr().String(), func() []int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int64(nil), seen...)
+	}
+}
+
+func TestAdaptiveScanFewerCalls(t *testing.T) {
+	var keys []string
+	for i := 0; i < 10000; i++ {
+		keys = append(keys, fmt.Sprintf("bulk:%d", i))
+		if i%1000 == 999 {
+			keys = append(keys, fmt.Sprintf("needle:%d", i/1000))
+		}
+	}
+	addr, counts := scanServer(t, keys)
+
+	walk := func(cfg RedisConfig) []int64 {
+		cfg.Host = addr
+		r := NewRedis(cfg).(*redis)
+		if err := r.InitClient(); err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		before := len(counts())
+		var found int
+		err := r.scan("needle:*", 10, func(keys []string) error {
+			found += len(keys)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != 10 {
+			t.Fatalf("%+v: found %d keys, want 10", cfg, found)
+		}
+		return counts()[before:]
+	}
+
+	fixed := walk(RedisConfig{})
+	if len(fixed) != 1001 {
+		t.Fatalf("fixed count took %d calls, want 1001", len(fixed))
+	}
+	adaptive := walk(RedisConfig{ScanMinCount: 10, ScanMaxCount: 1000})
+	if len(adaptive) >= len(fixed)/10 {
+		t.Fatalf("adaptive scan took %d calls, fixed %d", len(adaptive), len(fixed))
+	}
+	want := []int64{10, 20, 40, 80, 160, 320, 640, 1000, 1000}
+	if fmt.Sprint(adaptive[:len(want)]) != fmt.Sprint(want) {
+		t.Fatalf("COUNT sent %v, want it doubled up to the maximum", adaptive[:len(want)])
+	}
+}
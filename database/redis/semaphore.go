@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	redisLib "github.com/go-redis/redis"
+)
+
+// semaphoreAcquireScript drops the expired holders of the sorted set at
+// KEYS[1] and adds ARGV[3] as holder when fewer than ARGV[1] remain.
+// ARGV: limit, ttl in milliseconds, holder. Expiry uses the server time, so
+// the clocks of the clients don't matter.
+var semaphoreAcquireScript = redisLib.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+if redis.call("ZCARD", KEYS[1]) >= limit then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[3])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", KEYS[1], last[2])
+return 1
+`)
+
+// Semaphore is a distributed counting semaphore backed by Redis, limiting how
+// many holders across instances run concurrently
+type Semaphore struct {
+	redis Redis
+}
+
+// NewSemaphore is a factory that return a semaphore using the given client
+func NewSemaphore(r Redis) *Semaphore {
+	return &Semaphore{redis: r}
+}
+
+// Acquire tries once to take one of the limit slots of the semaphore stored at
+// key, without waiting for a slot to free up. A slot is held until release is
+// called or ttl elapses, so holders that crash never leak it.
+func (s *Semaphore) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (func() error, bool, error) {
+	if limit <= 0 || ttl < time.Millisecond {
+		return nil, false, errors.New("redis: limit must be positive and ttl at least 1ms")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	cl, err := clientOf(s.redis)
+	if err != nil {
+		return nil, false, err
+	}
+
+	holder, err := newHolderID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	res, err := semaphoreAcquireScript.Run(cl, []string{key}, limit, int64(ttl/time.Millisecond), holder).Int64()
+	if err != nil {
+		return nil, false, err
+	}
+	if res != 1 {
+		return nil, false, nil
+	}
+
+	release := func() error {
+		return cl.ZRem(key, holder).Err()
+	}
+	return release, true, nil
+}
+
+func newHolderID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphore(t *testing.T) {
+	r, s := newTestRedis(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.SetTime(now)
+	sem := NewSemaphore(r)
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		releases []func() error
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, ok, err := sem.Acquire(ctx, "jobs", 3, time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				releases = append(releases, release)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(releases) != 3 {
+		t.Fatalf("%d holders acquired, want 3", len(releases))
+	}
+	if _, ok, _ := sem.Acquire(ctx, "jobs", 3, time.Minute); ok {
+		t.Fatal("acquired beyond the limit")
+	}
+
+	if err := releases[0](); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, ok, err := sem.Acquire(ctx, "jobs", 3, time.Minute); err != nil || !ok {
+		t.Fatalf("acquire after release = %v, %v", ok, err)
+	}
+	if _, ok, _ := sem.Acquire(ctx, "jobs", 3, time.Minute); ok {
+		t.Fatal("release freed more than one slot")
+	}
+
+	// Holders that never release free their slot once their ttl elapsed
+	s.SetTime(now.Add(time.Minute + time.Second))
+	for i := 0; i < 3; i++ {
+		if _, ok, err := sem.Acquire(ctx, "jobs", 3, time.Minute); err != nil || !ok {
+			t.Fatalf("acquire %d after expiry = %v, %v", i+1, ok, err)
+		}
+	}
+}
+
+func TestSemaphoreInvalid(t *testing.T) {
+	r, _ := newTestRedis(t)
+	sem := NewSemaphore(r)
+	if _, _, err := sem.Acquire(context.Background(), "jobs", 0, time.Minute); err == nil {
+		t.Fatal("limit 0 accepted")
+	}
+	if _, _, err := sem.Acquire(context.Background(), "jobs", 1, 0); err == nil {
+		t.Fatal("ttl 0 accepted")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := sem.Acquire(ctx, "jobs", 1, time.Minute); err != context.Canceled {
+		t.Fatalf("Acquire with a cancelled ctx = %v", err)
+	}
+}
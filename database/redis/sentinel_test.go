@@ -0,0 +1,213 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+// fakeSentinel answers the commands go-redis sends to a Sentinel, reporting
+// the address of master
+type fakeSentinel struct {
+	mu     sync.Mutex
+	master string
+}
+
+func (f *fakeSentinel) setMaster(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.master = addr
+}
+
+// startFakeSentinel serves f until the test ends and returns its address
+func startFakeSentinel(t *testing.T, master string) (*fakeSentinel, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	f := &fakeSentinel{master: master}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, l.Addr().String()
+}
+
+func (f *fakeSentinel) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		switch name := strings.ToUpper(cmd[0]); {
+		case name == "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case name == "SUBSCRIBE":
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(cmd[1]), cmd[1])
+		case name == "SENTINEL" && strings.EqualFold(cmd[1], "get-master-addr-by-name"):
+			f.mu.Lock()
+			host, port, _ := net.SplitHostPort(f.master)
+			f.mu.Unlock()
+			fmt.Fprintf(conn, "*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(host), host, len(port), port)
+		case name == "SENTINEL" && strings.EqualFold(cmd[1], "sentinels"):
+			fmt.Fprint(conn, "*0\r\n")
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command %s\r\n", cmd[0])
+		}
+	}
+}
+
+func TestSentinelValidate(t *testing.T) {
+	sentinel := SentinelConfig{MasterName: "mymaster", SentinelAddrs: []string{"10.0.0.1:26379"}}
+	for _, tt := range []struct {
+		name string
+		cfg  RedisConfig
+		err  string
+	}{
+		{"sentinel", RedisConfig{Sentinel: sentinel}, ""},
+		{"host and sentinel", RedisConfig{Host: "localhost:6379", Sentinel: sentinel}, "mutually exclusive"},
+		{"no master name", RedisConfig{Sentinel: SentinelConfig{SentinelAddrs: sentinel.SentinelAddrs}}, "master name is required"},
+		{"no address", RedisConfig{Sentinel: SentinelConfig{MasterName: "mymaster"}}, "at least one address"},
+		{"sentinel password", RedisConfig{Sentinel: SentinelConfig{MasterName: "mymaster", SentinelAddrs: sentinel.SentinelAddrs, SentinelPassword: "s"}}, "not supported"},
+		{"invalid options", RedisConfig{Sentinel: sentinel, PoolSize: -1}, "pool size"},
+	} {
+		err := tt.cfg.Validate()
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: Validate() = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	err := NewRedis(RedisConfig{Host: "localhost:6379", Sentinel: sentinel}).InitClient()
+	if err == nil || !strings.Contains(err.Error(), "host and sentinel are mutually exclusive") {
+		t.Fatalf("InitClient = %v, want the mutual exclusion error", err)
+	}
+	ring := NewRedisRing(map[string]string{"a": "localhost:6379"}, RedisConfig{Sentinel: sentinel})
+	if err := ring.InitClient(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("ring InitClient = %v, want the mutual exclusion error", err)
+	}
+}
+
+func TestSentinelFailoverOptions(t *testing.T) {
+	r := NewRedis(RedisConfig{
+		Sentinel:    SentinelConfig{MasterName: "mymaster", SentinelAddrs: []string{"10.0.0.1:26379", "10.0.0.2:26379"}},
+		Username:    "app",
+		Password:    "secret",
+		DB:          3,
+		PoolSize:    8,
+		ReadTimeout: 2 * time.Second,
+		PoolTimeout: 3 * time.Second,
+		UseTLS:      true,
+	}).(*redis)
+	opt, err := r.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fo := failoverOptions(r.config.Sentinel, opt)
+	if fo.MasterName != "mymaster" || len(fo.SentinelAddrs) != 2 || fo.SentinelAddrs[1] != "10.0.0.2:26379" {
+		t.Fatalf("sentinel options = %+v", fo)
+	}
+	if fo.PoolSize != 8 || fo.ReadTimeout != 2*time.Second || fo.PoolTimeout != 3*time.Second {
+		t.Fatalf("pool options = %+v", fo)
+	}
+	if fo.TLSConfig == nil || fo.TLSConfig.MinVersion == 0 {
+		t.Fatalf("TLS config = %+v", fo.TLSConfig)
+	}
+	// The ACL user authenticates in OnConnect, see options
+	if fo.OnConnect == nil || fo.Password != "" || fo.DB != 0 {
+		t.Fatalf("ACL options = %+v", fo)
+	}
+
+	plain := NewRedis(RedisConfig{
+		Sentinel: SentinelConfig{MasterName: "mymaster", SentinelAddrs: []string{"10.0.0.1:26379"}},
+		Password: "secret",
+		DB:       2,
+	}).(*redis)
+	opt, _ = plain.options()
+	if fo := failoverOptions(plain.config.Sentinel, opt); fo.Password != "secret" || fo.DB != 2 || fo.OnConnect != nil || fo.TLSConfig != nil {
+		t.Fatalf("password only options = %+v", fo)
+	}
+}
+
+func TestSentinelClient(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	master := miniredis.RunT(t)
+	sentinel, addr := startFakeSentinel(t, master.Addr())
+
+	r := NewRedis(RedisConfig{Sentinel: SentinelConfig{MasterName: "mymaster", SentinelAddrs: []string{addr}}})
+	if err := r.InitClient(); err != nil {
+		t.Fatalf("InitClient: %v", err)
+	}
+	defer r.Close()
+	ctx := context.Background()
+	if err := r.Set(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _ := master.Get("k"); v != "v" {
+		t.Fatalf("key not written to the master: %q", v)
+	}
+	if v, err := r.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("Get = %q, %v", v, err)
+	}
+	if _, err := r.Get(ctx, "missing"); err != ErrNil {
+		t.Fatalf("Get of a missing key = %v, want ErrNil", err)
+	}
+	if r.GetClient() == nil {
+		t.Fatal("no traced client")
+	}
+	traced := 0
+	for _, span := range mt.FinishedSpans() {
+		if span.OperationName() == "redis.command" {
+			traced++
+		}
+	}
+	if traced < 3 {
+		t.Fatalf("%d commands traced, want them all", traced)
+	}
+
+	// The new master, reported by the Sentinel, takes over
+	promoted := miniredis.RunT(t)
+	sentinel.setMaster(promoted.Addr())
+	master.Close()
+	var err error
+	for i := 0; i < 5; i++ {
+		if err = r.Set(ctx, "k", "after failover", 0); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Set after failover: %v", err)
+	}
+	if v, _ := promoted.Get("k"); v != "after failover" {
+		t.Fatalf("key not written to the promoted master: %q", v)
+	}
+}
+
+func TestSentinelUnreachable(t *testing.T) {
+	r := NewRedis(RedisConfig{Sentinel: SentinelConfig{MasterName: "mymaster", SentinelAddrs: []string{"127.0.0.1:1"}}})
+	err := r.InitClient()
+	if err == nil {
+		r.Close()
+		t.Fatal("InitClient succeeded without Sentinel")
+	}
+	if cl := r.GetUniversalClient(); cl != nil {
+		t.Fatal("client kept after the failed connect")
+	}
+}
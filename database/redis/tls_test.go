@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// tlsServer starts a miniredis serving TLS with a self-signed certificate
+// for 127.0.0.1, and returns the path of that certificate in PEM
+func tlsServer(t *testing.T) (*miniredis.Miniredis, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := miniredis.RunTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, caFile
+}
+
+func TestTLSOptions(t *testing.T) {
+	_, caFile := tlsServer(t)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(empty, []byte("no certificate"), 0o600)
+	custom := &tls.Config{ServerName: "redis.internal"}
+
+	for _, tt := range []struct {
+		name  string
+		cfg   RedisConfig
+		check func(*tls.Config) bool
+		err   string
+	}{
+		{"plain", RedisConfig{}, func(c *tls.Config) bool { return c == nil }, ""},
+		{"system roots", RedisConfig{UseTLS: true}, func(c *tls.Config) bool {
+			return c.MinVersion == tls.VersionTLS12 && !c.InsecureSkipVerify && c.RootCAs == nil
+		}, ""},
+		{"CA file", RedisConfig{UseTLS: true, CAFile: caFile}, func(c *tls.Config) bool {
+			return c.RootCAs != nil && !c.InsecureSkipVerify
+		}, ""},
+		{"insecure", RedisConfig{UseTLS: true, InsecureSkipVerify: true}, func(c *tls.Config) bool {
+			return c.InsecureSkipVerify
+		}, ""},
+		{"custom config", RedisConfig{TLSConfig: custom, UseTLS: true, InsecureSkipVerify: true}, func(c *tls.Config) bool {
+			return c != custom && c.ServerName == "redis.internal" && !c.InsecureSkipVerify
+		}, ""},
+		{"missing CA file", RedisConfig{UseTLS: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}, nil, "failed to read CA file"},
+		{"CA file without certificate", RedisConfig{UseTLS: true, CAFile: empty}, nil, "no certificate found"},
+	} {
+		tt.cfg.Host = "localhost:6379"
+		opt, err := NewRedis(tt.cfg).(*redis).options()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: options() = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: options() = %v", tt.name, err)
+			continue
+		}
+		if !tt.check(opt.TLSConfig) {
+			t.Errorf("%s: TLS config = %+v", tt.name, opt.TLSConfig)
+		}
+	}
+
+	for name, cfg := range map[string]RedisConfig{
+		"insecure without TLS": {Host: "localhost:6379", InsecureSkipVerify: true},
+		"CA file without TLS":  {Host: "localhost:6379", CAFile: caFile},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate() succeeded", name)
+		}
+	}
+	ring := NewRedisRing(map[string]string{"a": "localhost:6379"}, RedisConfig{UseTLS: true})
+	if err := ring.InitClient(); err == nil || !strings.Contains(err.Error(), "TLS") {
+		t.Errorf("ring with TLS: InitClient() = %v", err)
+	}
+}
+
+func TestTLSConnection(t *testing.T) {
+	s, caFile := tlsServer(t)
+	s.RequireUserAuth("app", "secret")
+	ctx := context.Background()
+
+	for name, cfg := range map[string]RedisConfig{
+		"CA file":  {UseTLS: true, CAFile: caFile},
+		"insecure": {UseTLS: true, InsecureSkipVerify: true},
+	} {
+		cfg.Host, cfg.Username, cfg.Password = s.Addr(), "app", "secret"
+		r := NewRedis(cfg)
+		if err := r.InitClient(); err != nil {
+			t.Errorf("%s: InitClient: %v", name, err)
+			continue
+		}
+		if err := r.Set(ctx, "k", name, 0); err != nil {
+			t.Errorf("%s: Set: %v", name, err)
+		}
+		if v, err := r.Get(ctx, "k"); err != nil || v != name {
+			t.Errorf("%s: Get = %q, %v", name, v, err)
+		}
+		r.Close()
+	}
+
+	// Not signed by the system roots
+	r := NewRedis(RedisConfig{Host: s.Addr(), Username: "app", Password: "secret", UseTLS: true})
+	err := r.InitClient()
+	var unknownAuthority x509.UnknownAuthorityError
+	if err == nil || !strings.Contains(err.Error(), "TLS handshake with "+s.Addr()) || !errors.As(err, &unknownAuthority) {
+		t.Fatalf("InitClient = %v, want the TLS handshake failure", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package lifecycle coordinates the graceful shutdown of the resources of a
+// service
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+var logger logs.Logger = logs.NewCommonLog("lifecycle")
+
+// SetLogger replaces the logger failures are logged with, e.g. with the mock
+// of the logs/mocks package in tests
+func SetLogger(l logs.Logger) {
+	logger = l
+}
+
+// CloserFunc adapts a function to an io.Closer
+type CloserFunc func() error
+
+// Close calls f()
+func (f CloserFunc) Close() error {
+	return f()
+}
+
+// SentryFlusher returns a closer waiting until the queued error reports and
+// the buffered Sentry events are sent, for at most timeout
+func SentryFlusher(timeout time.Duration) io.Closer {
+	return CloserFunc(func() error {
+		if !logs.Flush(timeout) {
+			return errors.New("lifecycle: timed out flushing sentry events")
+		}
+		return nil
+	})
+}
+
+// CloseAll closes closers one after the other in the given order, so pass
+// the Redis client before the log and Sentry flushers to still get the final
+// entries out. Failures are logged and do not stop the next closers.
+//
+// When ctx is done before every closer returned, CloseAll gives up on the
+// remaining ones and ctx.Err() is part of the returned error. The closer in
+// flight is left running in the background.
+func CloseAll(ctx context.Context, closers ...io.Closer) error {
+	var errs []error
+	for i, c := range closers {
+		if c == nil {
+			continue
+		}
+		done := make(chan error, 1)
+		go func() {
+			done <- c.Close()
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Errorf("Failed to close resource %d of %d: %v", i+1, len(closers), err)
+				errs = append(errs, err)
+			}
+		case <-ctx.Done():
+			logger.Errorf("Shutdown deadline exceeded, %d of %d resources left open", len(closers)-i, len(closers))
+			errs = append(errs, fmt.Errorf("lifecycle: %w", ctx.Err()))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rohanchauhan02/common/database/redis"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/rohanchauhan02/common/logs/mocks"
+)
+
+func TestCloseAll(t *testing.T) {
+	log := mocks.NewLogger()
+	SetLogger(log)
+
+	redis.SetLogger(logs.NewNoopLogger())
+	s := miniredis.RunT(t)
+	r := redis.NewRedis(redis.RedisConfig{Host: s.Addr()})
+	if err := r.InitClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	closer := func(name string, err error) io.Closer {
+		return CloserFunc(func() error {
+			order = append(order, name)
+			return err
+		})
+	}
+	failure := errors.New("flush failed")
+	err := CloseAll(context.Background(),
+		CloserFunc(func() error {
+			order = append(order, "redis")
+			return r.Close()
+		}),
+		nil,
+		closer("logs", failure),
+		SentryFlusher(time.Second),
+		closer("last", nil),
+	)
+	if !errors.Is(err, failure) {
+		t.Fatalf("CloseAll = %v, want the flush failure", err)
+	}
+	if len(order) != 3 || order[0] != "redis" || order[1] != "logs" || order[2] != "last" {
+		t.Fatalf("closed %v, want redis, logs then last", order)
+	}
+	if _, err := r.Get(context.Background(), "k"); err == nil {
+		t.Fatal("redis client still open")
+	}
+	if n := log.Count("Errorf", "flush failed"); n != 1 {
+		t.Fatalf("failure logged %d times", n)
+	}
+}
+
+func TestCloseAllDeadline(t *testing.T) {
+	log := mocks.NewLogger()
+	SetLogger(log)
+
+	release := make(chan struct{})
+	defer close(release)
+	var lastClosed bool
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := CloseAll(ctx,
+		CloserFunc(func() error { return nil }),
+		CloserFunc(func() error {
+			<-release
+			return nil
+		}),
+		CloserFunc(func() error {
+			lastClosed = true
+			return nil
+		}),
+	)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("CloseAll returned after %v, past the deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CloseAll = %v, want the deadline error", err)
+	}
+	if lastClosed {
+		t.Fatal("closer after the deadline called")
+	}
+	if n := log.Count("Errorf", "2 of 3 resources left open"); n != 1 {
+		t.Fatalf("deadline logged %d times: %v", n, log.Calls("Errorf"))
+	}
+}
@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// RequestLoggerOptions configures MiddlewareRequestLogger
+type RequestLoggerOptions struct {
+	// SkipPaths are the request paths not logged, e.g. health checks
+	SkipPaths []string
+}
+
+// MiddlewareRequestLogger logs one line per request with its method, path,
+// route, status, latency, request ID, remote IP, user agent and response
+// size, at level info for 2xx and 3xx responses, warn for 4xx and error for
+// 5xx. Errors returned by the handler go to the error field.
+//
+// It logs through the request scoped logger when registered after
+// MiddlewareLoggerRequestID, so its lines carry the same request ID as the
+// application ones.
+func (q *CommonLogger) MiddlewareRequestLogger(opts RequestLoggerOptions) echo.MiddlewareFunc {
+	skip := make(map[string]struct{}, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			req := c.Request()
+			if _, ok := skip[req.URL.Path]; ok {
+				return next(c)
+			}
+
+			start := time.Now()
+			if err = next(c); err != nil {
+				// Let the error handler write the response to log its status
+				c.Error(err)
+			}
+			latency := time.Since(start)
+
+			l, ok := c.Get(echoContextKey).(*CommonLogger)
+			if !ok {
+				l = q
+			}
+			res := c.Response()
+			if l.requestID == "" {
+				if id := req.Header.Get(echo.HeaderXRequestID); id != "" {
+					l = l.WithRequestID(id)
+				} else if id := res.Header().Get(echo.HeaderXRequestID); id != "" {
+					l = l.WithRequestID(id)
+				}
+			}
+
+			fields := map[string]interface{}{
+				"method":     req.Method,
+				"path":       req.URL.Path,
+				"route":      c.Path(),
+				"status":     res.Status,
+				"latency_ms": float64(latency) / float64(time.Millisecond),
+				"remote_ip":  c.RealIP(),
+				"user_agent": req.UserAgent(),
+				"bytes_out":  res.Size,
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+			}
+			l = l.WithFields(fields)
+
+			const format = "%s %s %d"
+			switch {
+			case res.Status >= http.StatusInternalServerError:
+				// The handler error is the one worth reporting
+				l.WithoutSentry().Errorf(format, req.Method, req.URL.Path, res.Status)
+			case res.Status >= http.StatusBadRequest:
+				l.Warnf(format, req.Method, req.URL.Path, res.Status)
+			default:
+				l.Infof(format, req.Method, req.URL.Path, res.Status)
+			}
+			return err
+		}
+	}
+}
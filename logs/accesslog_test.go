@@ -0,0 +1,82 @@
+package logs_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestMiddlewareRequestLogger(t *testing.T) {
+	q, buf := newTestLogger("api")
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.Use(q.MiddlewareRequestLogger(logs.RequestLoggerOptions{SkipPaths: []string{"/health"}}))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "hello")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, tt := range []struct {
+		path   string
+		level  string
+		route  string
+		status float64
+		err    interface{}
+	}{
+		{"/users/42", "info", "/users/:id", http.StatusOK, nil},
+		{"/missing", "warning", "", http.StatusNotFound, "code=404, message=Not Found"},
+		{"/fail", "error", "/fail", http.StatusInternalServerError, "boom"},
+	} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		req.Header.Set("User-Agent", "tester")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != int(tt.status) {
+			t.Fatalf("%s responded %d", tt.path, rec.Code)
+		}
+
+		entries := decodeEntries(t, buf)
+		if len(entries) != 1 {
+			t.Fatalf("%s logged %d entries, want 1", tt.path, len(entries))
+		}
+		entry := entries[0]
+		for key, want := range map[string]interface{}{
+			"level":      tt.level,
+			"method":     http.MethodGet,
+			"path":       tt.path,
+			"status":     tt.status,
+			"requestID":  "req-1",
+			"remote_ip":  "192.0.2.1",
+			"user_agent": "tester",
+			"bytes_out":  float64(rec.Body.Len()),
+			"error":      tt.err,
+		} {
+			if entry[key] != want {
+				t.Errorf("%s: %s = %v, want %v", tt.path, key, entry[key], want)
+			}
+		}
+		if tt.route != "" && entry["route"] != tt.route {
+			t.Errorf("%s: route = %v, want %s", tt.path, entry["route"], tt.route)
+		}
+		if latency, ok := entry["latency_ms"].(float64); !ok || latency < 0 {
+			t.Errorf("%s: latency_ms = %v", tt.path, entry["latency_ms"])
+		}
+	}
+
+	buf.Reset()
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("skipped path logged %q", buf.String())
+	}
+}
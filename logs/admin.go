@@ -0,0 +1,71 @@
+package logs
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+)
+
+// levelBody is the body of the level admin routes
+type levelBody struct {
+	Level  string `json:"level"`
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// LevelHandler serves the log level: GET returns it as {"level":"info"}, PUT
+// and POST change it from a body of the same shape, answering 400 for unknown
+// levels. With a prefix query parameter the override of that prefix is read
+// or changed instead, an empty level removing it.
+func (q *CommonLogger) LevelHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		prefix := c.QueryParam("prefix")
+
+		switch c.Request().Method {
+		case http.MethodGet:
+			body := levelBody{Prefix: prefix}
+			if prefix == "" {
+				body.Level = levelName(toEchoLevel(q.configuredLevel()))
+			} else if override, ok := levelOverride(prefix); ok {
+				body.Level = levelName(toEchoLevel(override))
+			}
+			return c.JSON(http.StatusOK, body)
+
+		case http.MethodPut, http.MethodPost:
+			var body levelBody
+			if err := c.Bind(&body); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid body")
+			}
+			if prefix != "" && body.Level == "" {
+				SetLevelForPrefix(prefix, Unset)
+				return c.JSON(http.StatusOK, levelBody{Prefix: prefix})
+			}
+			level, err := ParseLevel(body.Level)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			if prefix != "" {
+				SetLevelForPrefix(prefix, level)
+			} else {
+				q.SetLevel(level)
+			}
+			return c.JSON(http.StatusOK, levelBody{Level: levelName(level), Prefix: prefix})
+		}
+		return echo.ErrMethodNotAllowed
+	}
+}
+
+// RegisterAdminRoutes serves LevelHandler on /level of g
+func (q *CommonLogger) RegisterAdminRoutes(g *echo.Group) {
+	h := q.LevelHandler()
+	g.GET("/level", h)
+	g.PUT("/level", h)
+	g.POST("/level", h)
+}
+
+func levelName(level gommonLog.Lvl) string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return "off"
+}
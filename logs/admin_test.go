@@ -0,0 +1,123 @@
+package logs_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// levelServer serves the admin routes of q under /admin
+func levelServer(q *logs.CommonLogger) *echo.Echo {
+	e := echo.New()
+	q.RegisterAdminRoutes(e.Group("/admin"))
+	return e
+}
+
+func levelRequest(t *testing.T, e *echo.Echo, method, target, body string) (int, map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var got map[string]string
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+	}
+	return rec.Code, got
+}
+
+func TestLevelHandler(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.INFO)
+	e := levelServer(q)
+
+	if code, got := levelRequest(t, e, http.MethodGet, "/admin/level", ""); code != http.StatusOK || got["level"] != "info" {
+		t.Fatalf("GET = %d %v, want info", code, got)
+	}
+	q.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatal("debug logged at info")
+	}
+
+	if code, got := levelRequest(t, e, http.MethodPut, "/admin/level", `{"level":"DEBUG"}`); code != http.StatusOK || got["level"] != "debug" {
+		t.Fatalf("PUT = %d %v, want debug", code, got)
+	}
+	q.Debug("shown")
+	if lastEntry(t, buf)["msg"] != "shown" {
+		t.Fatal("debug not logged after the change")
+	}
+	if code, got := levelRequest(t, e, http.MethodPost, "/admin/level", `{"level":"warn"}`); code != http.StatusOK || q.Level() != gommonLog.WARN {
+		t.Fatalf("POST = %d %v, level %v", code, got, q.Level())
+	}
+
+	for _, body := range []string{`{"level":"verbose"}`, `{"level":""}`, `not json`} {
+		if code, _ := levelRequest(t, e, http.MethodPut, "/admin/level", body); code != http.StatusBadRequest {
+			t.Errorf("PUT %s = %d, want 400", body, code)
+		}
+	}
+	if q.Level() != gommonLog.WARN {
+		t.Fatalf("level = %v, changed by a bad request", q.Level())
+	}
+}
+
+func TestLevelHandlerPrefix(t *testing.T) {
+	defer logs.ClearLevelOverrides()
+	q, _ := newTestLogger()
+	billing, buf := newTestLogger("billing")
+	billing.SetLevel(gommonLog.INFO)
+	e := levelServer(q)
+
+	if code, got := levelRequest(t, e, http.MethodGet, "/admin/level?prefix=billing", ""); code != http.StatusOK || got["level"] != "" {
+		t.Fatalf("GET = %d %v, want no override", code, got)
+	}
+	if code, _ := levelRequest(t, e, http.MethodPut, "/admin/level?prefix=billing", `{"level":"debug"}`); code != http.StatusOK {
+		t.Fatalf("PUT = %d", code)
+	}
+	if _, got := levelRequest(t, e, http.MethodGet, "/admin/level?prefix=billing", ""); got["level"] != "debug" || got["prefix"] != "billing" {
+		t.Fatalf("GET = %v, want the override", got)
+	}
+	billing.Debug("shown")
+	if lastEntry(t, buf)["msg"] != "shown" {
+		t.Fatal("debug not logged after the override")
+	}
+
+	levelRequest(t, e, http.MethodPut, "/admin/level?prefix=billing", `{"level":""}`)
+	buf.Reset()
+	billing.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Fatal("override kept after its removal")
+	}
+}
+
+func TestLevelHandlerConcurrent(t *testing.T) {
+	q, _ := newTestLogger()
+	e := levelServer(q)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			q.Debug("payload")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, level := range []string{"debug", "info", "debug", "error"} {
+			req := httptest.NewRequest(http.MethodPut, "/admin/level", strings.NewReader(`{"level":"`+level+`"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,256 @@
+package logs_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func validAuditEvent() logs.AuditEvent {
+	return logs.AuditEvent{
+		Actor:      "alice",
+		Action:     "delete",
+		Resource:   "invoice",
+		ResourceID: "inv-42",
+		Outcome:    "success",
+		Metadata:   map[string]interface{}{"reason": "duplicate", "password": "hunter2"},
+	}
+}
+
+func TestAuditSchema(t *testing.T) {
+	q, buf := newTestLogger()
+	if err := q.Audit(validAuditEvent()); err != nil {
+		t.Fatalf("Audit: %v", err)
+	}
+	entry := lastEntry(t, buf)
+	for key, want := range map[string]string{
+		"log_type":    "audit",
+		"actor":       "alice",
+		"action":      "delete",
+		"resource":    "invoice",
+		"resource_id": "inv-42",
+		"outcome":     "success",
+	} {
+		if entry[key] != want {
+			t.Errorf("%s = %v, want %q", key, entry[key], want)
+		}
+	}
+	metadata, _ := entry["metadata"].(string)
+	if !strings.Contains(metadata, `"reason":"duplicate"`) {
+		t.Fatalf("metadata = %v", entry["metadata"])
+	}
+	if strings.Contains(metadata, "hunter2") {
+		t.Fatalf("metadata not redacted: %v", metadata)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		edit    func(*logs.AuditEvent)
+		missing string
+	}{
+		{"actor", func(e *logs.AuditEvent) { e.Actor = "" }, "actor"},
+		{"blank action", func(e *logs.AuditEvent) { e.Action = "  " }, "action"},
+		{"resource", func(e *logs.AuditEvent) { e.Resource = "" }, "resource"},
+		{"outcome", func(e *logs.AuditEvent) { e.Outcome = "" }, "outcome"},
+		{"all", func(e *logs.AuditEvent) { *e = logs.AuditEvent{} }, "actor, action, resource, outcome"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			event := validAuditEvent()
+			tc.edit(&event)
+			err := q.Audit(event)
+			if err == nil || !strings.HasSuffix(err.Error(), "missing "+tc.missing) {
+				t.Fatalf("Audit = %v, want missing %s", err, tc.missing)
+			}
+			if buf.Len() != 0 {
+				t.Fatalf("invalid event logged: %q", buf.String())
+			}
+		})
+	}
+
+	// Optional fields are left out when empty
+	buf.Reset()
+	event := validAuditEvent()
+	event.ResourceID, event.Metadata = "", nil
+	if err := q.Audit(event); err != nil {
+		t.Fatal(err)
+	}
+	entry = lastEntry(t, buf)
+	if _, ok := entry["resource_id"]; ok {
+		t.Errorf("empty resource_id logged: %v", entry)
+	}
+	if _, ok := entry["metadata"]; ok {
+		t.Errorf("empty metadata logged: %v", entry)
+	}
+}
+
+func TestAuditBypassesLevel(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.WARN)
+	q.Info("filtered")
+	if err := q.Audit(validAuditEvent()); err != nil {
+		t.Fatal(err)
+	}
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 || entries[0]["log_type"] != "audit" {
+		t.Fatalf("entries = %v, want the audit entry only", entries)
+	}
+}
+
+func TestAuditOutput(t *testing.T) {
+	_, buf := captureShared(t)
+	audit := &bytes.Buffer{}
+	defer logs.SetAuditOutput(nil)
+	q, err := logs.NewCommonLogWithConfig(logs.Config{Format: "JSON", AuditOutput: audit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Info("application entry")
+	if err := q.WithRequestID("req-1").Audit(validAuditEvent()); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range decodeEntries(t, buf) {
+		if entry["log_type"] == "audit" {
+			t.Fatalf("audit entry written to the main output: %v", entry)
+		}
+	}
+	if entry := lastEntry(t, buf); entry["msg"] != "application entry" {
+		t.Fatalf("main output got %v", entry)
+	}
+	entries := decodeEntries(t, audit)
+	if len(entries) != 1 {
+		t.Fatalf("audit output got %d entries, want 1", len(entries))
+	}
+	if entries[0]["log_type"] != "audit" || entries[0]["requestID"] != "req-1" {
+		t.Fatalf("audit entry = %v", entries[0])
+	}
+
+	// Back to the main output once reset
+	logs.SetAuditOutput(nil)
+	if err := q.Audit(validAuditEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if entry := lastEntry(t, buf); entry["log_type"] != "audit" {
+		t.Fatalf("audit entry not written to the main output after reset: %v", entry)
+	}
+}
+
+func TestAuditRequestID(t *testing.T) {
+	q, buf := newTestLogger()
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.DELETE("/invoices/:id", func(c echo.Context) error {
+		event := validAuditEvent()
+		event.ResourceID = c.Param("id")
+		if err := logs.FromEchoContext(c).Audit(event); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodDelete, "/invoices/inv-7", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-7")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	var audit map[string]interface{}
+	for _, entry := range decodeEntries(t, buf) {
+		if entry["log_type"] == "audit" {
+			audit = entry
+		}
+	}
+	if audit == nil {
+		t.Fatalf("no audit entry in %q", buf.String())
+	}
+	if audit["requestID"] != "req-7" || audit["resource_id"] != "inv-7" {
+		t.Fatalf("audit entry = %v, want the request ID", audit)
+	}
+}
+
+func TestAuditRedactsMessage(t *testing.T) {
+	q, buf := newTestLogger()
+	logs.WithRedaction(logs.Redaction{Patterns: []*regexp.Regexp{logs.JWTPattern}})(q)
+	event := validAuditEvent()
+	event.Actor = jwt
+	if err := q.Audit(event); err != nil {
+		t.Fatal(err)
+	}
+	if msg := lastEntry(t, buf)["msg"]; msg != "Audit: [REDACTED] delete invoice" {
+		t.Fatalf("msg = %v", msg)
+	}
+
+	// And so are the entries written to the audit output
+	audit := &bytes.Buffer{}
+	logs.SetAuditOutput(audit)
+	defer logs.SetAuditOutput(nil)
+	if err := q.Audit(event); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(audit.String(), `msg="Audit: [REDACTED] delete invoice"`) {
+		t.Fatalf("audit output not redacted: %q", audit.String())
+	}
+}
+
+func TestAuditSharedOutput(t *testing.T) {
+	q, buf := captureShared(t)
+	q.SetLevel(gommonLog.ERROR)
+	// The audit output is the output of the logger, so both write under the
+	// same lock, which the race detector checks
+	logs.SetAuditOutput(buf)
+	defer logs.SetAuditOutput(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			q.Info("filtered")
+			q.Error("failed")
+		}()
+		go func() {
+			defer wg.Done()
+			if err := q.Audit(validAuditEvent()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var audits, errs int
+	for _, entry := range decodeEntries(t, buf) {
+		switch {
+		case entry["log_type"] == "audit":
+			audits++
+		case entry["msg"] == "failed":
+			errs++
+		default:
+			t.Fatalf("unexpected entry %v", entry)
+		}
+	}
+	if audits != 10 || errs != 10 {
+		t.Fatalf("got %d audit and %d error entries, want 10 each", audits, errs)
+	}
+}
+
+func TestAuditOutputFormat(t *testing.T) {
+	q, _ := captureShared(t)
+	audit := &bytes.Buffer{}
+	logs.SetAuditOutput(audit)
+	defer logs.SetAuditOutput(nil)
+
+	logs.WithFormatter(&logrus.TextFormatter{DisableColors: true})(q)
+	if err := q.Audit(validAuditEvent()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(audit.String(), "log_type=audit") {
+		t.Fatalf("audit entry not in the format of the logger: %q", audit.String())
+	}
+}
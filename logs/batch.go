@@ -0,0 +1,206 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// BatchOptions configures a BatchHook
+type BatchOptions struct {
+	// BatchSize sends the buffered entries as soon as that many are
+	// buffered. Defaults to 100.
+	BatchSize int
+	// FlushInterval sends a partial batch once that long passed since the
+	// previous send. Defaults to 1s.
+	FlushInterval time.Duration
+	// QueueSize bounds the entries waiting to be batched, entries logged
+	// while it is full are dropped. Defaults to 10 times BatchSize.
+	QueueSize int
+}
+
+// BatchHook is a logrus hook shipping formatted entries asynchronously, in
+// batches sent when BatchSize entries are buffered or FlushInterval elapsed,
+// whichever comes first. Logging never blocks on the sink.
+type BatchHook struct {
+	send func(batch [][]byte) error
+	// encode replaces the formatter of the logger when set
+	encode func(e *logrus.Entry) ([]byte, error)
+	opts   BatchOptions
+
+	queue   chan []byte
+	flush   chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// NewBatchHook starts a BatchHook passing every batch to send, to register
+// with AddHook. Failed sends are reported on stderr and not retried.
+func NewBatchHook(send func(batch [][]byte) error, opts BatchOptions) *BatchHook {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10 * opts.BatchSize
+	}
+
+	h := &BatchHook{
+		send:    send,
+		opts:    opts,
+		queue:   make(chan []byte, opts.QueueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// NewHTTPBatchSender returns a send function for NewBatchHook posting every
+// batch to url as newline delimited entries
+func NewHTTPBatchSender(url string, client *http.Client) func(batch [][]byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(batch [][]byte) error {
+		resp, err := client.Post(url, "application/x-ndjson", bytes.NewReader(bytes.Join(batch, nil)))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("logs: batch endpoint responded %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// Levels implements logrus.Hook
+func (h *BatchHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *BatchHook) Fire(e *logrus.Entry) error {
+	encode := h.encode
+	if encode == nil {
+		encode = e.Logger.Formatter.Format
+	}
+	line, err := encode(e)
+	if err != nil {
+		return err
+	}
+	// The formatter may reuse its buffer
+	line = append([]byte(nil), line...)
+
+	// Checked first, a select with room left in the queue would pick either
+	select {
+	case <-h.done:
+		h.dropped.Add(1)
+		return nil
+	default:
+	}
+	select {
+	case h.queue <- line:
+	default:
+		h.dropped.Add(1)
+	}
+	return nil
+}
+
+// Dropped returns the number of entries dropped because the queue was full
+// or the hook closed
+func (h *BatchHook) Dropped() int64 {
+	return h.dropped.Load()
+}
+
+// Flush sends the buffered entries and waits for the send to return
+func (h *BatchHook) Flush() {
+	ack := make(chan struct{})
+	select {
+	case h.flush <- ack:
+		<-ack
+	case <-h.stopped:
+	}
+}
+
+// Close sends the buffered entries and stops the hook
+func (h *BatchHook) Close() error {
+	h.once.Do(func() {
+		close(h.done)
+	})
+	<-h.stopped
+	return nil
+}
+
+func (h *BatchHook) run() {
+	defer close(h.stopped)
+
+	batch := make([][]byte, 0, h.opts.BatchSize)
+	timer := time.NewTimer(h.opts.FlushInterval)
+	defer timer.Stop()
+
+	send := func() {
+		if len(batch) > 0 {
+			if err := h.send(batch); err != nil {
+				fmt.Fprintf(os.Stderr, "logs: failed to send %d entries: %v\n", len(batch), err)
+			}
+			batch = make([][]byte, 0, h.opts.BatchSize)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(h.opts.FlushInterval)
+	}
+	drain := func() {
+		for {
+			select {
+			case line := <-h.queue:
+				batch = append(batch, line)
+				if len(batch) >= h.opts.BatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case line := <-h.queue:
+			batch = append(batch, line)
+			if len(batch) >= h.opts.BatchSize {
+				send()
+			}
+		case <-timer.C:
+			send()
+		case ack := <-h.flush:
+			drain()
+			close(ack)
+		case <-h.done:
+			drain()
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package logs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// batchRecorder returns a send function for NewBatchHook delivering the size
+// of every batch to the returned channel
+func batchRecorder() (func(batch [][]byte) error, chan int) {
+	sizes := make(chan int, 100)
+	return func(batch [][]byte) error {
+		sizes <- len(batch)
+		return nil
+	}, sizes
+}
+
+// receiveBatch returns the size of the next batch sent within the given
+// time, zero meaning already sent
+func receiveBatch(t *testing.T, sizes chan int, within time.Duration) int {
+	t.Helper()
+	if within == 0 {
+		select {
+		case n := <-sizes:
+			return n
+		default:
+			t.Fatal("no batch sent")
+		}
+	}
+	select {
+	case n := <-sizes:
+		return n
+	case <-time.After(within):
+		t.Fatalf("no batch sent within %v", within)
+		return 0
+	}
+}
+
+func TestBatchHookSizeTrigger(t *testing.T) {
+	send, sizes := batchRecorder()
+	h := logs.NewBatchHook(send, logs.BatchOptions{BatchSize: 3, FlushInterval: time.Hour})
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	for i := 0; i < 3; i++ {
+		q.Info("entry")
+	}
+	if n := receiveBatch(t, sizes, time.Second); n != 3 {
+		t.Fatalf("batch of %d entries, want 3", n)
+	}
+}
+
+func TestBatchHookTimeTrigger(t *testing.T) {
+	send, sizes := batchRecorder()
+	h := logs.NewBatchHook(send, logs.BatchOptions{BatchSize: 100, FlushInterval: 200 * time.Millisecond})
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	q.Info("one")
+	q.Info("two")
+	select {
+	case n := <-sizes:
+		t.Fatalf("partial batch of %d sent before the interval", n)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := receiveBatch(t, sizes, time.Second); n != 2 {
+		t.Fatalf("batch of %d entries, want the partial 2", n)
+	}
+}
+
+func TestBatchHookFlushAndClose(t *testing.T) {
+	send, sizes := batchRecorder()
+	h := logs.NewBatchHook(send, logs.BatchOptions{BatchSize: 100, FlushInterval: time.Hour})
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	q.Info("flushed")
+	h.Flush()
+	if n := receiveBatch(t, sizes, 0); n != 1 {
+		t.Fatalf("Flush sent %d entries, want 1", n)
+	}
+
+	q.Info("closed")
+	h.Close()
+	if n := receiveBatch(t, sizes, 0); n != 1 {
+		t.Fatalf("Close sent %d entries, want 1", n)
+	}
+	q.Info("after close")
+	if n := h.Dropped(); n != 1 {
+		t.Fatalf("Dropped = %d, want the entry logged after Close", n)
+	}
+}
@@ -0,0 +1,209 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBodyDumpMaxBytes = 4 << 10
+
+// defaultBodyDumpSkipContentTypes are the binary bodies never dumped
+var defaultBodyDumpSkipContentTypes = []string{"multipart/", "application/octet-stream"}
+
+// BodyDumpOptions configures MiddlewareBodyDump
+type BodyDumpOptions struct {
+	// MaxBytes is the number of bytes of each body logged, 4 KiB by default
+	MaxBytes int
+	// SkipPaths are the request paths not dumped at all
+	SkipPaths []string
+	// SkipContentTypes are the media types, or their prefix when ending with
+	// a slash, whose bodies are left out, multipart and octet-stream ones by
+	// default
+	SkipContentTypes []string
+	// Level is the level of the dumps, DEBUG by default
+	Level gommonLog.Lvl
+	// StatusLevels log the responses of these statuses at another level,
+	// e.g. {500: gommonLog.ERROR}
+	StatusLevels map[int]gommonLog.Lvl
+}
+
+// MiddlewareBodyDump logs the request and response bodies, up to MaxBytes
+// each, with a truncated field when one was cut. The bodies are captured as
+// they are read by the handler and written to the client, so streaming keeps
+// working and large bodies are never buffered. The redaction rules apply to
+// JSON bodies, the fields to redact being matched by key.
+//
+// Meant for debugging integrations, on the routes it is registered on only.
+func (q *CommonLogger) MiddlewareBodyDump(opts BodyDumpOptions) echo.MiddlewareFunc {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultBodyDumpMaxBytes
+	}
+	if opts.SkipContentTypes == nil {
+		opts.SkipContentTypes = defaultBodyDumpSkipContentTypes
+	}
+	if opts.Level == Unset {
+		opts.Level = gommonLog.DEBUG
+	}
+	skip := make(map[string]struct{}, len(opts.SkipPaths))
+	for _, p := range opts.SkipPaths {
+		skip[p] = struct{}{}
+	}
+	// The least verbose level a dump may be logged at, none is logged when
+	// it is disabled
+	quietest := toLogrusLevel(opts.Level)
+	for _, lvl := range opts.StatusLevels {
+		if l := toLogrusLevel(lvl); l < quietest {
+			quietest = l
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if _, ok := skip[req.URL.Path]; ok {
+				return next(c)
+			}
+			l, ok := c.Get(echoContextKey).(*CommonLogger)
+			if !ok {
+				l = q
+			}
+			if !l.enabled(quietest) {
+				return next(c)
+			}
+
+			reqBody := &cappedBuffer{max: opts.MaxBytes}
+			dumpRequest := req.Body != nil && !skipContentType(req.Header.Get(echo.HeaderContentType), opts.SkipContentTypes)
+			if dumpRequest {
+				req.Body = &teeReadCloser{Reader: io.TeeReader(req.Body, reqBody), Closer: req.Body}
+			}
+			resBody := &cappedBuffer{max: opts.MaxBytes}
+			res := c.Response()
+			res.Writer = &bodyDumpWriter{ResponseWriter: res.Writer, body: resBody}
+
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response to dump it
+				c.Error(err)
+			}
+
+			level := opts.Level
+			if lvl, ok := opts.StatusLevels[res.Status]; ok {
+				level = lvl
+			}
+			if !l.enabled(toLogrusLevel(level)) {
+				return err
+			}
+
+			rd := l.redactor()
+			fields := logrus.Fields{
+				"method": req.Method,
+				"path":   req.URL.Path,
+				"status": res.Status,
+			}
+			if dumpRequest {
+				fields["request_body"] = redactBody(rd, req.Header.Get(echo.HeaderContentType), reqBody)
+			}
+			resType := res.Header().Get(echo.HeaderContentType)
+			if !skipContentType(resType, opts.SkipContentTypes) {
+				fields["response_body"] = redactBody(rd, resType, resBody)
+			}
+			if reqBody.truncated || resBody.truncated {
+				fields["truncated"] = true
+			}
+			l.decorateLog().WithFields(fields).Logf(toLogrusLevel(level), "Body dump %s %s %d", req.Method, req.URL.Path, res.Status)
+			return err
+		}
+	}
+}
+
+// skipContentType reports whether the media type of contentType is among
+// skipped
+func skipContentType(contentType string, skipped []string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	for _, s := range skipped {
+		s = strings.ToLower(s)
+		if mediaType == s || strings.HasSuffix(s, "/") && strings.HasPrefix(mediaType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactBody applies the redaction rules to a captured body. Truncated JSON
+// bodies do not parse, their redacted fields are matched textually instead.
+func redactBody(rd *redactor, contentType string, body *cappedBuffer) string {
+	text := string(body.buf)
+	if !strings.Contains(strings.ToLower(contentType), "json") || len(rd.fields) == 0 {
+		return rd.scrub(text)
+	}
+	var v interface{}
+	if err := json.Unmarshal(body.buf, &v); err == nil {
+		if b, err := json.Marshal(rd.redactField("", v)); err == nil {
+			return rd.scrub(string(b))
+		}
+	}
+	for f := range rd.fields {
+		p := regexp.MustCompile(`(?i)("` + regexp.QuoteMeta(f) + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+		text = p.ReplaceAllString(text, `${1}"`+redactedValue+`"`)
+	}
+	return rd.scrub(text)
+}
+
+// cappedBuffer keeps the first max bytes written to it
+type cappedBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyDumpWriter copies the response written to the client to body
+type bodyDumpWriter struct {
+	http.ResponseWriter
+	body io.Writer
+}
+
+func (w *bodyDumpWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyDumpWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bodyDumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,128 @@
+package logs_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// bodyDumpServer echoes the request body, as JSON unless the path says
+// otherwise, recording how much of it the handler read
+func bodyDumpServer(q *logs.CommonLogger, opts logs.BodyDumpOptions, read *int) *echo.Echo {
+	e := echo.New()
+	e.Use(q.MiddlewareBodyDump(opts))
+	echoBody := func(c echo.Context) error {
+		b, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		*read = len(b)
+		return c.JSONBlob(http.StatusOK, b)
+	}
+	e.POST("/partner", echoBody)
+	e.POST("/health", echoBody)
+	e.POST("/fail", func(c echo.Context) error {
+		return c.String(http.StatusInternalServerError, "upstream down")
+	})
+	return e
+}
+
+func postBody(e *echo.Echo, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBodyDumpRedaction(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.DEBUG)
+	var read int
+	e := bodyDumpServer(q, logs.BodyDumpOptions{}, &read)
+
+	rec := postBody(e, "/partner", echo.MIMEApplicationJSON, `{"user":"ada","password":"hunter2"}`)
+	if !strings.Contains(rec.Body.String(), "hunter2") {
+		t.Fatalf("response %q, the client must get the body unchanged", rec.Body.String())
+	}
+	entry := lastEntry(t, buf)
+	if entry["level"] != "debug" || entry["status"] != float64(http.StatusOK) {
+		t.Fatalf("entry = %v", entry)
+	}
+	for _, key := range []string{"request_body", "response_body"} {
+		body, _ := entry[key].(string)
+		if strings.Contains(body, "hunter2") || !strings.Contains(body, `"password":"[REDACTED]"`) || !strings.Contains(body, `"user":"ada"`) {
+			t.Errorf("%s = %q, want the password redacted", key, body)
+		}
+	}
+	if _, ok := entry["truncated"]; ok {
+		t.Fatalf("entry = %v, small bodies marked truncated", entry)
+	}
+}
+
+func TestBodyDumpTruncation(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.DEBUG)
+	var read int
+	e := bodyDumpServer(q, logs.BodyDumpOptions{MaxBytes: 16}, &read)
+
+	body := `{"items":"` + strings.Repeat("x", 100) + `","password":"hunter2"}`
+	rec := postBody(e, "/partner", echo.MIMEApplicationJSON, body)
+	if read != len(body) || rec.Body.String() != body {
+		t.Fatalf("handler read %d bytes and responded %d, want the %d of the body", read, rec.Body.Len(), len(body))
+	}
+	entry := lastEntry(t, buf)
+	if entry["truncated"] != true {
+		t.Fatalf("entry = %v, want truncated", entry)
+	}
+	if dumped := entry["request_body"].(string); dumped != body[:16] {
+		t.Fatalf("request_body = %q, want the first 16 bytes", dumped)
+	}
+}
+
+func TestBodyDumpSkips(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.DEBUG)
+	var read int
+	e := bodyDumpServer(q, logs.BodyDumpOptions{SkipPaths: []string{"/health"}}, &read)
+
+	postBody(e, "/partner", echo.MIMEOctetStream, "\x00\x01binary")
+	entry := lastEntry(t, buf)
+	if _, ok := entry["request_body"]; ok {
+		t.Fatalf("entry = %v, binary request dumped", entry)
+	}
+	if read != 8 {
+		t.Fatalf("handler read %d bytes, want 8", read)
+	}
+
+	buf.Reset()
+	postBody(e, "/health", echo.MIMEApplicationJSON, `{}`)
+	if buf.Len() != 0 {
+		t.Fatalf("skipped path dumped: %q", buf.String())
+	}
+}
+
+func TestBodyDumpLevels(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.INFO)
+	var read int
+	e := bodyDumpServer(q, logs.BodyDumpOptions{
+		StatusLevels: map[int]gommonLog.Lvl{http.StatusInternalServerError: gommonLog.ERROR},
+	}, &read)
+
+	postBody(e, "/partner", echo.MIMEApplicationJSON, `{}`)
+	if buf.Len() != 0 {
+		t.Fatalf("debug dump logged at info: %q", buf.String())
+	}
+	postBody(e, "/fail", echo.MIMETextPlain, "request")
+	entry := lastEntry(t, buf)
+	if entry["level"] != "error" || entry["response_body"] != "upstream down" {
+		t.Fatalf("entry = %v, want the failed response dumped at error", entry)
+	}
+}
@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/getsentry/sentry-go"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+// breadcrumbLevel is the minimum level recorded as Sentry breadcrumbs, none
+// when Unset
+var breadcrumbLevel atomic.Int32
+
+// SetSentryBreadcrumbs records the debug, info and warn entries from level
+// as breadcrumbs of the Sentry scope, attached to the next event reported in
+// the same request, see MiddlewareLoggerRequestID. The message, level,
+// prefix, under category, and fields are recorded, within the
+// MaxBreadcrumbs of the Sentry client. INFO records info and warn entries,
+// DEBUG the debug ones too, and Unset, ERROR or OFF disables breadcrumbs,
+// the default.
+func SetSentryBreadcrumbs(level gommonLog.Lvl) {
+	if level > gommonLog.WARN {
+		level = Unset
+	}
+	breadcrumbLevel.Store(int32(level))
+}
+
+// WithSentryBreadcrumbs is the option form of SetSentryBreadcrumbs
+func WithSentryBreadcrumbs(level gommonLog.Lvl) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetSentryBreadcrumbs(level)
+		return nil
+	}
+}
+
+// sentryHub returns the hub bound to the request of q by
+// MiddlewareLoggerRequestID, the current hub otherwise
+func (q *CommonLogger) sentryHub() *sentry.Hub {
+	return hubFromContext(q.ctx)
+}
+
+func hubFromContext(ctx context.Context) *sentry.Hub {
+	if ctx != nil {
+		if hub := sentry.GetHubFromContext(ctx); hub != nil {
+			return hub
+		}
+	}
+	return sentry.CurrentHub()
+}
+
+// breadcrumbHook records the entries as breadcrumbs when enabled with
+// SetSentryBreadcrumbs. It runs after redactHook, so no secret is recorded.
+type breadcrumbHook struct{}
+
+func (h *breadcrumbHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.WarnLevel, logrus.InfoLevel, logrus.DebugLevel}
+}
+
+func (h *breadcrumbHook) Fire(e *logrus.Entry) error {
+	level := gommonLog.Lvl(breadcrumbLevel.Load())
+	if level == Unset || e.Level > toLogrusLevel(level) {
+		return nil
+	}
+	hub := hubFromContext(e.Context)
+	if hub.Client() == nil {
+		return nil
+	}
+
+	data := make(map[string]interface{}, len(e.Data))
+	category, _ := e.Data["prefix"].(string)
+	for k, v := range e.Data {
+		if k == "prefix" {
+			continue
+		}
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		data[k] = v
+	}
+	hub.AddBreadcrumb(&sentry.Breadcrumb{
+		Type:      "default",
+		Category:  category,
+		Message:   e.Message,
+		Data:      data,
+		Level:     breadcrumbSentryLevel(e.Level),
+		Timestamp: e.Time,
+	}, nil)
+	return nil
+}
+
+func breadcrumbSentryLevel(level logrus.Level) sentry.Level {
+	switch level {
+	case logrus.WarnLevel:
+		return sentry.LevelWarning
+	case logrus.InfoLevel:
+		return sentry.LevelInfo
+	}
+	return sentry.LevelDebug
+}
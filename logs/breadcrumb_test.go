@@ -0,0 +1,100 @@
+package logs_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestSentryBreadcrumbs(t *testing.T) {
+	sentry := recordSentry(t)
+	logs.SetSentryBreadcrumbs(gommonLog.INFO)
+	defer logs.SetSentryBreadcrumbs(logs.Unset)
+	q, _ := newTestLogger("checkout")
+	q.SetLevel(gommonLog.DEBUG)
+
+	q.Info("cart loaded")
+	q.Debug("cart payload")
+	q.WithField("items", 3).Infof("pricing %s", "cart")
+	q.Warn("coupon expired")
+	q.Error(errors.New("payment declined"))
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	want := []string{"cart loaded", "pricing cart", "coupon expired"}
+	if len(crumbs) != len(want) {
+		t.Fatalf("got %d breadcrumbs, want %v", len(crumbs), want)
+	}
+	for i, crumb := range crumbs {
+		if crumb.Message != want[i] || crumb.Category != "checkout" {
+			t.Errorf("breadcrumb %d = %q in %q, want %q in checkout", i, crumb.Message, crumb.Category, want[i])
+		}
+	}
+	if crumbs[1].Data["items"] != 3 || crumbs[2].Level != "warning" {
+		t.Fatalf("breadcrumbs = %+v %+v, want the fields and level", crumbs[1], crumbs[2])
+	}
+}
+
+func TestSentryBreadcrumbsDebug(t *testing.T) {
+	sentry := recordSentry(t)
+	logs.SetSentryBreadcrumbs(gommonLog.DEBUG)
+	defer logs.SetSentryBreadcrumbs(logs.Unset)
+	q, _ := newTestLogger()
+	q.SetLevel(gommonLog.DEBUG)
+
+	q.Debug("cart payload")
+	q.Error(errors.New("payment declined"))
+	events := sentry.Events()
+	if len(events) != 1 || len(events[0].Breadcrumbs) != 1 || events[0].Breadcrumbs[0].Level != "debug" {
+		t.Fatalf("events = %+v, want the debug breadcrumb", events)
+	}
+}
+
+func TestSentryBreadcrumbsDisabled(t *testing.T) {
+	sentry := recordSentry(t)
+	q, _ := newTestLogger()
+	q.Info("cart loaded")
+	q.Error(errors.New("payment declined"))
+	events := sentry.Events()
+	if len(events) != 1 || len(events[0].Breadcrumbs) != 0 {
+		t.Fatalf("events = %+v, want no breadcrumbs by default", events)
+	}
+}
+
+func TestSentryBreadcrumbsPerRequest(t *testing.T) {
+	sentry := recordSentry(t)
+	logs.SetSentryBreadcrumbs(gommonLog.INFO)
+	defer logs.SetSentryBreadcrumbs(logs.Unset)
+	q, _ := newTestLogger()
+
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.GET("/:step", func(c echo.Context) error {
+		l := logs.FromEchoContext(c)
+		l.Info("step " + c.Param("step"))
+		if c.Param("step") == "fail" {
+			l.Error(errors.New("failed"))
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	for _, step := range []string{"ok", "fail"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+step, nil))
+	}
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	crumbs := events[0].Breadcrumbs
+	if len(crumbs) != 1 || crumbs[0].Message != "step fail" {
+		t.Fatalf("breadcrumbs = %+v, want only those of the failed request", crumbs)
+	}
+}
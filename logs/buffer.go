@@ -0,0 +1,121 @@
+package logs
+
+import (
+	"bufio"
+	"io"
+	"sync"
+	"time"
+)
+
+// BufferOptions configures NewBufferedWriter
+type BufferOptions struct {
+	// Size is the size of the buffer in bytes, written out when full, 64 KiB
+	// by default
+	Size int
+	// FlushInterval is the longest time an entry stays in the buffer, 100ms
+	// by default
+	FlushInterval time.Duration
+}
+
+const (
+	defaultBufferSize          = 64 << 10
+	defaultBufferFlushInterval = 100 * time.Millisecond
+)
+
+// BufferedWriter batches the writes to an underlying writer, saving a write
+// syscall per entry on busy services. It is written out when the buffer is
+// full, every FlushInterval, and before Fatal and Panic terminate the
+// process.
+type BufferedWriter struct {
+	mu     sync.Mutex
+	buf    *bufio.Writer
+	w      io.Writer
+	closed bool
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewBufferedWriter returns a BufferedWriter writing to w, and starts the
+// goroutine writing it out periodically, stopped by Close
+func NewBufferedWriter(w io.Writer, opts BufferOptions) *BufferedWriter {
+	if opts.Size <= 0 {
+		opts.Size = defaultBufferSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultBufferFlushInterval
+	}
+	b := &BufferedWriter{
+		buf:  bufio.NewWriterSize(w, opts.Size),
+		w:    w,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	registerSink(b, "buffered output", func() {
+		b.Flush()
+	}, b.Close)
+	go b.run(opts.FlushInterval)
+	return b
+}
+
+// WithBufferedOutput buffers the current output with a BufferedWriter, see
+// NewBufferedWriter. Flush writes it out along with the error reports.
+func WithBufferedOutput(opts BufferOptions) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		q.SetOutput(NewBufferedWriter(q.Output(), opts))
+		return nil
+	}
+}
+
+func (b *BufferedWriter) run(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// Write implements io.Writer. Once closed, p is written straight to the
+// underlying writer.
+func (b *BufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return b.w.Write(p)
+	}
+	return b.buf.Write(p)
+}
+
+// Flush writes the buffered entries to the underlying writer
+func (b *BufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Flush()
+}
+
+// Close stops the periodic flushes and writes the buffer out. The underlying
+// writer is left open.
+func (b *BufferedWriter) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.stop)
+	})
+	<-b.done
+
+	unregisterSink(b)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return b.buf.Flush()
+}
@@ -0,0 +1,173 @@
+package logs_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// syncBuffer is a bytes.Buffer safe for the flusher goroutine to write to
+type syncBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes++
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func (s *syncBuffer) Writes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writes
+}
+
+func TestBufferedWriterSize(t *testing.T) {
+	out := &syncBuffer{}
+	b := logs.NewBufferedWriter(out, logs.BufferOptions{Size: 64, FlushInterval: time.Hour})
+	defer b.Close()
+
+	b.Write([]byte(strings.Repeat("a", 40)))
+	if out.Writes() != 0 {
+		t.Fatal("written out before the buffer is full")
+	}
+	b.Write([]byte(strings.Repeat("b", 40)))
+	if out.Writes() != 1 || len(out.String()) != 64 {
+		t.Fatalf("%d writes of %d bytes, want the full buffer written once", out.Writes(), len(out.String()))
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.String()) != 80 {
+		t.Fatalf("%d bytes written after Flush, want 80", len(out.String()))
+	}
+}
+
+func TestBufferedWriterInterval(t *testing.T) {
+	out := &syncBuffer{}
+	b := logs.NewBufferedWriter(out, logs.BufferOptions{FlushInterval: 10 * time.Millisecond})
+	defer b.Close()
+
+	for i := 0; i < 100; i++ {
+		b.Write([]byte("line\n"))
+	}
+	deadline := time.Now().Add(time.Second)
+	for out.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := strings.Count(out.String(), "line\n"); got != 100 {
+		t.Fatalf("%d lines written out by the interval, want 100", got)
+	}
+	if out.Writes() > 2 {
+		t.Fatalf("%d writes, want the lines written out together", out.Writes())
+	}
+}
+
+func TestBufferedWriterClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	out := &syncBuffer{}
+	b := logs.NewBufferedWriter(out, logs.BufferOptions{FlushInterval: time.Hour})
+	b.Write([]byte("buffered\n"))
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "buffered\n" {
+		t.Fatalf("Close wrote out %q", out.String())
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	// The flusher goroutine is gone
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("%d goroutines after Close, %d before", n, before)
+	}
+
+	b.Write([]byte("direct\n"))
+	if out.String() != "buffered\ndirect\n" {
+		t.Fatalf("write after Close buffered: %q", out.String())
+	}
+}
+
+func TestBufferedOutputFatal(t *testing.T) {
+	out := &syncBuffer{}
+	q := logs.NewCommonLogWithOutput(out)
+	if err := logs.WithBufferedOutput(logs.BufferOptions{FlushInterval: time.Hour})(q); err != nil {
+		t.Fatal(err)
+	}
+	defer q.Output().(*logs.BufferedWriter).Close()
+
+	q.Info("before")
+	var atExit string
+	q.SetExitFunc(func(int) {
+		atExit = out.String()
+	})
+	q.Fatal("crash")
+	if !strings.Contains(atExit, "before") || !strings.Contains(atExit, "crash") {
+		t.Fatalf("written out at exit: %q, want both entries", atExit)
+	}
+}
+
+func TestBufferedOutputFlush(t *testing.T) {
+	out := &syncBuffer{}
+	q := logs.NewCommonLogWithOutput(out)
+	logs.WithBufferedOutput(logs.BufferOptions{FlushInterval: time.Hour})(q)
+	defer q.Output().(*logs.BufferedWriter).Close()
+
+	q.Info("buffered")
+	if out.String() != "" {
+		t.Fatal("entry written out right away")
+	}
+	logs.Flush(time.Second)
+	if !strings.Contains(out.String(), "buffered") {
+		t.Fatalf("Flush wrote out %q", out.String())
+	}
+}
+
+func benchmarkFileOutput(b *testing.B, buffered bool) {
+	f, err := os.Create(filepath.Join(b.TempDir(), "bench.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	q := logs.NewCommonLogWithOutput(f, "bench")
+	if buffered {
+		logs.WithBufferedOutput(logs.BufferOptions{})(q)
+		defer q.Output().(*logs.BufferedWriter).Close()
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.Info("ingested record")
+	}
+}
+
+func BenchmarkUnbufferedOutput(b *testing.B) {
+	benchmarkFileOutput(b, false)
+}
+
+func BenchmarkBufferedOutput(b *testing.B) {
+	benchmarkFileOutput(b, true)
+}
@@ -0,0 +1,222 @@
+package logs
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxCallerDepth bounds the stack walked looking for the caller
+const maxCallerDepth = 32
+
+// CallerFormatter renders the source field from the full path of the calling
+// file, the line and the fully qualified function name
+type CallerFormatter func(file string, line int, fn string) string
+
+var callerFormatter atomic.Pointer[CallerFormatter]
+
+// SetCallerFormatter customizes how the source field of every logger is
+// rendered, for instance as "func (file:line)". A nil fn restores the default
+// file:line:func() layout.
+func SetCallerFormatter(fn func(file string, line int, fn string) string) {
+	if fn == nil {
+		callerFormatter.Store(nil)
+		return
+	}
+	f := CallerFormatter(fn)
+	callerFormatter.Store(&f)
+}
+
+func formatCaller(file string, line int, fn string) string {
+	if f := callerFormatter.Load(); f != nil {
+		return (*f)(file, line, fn)
+	}
+	return defaultCallerFormatter(file, line, fn)
+}
+
+// defaultCallerFormatter renders handler.go:42:Create()
+func defaultCallerFormatter(file string, line int, fn string) string {
+	if i := strings.LastIndex(fn, "."); i != -1 {
+		fn = fn[i+1:]
+	}
+	return fmt.Sprintf("%s:%v:%s()", path.Base(file), line, path.Base(fn))
+}
+
+// CallerFormat selects how the caller of the entries is logged
+type CallerFormat string
+
+const (
+	// CallerShort logs handler.go:42:Create() as source, the default
+	CallerShort CallerFormat = "short"
+	// CallerFull logs github.com/acme/payments/handler/handler.go:42 as
+	// source, telling apart the files with the same name
+	CallerFull CallerFormat = "full"
+	// CallerSplit logs the file in the CallerFull form, the line and the
+	// fully qualified function as the file, line and func fields instead of
+	// source, for querying them separately. Unlike source, fields of the
+	// same names given to WithFields replace them.
+	CallerSplit CallerFormat = "split"
+)
+
+// The fields of CallerSplit
+const (
+	callerFileField = "file"
+	callerLineField = "line"
+	callerFuncField = "func"
+)
+
+var callerFormat atomic.Value
+
+// fullFormatter is the formatter set by CallerFull
+var fullFormatter = CallerFormatter(fullCallerFormatter)
+
+// SetCallerFormat selects the caller format of every logger, CallerShort
+// when empty. CallerShort and CallerSplit keep the formatter set with
+// SetCallerFormatter, which CallerFull replaces.
+func SetCallerFormat(f CallerFormat) error {
+	f, err := parseCallerFormat(f)
+	if err != nil {
+		return err
+	}
+	switch f {
+	case CallerShort:
+		callerFormatter.CompareAndSwap(&fullFormatter, nil)
+		callerFormat.Store(CallerShort)
+	case CallerFull:
+		callerFormatter.Store(&fullFormatter)
+		callerFormat.Store(CallerFull)
+	case CallerSplit:
+		callerFormatter.CompareAndSwap(&fullFormatter, nil)
+		callerFormat.Store(CallerSplit)
+	}
+	return nil
+}
+
+// parseCallerFormat returns f lower cased, CallerShort when empty
+func parseCallerFormat(f CallerFormat) (CallerFormat, error) {
+	switch f = CallerFormat(strings.ToLower(string(f))); f {
+	case "":
+		return CallerShort, nil
+	case CallerShort, CallerFull, CallerSplit:
+		return f, nil
+	}
+	return "", fmt.Errorf("logs: unknown caller format %q", f)
+}
+
+// WithCallerFormat is the option form of SetCallerFormat
+func WithCallerFormat(f CallerFormat) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			_, err := parseCallerFormat(f)
+			return err
+		}
+		return SetCallerFormat(f)
+	}
+}
+
+// fullCallerFormatter renders github.com/acme/payments/handler/handler.go:42
+func fullCallerFormatter(file string, line int, fn string) string {
+	return fmt.Sprintf("%s:%v", packageFile(file, fn), line)
+}
+
+// packageFile returns the import path of the package of fn joined with the
+// base name of file
+func packageFile(file, fn string) string {
+	pkg := fn
+	slash := strings.LastIndex(pkg, "/")
+	if i := strings.Index(pkg[slash+1:], "."); i != -1 {
+		pkg = pkg[:slash+1+i]
+	}
+	if pkg == "" {
+		return path.Base(file)
+	}
+	return pkg + "/" + path.Base(file)
+}
+
+// WithCallerSkip returns a child logger reporting as source the caller n
+// frames above the call site, for helpers wrapping the logger. Frames of this
+// package are always skipped, wrappers need no skip when defined here.
+func (q *CommonLogger) WithCallerSkip(n int) *CommonLogger {
+	child := q.clone()
+	child.callerSkip = n
+	return child
+}
+
+// CallerSkip is the option form of WithCallerSkip
+func CallerSkip(n int) Option {
+	return func(q *CommonLogger) error {
+		if n < 0 {
+			return fmt.Errorf("logs: invalid caller skip %d", n)
+		}
+		q.callerSkip = n
+		return nil
+	}
+}
+
+// callerSource returns the source of the first frame outside this package
+// and the runtime, which shows up when logging a recovered panic, skipping
+// q.callerSkip more frames
+func (q *CommonLogger) callerSource() string {
+	f, ok := q.callerFrame()
+	if !ok {
+		return ""
+	}
+	return formatCaller(f.File, f.Line, f.Function)
+}
+
+// callerFields returns the fields locating the caller, source or, with
+// CallerSplit, file, line and func. The file is added in the long_file form
+// too when the formatter of q renders it.
+func (q *CommonLogger) callerFields() logrus.Fields {
+	if f, _ := callerFormat.Load().(CallerFormat); f == CallerSplit {
+		frame, ok := q.callerFrame()
+		if !ok {
+			return logrus.Fields{}
+		}
+		return logrus.Fields{
+			callerFileField: packageFile(frame.File, frame.Function),
+			callerLineField: frame.Line,
+			callerFuncField: frame.Function,
+		}
+	}
+	fields := logrus.Fields{"source": ""}
+	if frame, ok := q.callerFrame(); ok {
+		fields["source"] = formatCaller(frame.File, frame.Line, frame.Function)
+		if _, ok := longFileLoggers.Load(q.logger); ok {
+			fields[longFileField] = packageFile(frame.File, frame.Function)
+		}
+	}
+	return fields
+}
+
+// callerFrame returns the first frame outside this package and the runtime,
+// skipping q.callerSkip more frames
+func (q *CommonLogger) callerFrame() (runtime.Frame, bool) {
+	var pcs [maxCallerDepth]uintptr
+	// Skip runtime.Callers and callerFrame
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	skip := q.callerSkip
+	for {
+		f, more := frames.Next()
+		if !inLogsPackage(f.Function) && !strings.HasPrefix(f.Function, "runtime.") {
+			if skip == 0 {
+				return f, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// inLogsPackage reports whether fn, a fully qualified function name, belongs
+// to this package, its subpackages excluded
+func inLogsPackage(fn string) bool {
+	return strings.HasPrefix(fn, logsModule+".")
+}
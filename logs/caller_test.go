@@ -0,0 +1,156 @@
+package logs_test
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestSetCallerFormatter(t *testing.T) {
+	q, buf := newTestLogger()
+	defer logs.SetCallerFormatter(nil)
+	logs.SetCallerFormatter(func(file string, line int, fn string) string {
+		return fmt.Sprintf("%s (%s:%d)", fn[strings.LastIndex(fn, "/")+1:], path.Base(file), line)
+	})
+
+	_, _, line, _ := runtime.Caller(0)
+	q.Info("custom")
+	want := fmt.Sprintf("logs_test.TestSetCallerFormatter (caller_test.go:%d)", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("source = %v, want %s", source, want)
+	}
+
+	logs.SetCallerFormatter(nil)
+	_, _, line, _ = runtime.Caller(0)
+	q.Info("default")
+	want = fmt.Sprintf("caller_test.go:%d:TestSetCallerFormatter()", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("source after reset = %v, want %s", source, want)
+	}
+}
+
+// warnOuter and warnInner are the two levels of helper wrapping the logger
+func warnOuter(q *logs.CommonLogger, msg string) {
+	warnInner(q, msg)
+}
+
+func warnInner(q *logs.CommonLogger, msg string) {
+	q.WithCallerSkip(2).Warn(msg)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	q, buf := newTestLogger()
+	_, _, line, _ := runtime.Caller(0)
+	warnOuter(q, "wrapped")
+	want := fmt.Sprintf("caller_test.go:%d:TestWithCallerSkip()", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("source = %v, want %s", source, want)
+	}
+
+	// The option form
+	skipped, buf := newTestLogger()
+	if err := logs.CallerSkip(1)(skipped); err != nil {
+		t.Fatal(err)
+	}
+	_, _, line, _ = runtime.Caller(0)
+	warnInnerUnskipped(skipped)
+	want = fmt.Sprintf("caller_test.go:%d:TestWithCallerSkip()", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("source with CallerSkip(1) = %v, want %s", source, want)
+	}
+	if err := logs.CallerSkip(-1)(skipped); err == nil {
+		t.Fatal("negative skip accepted")
+	}
+}
+
+func warnInnerUnskipped(q *logs.CommonLogger) {
+	q.Warn("wrapped once")
+}
+
+func TestCallerFormat(t *testing.T) {
+	defer logs.SetCallerFormat(logs.CallerShort)
+	q, buf := newTestLogger()
+
+	if err := logs.SetCallerFormat("FULL"); err != nil {
+		t.Fatal(err)
+	}
+	_, _, line, _ := runtime.Caller(0)
+	q.Info("full")
+	want := fmt.Sprintf("github.com/rohanchauhan02/common/logs_test/caller_test.go:%d", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("full source = %v, want %s", source, want)
+	}
+	_, _, line, _ = runtime.Caller(0)
+	warnOuter(q, "wrapped")
+	want = fmt.Sprintf("github.com/rohanchauhan02/common/logs_test/caller_test.go:%d", line+1)
+	if source := lastEntry(t, buf)["source"]; source != want {
+		t.Fatalf("full source with caller skip = %v, want %s", source, want)
+	}
+
+	if err := logs.SetCallerFormat(logs.CallerSplit); err != nil {
+		t.Fatal(err)
+	}
+	for _, log := range []func() int{
+		func() int {
+			_, _, line, _ := runtime.Caller(0)
+			q.Info("split")
+			return line + 1
+		},
+		func() int {
+			_, _, line, _ := runtime.Caller(0)
+			warnOuter(q, "wrapped")
+			return line + 1
+		},
+	} {
+		line := log()
+		entry := lastEntry(t, buf)
+		if entry["file"] != "github.com/rohanchauhan02/common/logs_test/caller_test.go" || entry["line"] != float64(line) {
+			t.Fatalf("split entry = %v, want line %d", entry, line)
+		}
+		if fn, _ := entry["func"].(string); !strings.HasPrefix(fn, "github.com/rohanchauhan02/common/logs_test.TestCallerFormat.func") {
+			t.Fatalf("func = %v", entry["func"])
+		}
+		if _, ok := entry["source"]; ok {
+			t.Fatalf("source logged with the split format: %v", entry)
+		}
+	}
+
+	if err := logs.SetCallerFormat(""); err != nil {
+		t.Fatal(err)
+	}
+	_, _, line, _ = runtime.Caller(0)
+	q.Info("short")
+	want = fmt.Sprintf("caller_test.go:%d:TestCallerFormat()", line+1)
+	entry := lastEntry(t, buf)
+	if entry["source"] != want || entry["file"] != nil {
+		t.Fatalf("short entry = %v, want source %s", entry, want)
+	}
+
+	if err := logs.SetCallerFormat("long"); err == nil {
+		t.Fatal("unknown caller format accepted")
+	}
+	if _, err := logs.NewCommonLogWithConfig(logs.Config{CallerFormat: "long"}); err == nil {
+		t.Fatal("unknown Config.CallerFormat accepted")
+	}
+}
+
+func BenchmarkCallerSkip(b *testing.B) {
+	q := logs.NewCommonLogWithOutput(io.Discard)
+	b.Run("direct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			q.Warn("direct")
+		}
+	})
+	b.Run("wrapped", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			warnOuter(q, "wrapped")
+		}
+	})
+}
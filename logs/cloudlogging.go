@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Special keys of the Cloud Logging structured payload
+const (
+	cloudLoggingTraceKey   = "logging.googleapis.com/trace"
+	cloudLoggingSpanIDKey  = "logging.googleapis.com/spanId"
+	cloudLoggingSourceKey  = "logging.googleapis.com/sourceLocation"
+	cloudLoggingProjectEnv = "GOOGLE_CLOUD_PROJECT"
+)
+
+// UseCloudLoggingFormatter renders entries as the structured JSON Google Cloud
+// Logging understands: the level mapped to severity, the message under
+// message, and the trace_id and span_id fields moved to the trace correlation
+// keys. The trace is prefixed with projects/<id>/traces/ when
+// GOOGLE_CLOUD_PROJECT is set.
+func UseCloudLoggingFormatter() Option {
+	return func(q *CommonLogger) error {
+		q.setFormatter(&CloudLoggingFormatter{
+			ProjectID: os.Getenv(cloudLoggingProjectEnv),
+		})
+		return nil
+	}
+}
+
+// CloudLoggingFormatter is the logrus formatter set by UseCloudLoggingFormatter
+type CloudLoggingFormatter struct {
+	// ProjectID qualifies the trace ids, left raw when empty
+	ProjectID string
+}
+
+// Format implements logrus.Formatter
+func (f *CloudLoggingFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	payload := make(map[string]interface{}, len(e.Data)+3)
+	for k, v := range e.Data {
+		switch k {
+		case "trace_id":
+			if trace, _ := v.(string); trace != "" {
+				if f.ProjectID != "" {
+					trace = "projects/" + f.ProjectID + "/traces/" + trace
+				}
+				payload[cloudLoggingTraceKey] = trace
+			}
+		case "span_id":
+			payload[cloudLoggingSpanIDKey] = v
+		case "source":
+			payload[cloudLoggingSourceKey] = map[string]interface{}{"file": v}
+		default:
+			if err, ok := v.(error); ok {
+				// Errors marshal to {} otherwise
+				v = err.Error()
+			}
+			payload[k] = v
+		}
+	}
+	payload["severity"] = CloudLoggingSeverity(e.Level)
+	payload["message"] = e.Message
+	payload["time"] = e.Time.Format(time.RFC3339Nano)
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("logs: failed to marshal entry: %w", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// CloudLoggingSeverity returns the Cloud Logging severity of a logrus level
+func CloudLoggingSeverity(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	}
+	return "ALERT"
+}
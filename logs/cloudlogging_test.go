@@ -0,0 +1,86 @@
+package logs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCloudLoggingSeverity(t *testing.T) {
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf)
+	if err := logs.UseCloudLoggingFormatter()(q); err != nil {
+		t.Fatal(err)
+	}
+	q.SetLevel(logs.TRACE)
+
+	for _, tt := range []struct {
+		log      func(i ...interface{})
+		severity string
+	}{
+		{q.Trace, "DEBUG"},
+		{q.Debug, "DEBUG"},
+		{q.Info, "INFO"},
+		{q.Warn, "WARNING"},
+		{q.Error, "ERROR"},
+	} {
+		buf.Reset()
+		tt.log("entry")
+		entry := lastEntry(t, buf)
+		if entry["severity"] != tt.severity || entry["message"] != "entry" {
+			t.Errorf("entry = %v, want severity %s", entry, tt.severity)
+		}
+		if _, ok := entry["msg"]; ok {
+			t.Errorf("message also logged under msg: %v", entry)
+		}
+	}
+
+	for level, want := range map[logrus.Level]string{
+		logrus.FatalLevel: "CRITICAL",
+		logrus.PanicLevel: "ALERT",
+	} {
+		if s := logs.CloudLoggingSeverity(level); s != want {
+			t.Errorf("CloudLoggingSeverity(%s) = %s, want %s", level, s, want)
+		}
+	}
+}
+
+func TestCloudLoggingTrace(t *testing.T) {
+	f := &logs.CloudLoggingFormatter{ProjectID: "acme"}
+	b, err := f.Format(&logrus.Entry{
+		Level:   logrus.InfoLevel,
+		Message: "traced",
+		Time:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Data: logrus.Fields{
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+			"source":   "handler.go:42:Create()",
+			"error":    errors.New("boom"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["logging.googleapis.com/trace"] != "projects/acme/traces/4bf92f3577b34da6a3ce929d0e0e4736" ||
+		entry["logging.googleapis.com/spanId"] != "00f067aa0ba902b7" {
+		t.Fatalf("trace correlation = %v", entry)
+	}
+	if _, ok := entry["trace_id"]; ok {
+		t.Fatalf("trace_id kept: %v", entry)
+	}
+	if entry["error"] != "boom" || entry["time"] != "2024-01-01T00:00:00Z" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if source, _ := entry["logging.googleapis.com/sourceLocation"].(map[string]interface{}); source["file"] != "handler.go:42:Create()" {
+		t.Fatalf("source location = %v", entry["logging.googleapis.com/sourceLocation"])
+	}
+}
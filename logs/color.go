@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// ColorMode selects when the text format is colored
+type ColorMode string
+
+const (
+	// ColorAuto colors the output when it is a terminal, the default
+	ColorAuto ColorMode = "auto"
+	// ColorAlways colors the output even when it is a pipe or a file
+	ColorAlways ColorMode = "always"
+	// ColorNever never colors the output
+	ColorNever ColorMode = "never"
+)
+
+var colorMode atomic.Value
+
+// WithColor sets when the text format is colored, case insensitively, empty
+// meaning ColorAuto. Under ColorAuto, the output is checked again every time
+// it is replaced with SetOutput or AddOutput. The other formats are never
+// colored.
+func WithColor(mode ColorMode) Option {
+	return func(q *CommonLogger) error {
+		m := ColorMode(strings.ToLower(string(mode)))
+		switch m {
+		case "":
+			m = ColorAuto
+		case ColorAuto, ColorAlways, ColorNever:
+		default:
+			return fmt.Errorf("logs: unknown color mode %q", mode)
+		}
+		if q.dryRun {
+			return nil
+		}
+		colorMode.Store(m)
+		if f, ok := q.logger.Formatter.(*prefixed.TextFormatter); ok {
+			q.setFormatter(withColorMode(f))
+		}
+		return nil
+	}
+}
+
+// withColorMode returns a copy of f colored according to the mode set with
+// WithColor. The copy detects a terminal again on its first entry.
+func withColorMode(f *prefixed.TextFormatter) *prefixed.TextFormatter {
+	text := copyTextFormatter(f)
+	mode, _ := colorMode.Load().(ColorMode)
+	switch mode {
+	case ColorAlways:
+		text.ForceColors, text.ForceFormatting, text.DisableColors = true, true, false
+	case ColorNever:
+		text.ForceColors, text.DisableColors = false, true
+	default:
+		text.ForceColors, text.ForceFormatting, text.DisableColors = false, false, false
+	}
+	return text
+}
+
+// redetectColor has the text formatter of the shared logger check its new
+// output, outputMu held
+func (q *CommonLogger) redetectColor() {
+	if f, ok := q.logger.Formatter.(*prefixed.TextFormatter); ok {
+		q.setFormatter(withColorMode(f))
+	}
+}
@@ -0,0 +1,84 @@
+package logs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+const ansiEscape = "\x1b["
+
+// colorLogger returns an independent text logger under mode, the mode being
+// reset to auto when the test ends
+func colorLogger(t *testing.T, mode logs.ColorMode) (*logs.CommonLogger, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf, "api")
+	if err := logs.WithColor(mode)(q); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		logs.WithColor(logs.ColorAuto)(q)
+	})
+	return q, buf
+}
+
+func TestColorAuto(t *testing.T) {
+	for _, mode := range []logs.ColorMode{logs.ColorAuto, "", "AUTO"} {
+		q, buf := colorLogger(t, mode)
+		q.Warn("piped")
+		if strings.Contains(buf.String(), ansiEscape) {
+			t.Fatalf("mode %q colored a buffer: %q", mode, buf.String())
+		}
+		if !strings.Contains(buf.String(), "piped") {
+			t.Fatalf("line %q", buf.String())
+		}
+	}
+}
+
+func TestColorAlways(t *testing.T) {
+	q, buf := colorLogger(t, logs.ColorAlways)
+	q.Warn("forced")
+	if !strings.Contains(buf.String(), ansiEscape) {
+		t.Fatalf("line %q not colored", buf.String())
+	}
+
+	// Still colored once the output is replaced
+	other := &bytes.Buffer{}
+	q.SetOutput(other)
+	q.Warn("forced")
+	if !strings.Contains(other.String(), ansiEscape) {
+		t.Fatalf("line %q not colored after SetOutput", other.String())
+	}
+}
+
+func TestColorNever(t *testing.T) {
+	q, buf := colorLogger(t, logs.ColorNever)
+	q.Warn("plain")
+	if strings.Contains(buf.String(), ansiEscape) {
+		t.Fatalf("line %q colored", buf.String())
+	}
+}
+
+func TestColorJSON(t *testing.T) {
+	q, buf := colorLogger(t, logs.ColorAlways)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+	logs.WithColor(logs.ColorAlways)(q)
+	q.Warn("structured")
+	if strings.Contains(buf.String(), ansiEscape) {
+		t.Fatalf("JSON colored: %q", buf.String())
+	}
+	if lastEntry(t, buf)["msg"] != "structured" {
+		t.Fatal("JSON entry unreadable")
+	}
+}
+
+func TestColorUnknown(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithColor("sometimes")(q); err == nil {
+		t.Fatal("unknown color mode accepted")
+	}
+}
@@ -0,0 +1,279 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// Format selects how log entries are rendered
+type Format string
+
+const (
+	// FormatText is the human readable prefixed text output, the default
+	FormatText Format = "text"
+	// FormatJSON renders one JSON object per line, with the fields as top
+	// level keys
+	FormatJSON Format = "json"
+)
+
+// Config configures the logger built by NewCommonLogWithConfig. The zero
+// value keeps the defaults of NewCommonLog.
+type Config struct {
+	// Prefix is the prefix field of the returned logger
+	Prefix string
+	// Format is FormatText or FormatJSON, case insensitive, so it can be
+	// read straight from the environment. Empty keeps the current format,
+	// FormatText unless set before.
+	Format Format
+	// Color selects when the text format is colored, see WithColor. Empty
+	// keeps the current mode, ColorAuto unless set before.
+	Color ColorMode
+	// ServiceName, Environment and Version are added to every entry with
+	// the host name when one of them is set, see WithServiceMetadata
+	ServiceName string
+	Environment string
+	Version     string
+	// TimestampFormat is the time layout of the entries, RFC3339 for the
+	// text format and RFC3339Nano for JSON by default
+	TimestampFormat string
+	// TimeLocation converts the entry times to that location, UTC when UTC
+	// is set, and the local time is kept when both are left zero
+	TimeLocation *time.Location
+	UTC          bool
+	// Level is the name of the minimum level logged, see WithLevelName. When
+	// empty, LOG_LEVEL is read instead, and the level left as is when
+	// LOG_LEVEL is not set either.
+	Level string
+	// TimestampKey and LevelKey rename the time and level keys of the JSON
+	// output, e.g. "@timestamp" and "severity"
+	TimestampKey string
+	LevelKey     string
+	// SentryDSN initializes Sentry with SentryEnvironment and SentryRelease
+	// when set, see InitSentry
+	SentryDSN         string
+	SentryEnvironment string
+	SentryRelease     string
+	// ExitFlushTimeout bounds the wait for the error reports before Fatal
+	// and Panic terminate the process, see SetExitFlushTimeout
+	ExitFlushTimeout time.Duration
+	// SentryLimits caps the events reported to Sentry, the defaults of
+	// SentryLimits apply when left zero
+	SentryLimits SentryLimits
+	// SentryBreadcrumbs is the name of the minimum level recorded as Sentry
+	// breadcrumbs, info or debug, none when empty, see SetSentryBreadcrumbs
+	SentryBreadcrumbs string
+	// DatadogCorrelation adds the IDs of the active Datadog span, see
+	// WithDatadogCorrelation
+	DatadogCorrelation bool
+	// ContextExtractor adds fields taken from the context of the loggers
+	// bound to one, see SetContextExtractor
+	ContextExtractor ContextExtractor
+	// File writes the entries to a rotating file instead of stderr when its
+	// Path is set, see WithFile
+	File FileOptions
+	// Buffer batches the writes to the output when its Size or
+	// FlushInterval is set, see WithBufferedOutput
+	Buffer BufferOptions
+	// Syslog also sends the entries to a syslog daemon when its Network or
+	// Addr is set, see WithSyslog
+	Syslog SyslogOptions
+	// Fluentd also ships the entries to a Fluentd agent when its Host is
+	// set, see WithFluentd
+	Fluentd FluentdOptions
+	// GELF also ships the entries to Graylog when its Addr is set, see
+	// WithGELF
+	GELF GELFOptions
+	// AuditOutput receives the audit entries instead of the output, see
+	// SetAuditOutput
+	AuditOutput io.Writer
+	// Redaction replaces the global redaction rules when set, see
+	// SetRedaction
+	Redaction *Redaction
+	// CallerFormat selects how the caller is logged, CallerShort when empty,
+	// see SetCallerFormat
+	CallerFormat CallerFormat
+	// GoroutineID adds the goroutine_id field, expensive, see SetGoroutineID
+	GoroutineID bool
+	// ErrorStackTraces adds a stack_trace field to the error, fatal and
+	// panic entries, see SetErrorStackTraces
+	ErrorStackTraces bool
+	// Dedup suppresses the repeated entries when set, see SetDedup
+	Dedup *DedupOptions
+	// Sampling samples the entries up to warn when set, see SetSampling
+	Sampling *Sampling
+	// Metrics counts the entries per level and prefix, see WithMetrics
+	Metrics bool
+	// ReportQueue configures the queue error reports are delivered from
+	ReportQueue ReportQueueOptions
+}
+
+// NewCommonLogWithConfig is like NewCommonLogE, configured from cfg. The
+// format applies to the shared logger, every logger returned by NewCommonLog
+// included.
+func NewCommonLogWithConfig(cfg Config) (*CommonLogger, error) {
+	return NewCommonLogE(cfg.options()...)
+}
+
+// options translates the configuration into the options it stands for
+func (cfg Config) options() []Option {
+	opts := []Option{WithPrefix(cfg.Prefix)}
+	if cfg.Color != "" {
+		opts = append(opts, WithColor(cfg.Color))
+	}
+	if cfg.Format != "" {
+		opts = append(opts, WithFormat(cfg.Format, cfg.TimestampKey, cfg.LevelKey))
+	}
+	if cfg.TimestampFormat != "" {
+		opts = append(opts, WithTimestampFormat(cfg.TimestampFormat))
+	}
+	if cfg.UTC {
+		opts = append(opts, WithTimeLocation(time.UTC))
+	} else if cfg.TimeLocation != nil {
+		opts = append(opts, WithTimeLocation(cfg.TimeLocation))
+	}
+	level := cfg.Level
+	if level == "" {
+		level = os.Getenv(levelEnv)
+	}
+	if level != "" {
+		opts = append(opts, WithLevelName(level))
+	}
+	if cfg.ServiceName != "" || cfg.Environment != "" || cfg.Version != "" {
+		opts = append(opts, WithServiceMetadata(cfg.ServiceName, cfg.Environment, cfg.Version))
+	}
+	if cfg.SentryDSN != "" {
+		opts = append(opts, WithSentry(cfg.SentryDSN, cfg.SentryEnvironment, cfg.SentryRelease))
+	}
+	if cfg.ExitFlushTimeout > 0 {
+		timeout := cfg.ExitFlushTimeout
+		opts = append(opts, func(q *CommonLogger) error {
+			if q.dryRun {
+				return nil
+			}
+			SetExitFlushTimeout(timeout)
+			return nil
+		})
+	}
+	if cfg.SentryLimits != (SentryLimits{}) {
+		opts = append(opts, WithSentryLimits(cfg.SentryLimits))
+	}
+	if cfg.SentryBreadcrumbs != "" {
+		name := cfg.SentryBreadcrumbs
+		opts = append(opts, func(q *CommonLogger) error {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return err
+			}
+			if q.dryRun {
+				return nil
+			}
+			SetSentryBreadcrumbs(level)
+			return nil
+		})
+	}
+	if cfg.File.Path != "" {
+		opts = append(opts, WithFile(cfg.File))
+	}
+	if cfg.Buffer != (BufferOptions{}) {
+		opts = append(opts, WithBufferedOutput(cfg.Buffer))
+	}
+	if cfg.Syslog.Network != "" || cfg.Syslog.Addr != "" {
+		opts = append(opts, WithSyslog(cfg.Syslog))
+	}
+	if cfg.Fluentd.Host != "" {
+		opts = append(opts, WithFluentd(cfg.Fluentd))
+	}
+	if cfg.GELF.Addr != "" {
+		opts = append(opts, WithGELF(cfg.GELF))
+	}
+	if cfg.DatadogCorrelation {
+		opts = append(opts, WithDatadogCorrelation())
+	}
+	if cfg.ContextExtractor != nil {
+		opts = append(opts, WithContextExtractor(cfg.ContextExtractor))
+	}
+	if cfg.AuditOutput != nil {
+		opts = append(opts, WithAuditOutput(cfg.AuditOutput))
+	}
+	if cfg.Redaction != nil {
+		redaction := *cfg.Redaction
+		opts = append(opts, func(q *CommonLogger) error {
+			if q.dryRun {
+				return nil
+			}
+			SetRedaction(redaction)
+			return nil
+		})
+	}
+	if cfg.CallerFormat != "" {
+		opts = append(opts, WithCallerFormat(cfg.CallerFormat))
+	}
+	if cfg.GoroutineID {
+		opts = append(opts, WithGoroutineID())
+	}
+	if cfg.ErrorStackTraces {
+		opts = append(opts, WithErrorStackTraces())
+	}
+	if cfg.Dedup != nil {
+		opts = append(opts, WithDedup(*cfg.Dedup))
+	}
+	if cfg.Sampling != nil {
+		opts = append(opts, WithSampling(*cfg.Sampling))
+	}
+	if cfg.Metrics {
+		opts = append(opts, WithMetrics())
+	}
+	if cfg.ReportQueue != (ReportQueueOptions{}) {
+		opts = append(opts, WithReportQueue(cfg.ReportQueue))
+	}
+	return opts
+}
+
+// WithFormat selects the output format. timestampKey and levelKey rename the
+// corresponding JSON keys when not empty and are ignored by FormatText.
+func WithFormat(format Format, timestampKey, levelKey string) Option {
+	return func(q *CommonLogger) error {
+		formatter, err := newFormatter(format, timestampKey, levelKey)
+		if err != nil {
+			return err
+		}
+		q.setFormatter(formatter)
+		return nil
+	}
+}
+
+// SetFormat is like WithFormat with the default key names
+func (q *CommonLogger) SetFormat(format Format) error {
+	return WithFormat(format, "", "")(q)
+}
+
+func newFormatter(format Format, timestampKey, levelKey string) (logrus.Formatter, error) {
+	switch Format(strings.ToLower(string(format))) {
+	case "", FormatText:
+		if h, _ := header.Load().(string); h != "" {
+			return NewHeaderFormatter(h), nil
+		}
+		return withColorMode(&prefixed.TextFormatter{
+			FullTimestamp: true,
+		}), nil
+	case FormatJSON:
+		fieldMap := logrus.FieldMap{}
+		if timestampKey != "" {
+			fieldMap[logrus.FieldKeyTime] = timestampKey
+		}
+		if levelKey != "" {
+			fieldMap[logrus.FieldKeyLevel] = levelKey
+		}
+		return &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        fieldMap,
+		}, nil
+	}
+	return nil, fmt.Errorf("logs: unknown format %q", format)
+}
@@ -0,0 +1,71 @@
+package logs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestConfigJSON(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	_, buf := captureShared(t)
+
+	q, err := logs.NewCommonLogWithConfig(logs.Config{
+		Prefix:       "payments",
+		Format:       "JSON",
+		TimestampKey: "@timestamp",
+		LevelKey:     "severity",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.WithRequestID("req-1").Info("charged")
+	entry := lastEntry(t, buf)
+	for _, key := range []string{"@timestamp", "severity", "source", "prefix", "requestID"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("key %s missing from %v", key, entry)
+		}
+	}
+	if entry["severity"] != "info" || entry["prefix"] != "payments" || entry["requestID"] != "req-1" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if _, ok := entry["time"]; ok {
+		t.Fatalf("time key not renamed: %v", entry)
+	}
+
+	// Loggers built afterwards, with or without config, write JSON too
+	other, err := logs.NewCommonLogWithConfig(logs.Config{Prefix: "ledger"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Info("second config")
+	if entry := lastEntry(t, buf); entry["prefix"] != "ledger" || entry["severity"] != "info" {
+		t.Fatalf("second config changed the format: %v", entry)
+	}
+	logs.NewCommonLog("plain").Info("no config")
+	if entry := lastEntry(t, buf); entry["prefix"] != "plain" {
+		t.Fatalf("entry = %v", entry)
+	}
+}
+
+func TestConfigText(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	_, buf := captureShared(t)
+
+	q, err := logs.NewCommonLogWithConfig(logs.Config{Prefix: "local", Format: logs.FormatText})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Info("readable")
+	line := buf.String()
+	if strings.HasPrefix(line, "{") || !strings.Contains(line, "readable") || !strings.Contains(line, "local") {
+		t.Fatalf("text output = %q", line)
+	}
+}
+
+func TestConfigInvalidFormat(t *testing.T) {
+	if _, err := logs.NewCommonLogWithConfig(logs.Config{Format: "yaml"}); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
@@ -0,0 +1,56 @@
+package logs
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Fields Datadog correlates logs and traces on
+const (
+	datadogTraceIDField = "dd.trace_id"
+	datadogSpanIDField  = "dd.span_id"
+)
+
+var datadogCorrelation atomic.Bool
+
+// WithDatadogCorrelation adds the dd.trace_id and dd.span_id fields of the
+// active span to the entries of loggers bound to a context with WithContext,
+// the fields being omitted when the context has no span
+func WithDatadogCorrelation() Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		datadogCorrelation.Store(true)
+		return nil
+	}
+}
+
+// WithContext returns a child logger bound to ctx, the request context
+// usually. It is passed on to the hooks and carries the active span for
+// WithDatadogCorrelation.
+func (q *CommonLogger) WithContext(ctx context.Context) *CommonLogger {
+	child := q.clone()
+	child.ctx = ctx
+	return child
+}
+
+// datadogFields returns the correlation fields of the span active in ctx
+func datadogFields(ctx context.Context) logrus.Fields {
+	if ctx == nil || !datadogCorrelation.Load() {
+		return nil
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	sc := span.Context()
+	return logrus.Fields{
+		datadogTraceIDField: strconv.FormatUint(sc.TraceID(), 10),
+		datadogSpanIDField:  strconv.FormatUint(sc.SpanID(), 10),
+	}
+}
@@ -0,0 +1,78 @@
+package logs_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestDatadogCorrelation(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	q, buf := newTestLogger()
+	logs.WithDatadogCorrelation()(q)
+	defer logs.DisableDatadogCorrelation()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "op")
+	defer span.Finish()
+	q.WithContext(ctx).Info("traced")
+	entry := lastEntry(t, buf)
+	if want := strconv.FormatUint(span.Context().TraceID(), 10); entry["dd.trace_id"] != want {
+		t.Errorf("dd.trace_id = %v, want %s", entry["dd.trace_id"], want)
+	}
+	if want := strconv.FormatUint(span.Context().SpanID(), 10); entry["dd.span_id"] != want {
+		t.Errorf("dd.span_id = %v, want %s", entry["dd.span_id"], want)
+	}
+
+	for name, l := range map[string]*logs.CommonLogger{
+		"no context": q,
+		"no span":    q.WithContext(context.Background()),
+	} {
+		l.Info("untraced")
+		entry := lastEntry(t, buf)
+		if _, ok := entry["dd.trace_id"]; ok {
+			t.Errorf("%s: dd.trace_id logged: %v", name, entry)
+		}
+		if _, ok := entry["dd.span_id"]; ok {
+			t.Errorf("%s: dd.span_id logged: %v", name, entry)
+		}
+	}
+}
+
+func TestDatadogCorrelationMiddleware(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	q, buf := newTestLogger()
+	logs.WithDatadogCorrelation()(q)
+	defer logs.DisableDatadogCorrelation()
+
+	var spanID uint64
+	e := echo.New()
+	// Stands for the tracing middleware, starting the request span
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "http.request")
+			defer span.Finish()
+			spanID = span.Context().SpanID()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.GET("/", func(c echo.Context) error {
+		logs.FromEchoContext(c).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if entry := lastEntry(t, buf); entry["dd.span_id"] != strconv.FormatUint(spanID, 10) {
+		t.Fatalf("entry = %v, want the span of the request", entry)
+	}
+}
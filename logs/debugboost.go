@@ -0,0 +1,52 @@
+package logs
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+// headerDebugLog is the default header requesting a debug boost
+const headerDebugLog = "X-Debug-Log"
+
+// debugBoostField marks the entries of the boosted requests
+const debugBoostField = "debug_boost"
+
+// debugBoosts is set once a middleware may boost requests, the logrus level
+// then letting the debug entries through for enabled to filter them
+var debugBoosts atomic.Bool
+
+// enableDebugBoosts lowers the level floor to debug for good
+func enableDebugBoosts() {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if !debugBoosts.Swap(true) {
+		applyLevelFloors()
+	}
+}
+
+// debugBoost returns the middleware step boosting the request scoped logger
+// to debug when the request asks for it and passes opts.DebugAuthorizer, nil
+// when no authorizer is set so nothing can be boosted
+func debugBoost(opts RequestIDOptions) func(c echo.Context, child *CommonLogger) {
+	authorize := opts.DebugAuthorizer
+	if authorize == nil {
+		return nil
+	}
+	header := opts.DebugHeader
+	if header == "" {
+		header = headerDebugLog
+	}
+	enableDebugBoosts()
+
+	return func(c echo.Context, child *CommonLogger) {
+		requested, _ := strconv.ParseBool(c.Request().Header.Get(header))
+		if !requested || !authorize(c) {
+			return
+		}
+		child.boostLevel = logrus.DebugLevel
+		child.fields[debugBoostField] = true
+	}
+}
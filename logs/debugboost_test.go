@@ -0,0 +1,125 @@
+package logs_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// supportOnly authorizes the requests sending the support API key
+func supportOnly(c echo.Context) bool {
+	return c.Request().Header.Get("X-Api-Key") == "support"
+}
+
+// boostServer logs a debug and an info entry per request, tagged with the
+// request ID
+func boostServer(q *logs.CommonLogger, opts logs.RequestIDOptions) *echo.Echo {
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestIDWithOptions(opts))
+	e.GET("/", func(c echo.Context) error {
+		l := logs.FromEchoContext(c)
+		id := c.Response().Header().Get(echo.HeaderXRequestID)
+		l.Debugf("debug %s", id)
+		l.Infof("info %s", id)
+		return c.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func boostRequest(e *echo.Echo, id string, headers map[string]string) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, id)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	e.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDebugBoost(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opts    logs.RequestIDOptions
+		headers map[string]string
+		boosted bool
+	}{
+		{"authorized", logs.RequestIDOptions{DebugAuthorizer: supportOnly}, map[string]string{"X-Debug-Log": "true", "X-Api-Key": "support"}, true},
+		{"unauthorized", logs.RequestIDOptions{DebugAuthorizer: supportOnly}, map[string]string{"X-Debug-Log": "true", "X-Api-Key": "guess"}, false},
+		{"not requested", logs.RequestIDOptions{DebugAuthorizer: supportOnly}, map[string]string{"X-Api-Key": "support"}, false},
+		{"disabled", logs.RequestIDOptions{DebugAuthorizer: supportOnly}, map[string]string{"X-Debug-Log": "false", "X-Api-Key": "support"}, false},
+		{"no authorizer", logs.RequestIDOptions{}, map[string]string{"X-Debug-Log": "true", "X-Api-Key": "support"}, false},
+		{"custom header", logs.RequestIDOptions{DebugAuthorizer: supportOnly, DebugHeader: "X-Trace-Me"}, map[string]string{"X-Trace-Me": "1", "X-Api-Key": "support"}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, buf := newTestLogger()
+			q.SetLevel(gommonLog.INFO)
+			boostRequest(boostServer(q, tc.opts), "req-1", tc.headers)
+
+			entries := decodeEntries(t, buf)
+			want := 1
+			if tc.boosted {
+				want = 2
+			}
+			if len(entries) != want {
+				t.Fatalf("%d entries, want %d: %v", len(entries), want, entries)
+			}
+			for _, entry := range entries {
+				if boost, _ := entry["debug_boost"].(bool); boost != tc.boosted {
+					t.Errorf("entry %v, want debug_boost %v", entry, tc.boosted)
+				}
+			}
+
+			// The level of the logger is left alone
+			buf.Reset()
+			q.Debug("outside")
+			if buf.Len() != 0 {
+				t.Fatalf("debug entry logged outside the request: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestDebugBoostConcurrent(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.INFO)
+	e := boostServer(q, logs.RequestIDOptions{DebugAuthorizer: supportOnly})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		headers := map[string]string{"X-Debug-Log": "true"}
+		if i%2 == 0 {
+			headers["X-Api-Key"] = "support"
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			boostRequest(e, id, headers)
+		}(fmt.Sprintf("req-%d", i))
+	}
+	wg.Wait()
+
+	var debug int
+	for _, entry := range decodeEntries(t, buf) {
+		var i int
+		fmt.Sscanf(entry["requestID"].(string), "req-%d", &i)
+		authorized := i%2 == 0
+		if boost, _ := entry["debug_boost"].(bool); boost != authorized {
+			t.Errorf("entry %v of request %d, want debug_boost %v", entry, i, authorized)
+		}
+		if msg := entry["msg"].(string); strings.HasPrefix(msg, "debug ") {
+			debug++
+			if !authorized {
+				t.Errorf("debug entry logged for the unauthorized request %d", i)
+			}
+		}
+	}
+	if debug != 50 {
+		t.Fatalf("%d debug entries, want one per authorized request", debug)
+	}
+}
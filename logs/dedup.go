@@ -0,0 +1,166 @@
+package logs
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDedupWindow          = 10 * time.Second
+	defaultDedupThreshold       = 5
+	defaultDedupMaxFingerprints = 1000
+)
+
+// DedupOptions configures the dedup filter of SetDedup
+type DedupOptions struct {
+	// Window is how long the occurrences of an entry are counted, from the
+	// first one. Defaults to 10s.
+	Window time.Duration
+	// Threshold is the number of occurrences logged per window, the next
+	// ones being suppressed. Defaults to 5.
+	Threshold int
+	// MaxFingerprints bounds the entries tracked, the least recently seen
+	// ones being forgotten first. Defaults to 1000.
+	MaxFingerprints int
+}
+
+var dedup atomic.Pointer[dedupFilter]
+
+// SetDedup suppresses the entries repeated more than Threshold times within
+// Window, identified by their level, source and message template: the
+// format of the formatting methods and the message of the others. When the
+// window closes, a summary entry with the message and a repeat_count field
+// is logged in place of the suppressed ones, without their other fields.
+// Fatal and Panic entries are never suppressed, and the error entries
+// suppressed are still reported. nil disables the filter, the default.
+func SetDedup(opts *DedupOptions) {
+	if opts == nil {
+		dedup.Store(nil)
+		return
+	}
+	dedup.Store(newDedupFilter(*opts))
+}
+
+// WithDedup is the option form of SetDedup
+func WithDedup(opts DedupOptions) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetDedup(&opts)
+		return nil
+	}
+}
+
+type dedupFilter struct {
+	opts DedupOptions
+	// now and afterFunc are the clock of the filter, replaced by the tests
+	now       func() time.Time
+	afterFunc func(d time.Duration, fn func())
+
+	mu     sync.Mutex
+	seen   map[string]*list.Element
+	recent *list.List
+}
+
+// dedupRecord counts the occurrences of a fingerprint in the current window
+type dedupRecord struct {
+	fingerprint string
+	start       time.Time
+	count       int
+	suppressed  int
+	// summarizing is set while the summary of the window is scheduled
+	summarizing bool
+}
+
+func newDedupFilter(opts DedupOptions) *dedupFilter {
+	if opts.Window <= 0 {
+		opts.Window = defaultDedupWindow
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = defaultDedupThreshold
+	}
+	if opts.MaxFingerprints <= 0 {
+		opts.MaxFingerprints = defaultDedupMaxFingerprints
+	}
+	return &dedupFilter{
+		opts: opts,
+		now:  time.Now,
+		afterFunc: func(d time.Duration, fn func()) {
+			time.AfterFunc(d, fn)
+		},
+		seen:   make(map[string]*list.Element),
+		recent: list.New(),
+	}
+}
+
+// duplicate reports whether the dedup filter suppresses the entry at level
+// with the given message template
+func (q *CommonLogger) duplicate(level logrus.Level, template string) bool {
+	f := dedup.Load()
+	if f == nil || level <= logrus.FatalLevel {
+		return false
+	}
+	source := q.callerSource()
+	return f.suppress(q, level, source, template)
+}
+
+func (f *dedupFilter) suppress(q *CommonLogger, level logrus.Level, source, template string) bool {
+	fingerprint := level.String() + "|" + source + "|" + template
+	now := f.now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	el, ok := f.seen[fingerprint]
+	if !ok {
+		el = f.recent.PushFront(&dedupRecord{fingerprint: fingerprint, start: now})
+		f.seen[fingerprint] = el
+		for f.recent.Len() > f.opts.MaxFingerprints {
+			oldest := f.recent.Back()
+			f.recent.Remove(oldest)
+			delete(f.seen, oldest.Value.(*dedupRecord).fingerprint)
+		}
+	} else {
+		f.recent.MoveToFront(el)
+	}
+
+	r := el.Value.(*dedupRecord)
+	if now.Sub(r.start) >= f.opts.Window && !r.summarizing {
+		r.start, r.count = now, 0
+	}
+	r.count++
+	if r.count <= f.opts.Threshold {
+		return false
+	}
+	r.suppressed++
+	if !r.summarizing {
+		r.summarizing = true
+		f.afterFunc(r.start.Add(f.opts.Window).Sub(now), func() {
+			f.summarize(q, r, level, source, template)
+		})
+	}
+	return true
+}
+
+// summarize logs the summary of the entries suppressed in the window of r
+// and opens the next one
+func (f *dedupFilter) summarize(q *CommonLogger, r *dedupRecord, level logrus.Level, source, template string) {
+	f.mu.Lock()
+	suppressed := r.suppressed
+	r.suppressed, r.count, r.summarizing = 0, 0, false
+	r.start = f.now()
+	f.mu.Unlock()
+
+	fields := logrus.Fields{
+		"source":       source,
+		"repeat_count": suppressed,
+	}
+	if q.prefix != "" {
+		fields["prefix"] = q.prefix
+	}
+	q.logger.WithFields(fields).Logf(level, "%s (repeated %d more times)", template, suppressed)
+}
@@ -0,0 +1,114 @@
+package logs_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// dedupLogger returns a JSON logger writing to a buffer safe for the summary
+// goroutine, with the dedup filter set to opts until the test ends
+func dedupLogger(t *testing.T, opts logs.DedupOptions) (*logs.CommonLogger, *syncBuffer) {
+	t.Helper()
+	logs.SetDedup(&opts)
+	t.Cleanup(func() { logs.SetDedup(nil) })
+	out := &syncBuffer{}
+	q := logs.NewCommonLogWithOutput(out)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+	return q, out
+}
+
+// syncEntries returns the JSON entries written to out
+func syncEntries(t *testing.T, out *syncBuffer) []map[string]interface{} {
+	t.Helper()
+	return decodeEntries(t, bytes.NewBufferString(out.String()))
+}
+
+func TestDedupSuppressesRepeats(t *testing.T) {
+	const threshold = 3
+	now := time.Now()
+	summarize := logs.SetDedupClock(logs.DedupOptions{Window: time.Second, Threshold: threshold}, func() time.Time { return now })
+	t.Cleanup(func() { logs.SetDedup(nil) })
+	out := &syncBuffer{}
+	q := logs.NewCommonLogWithOutput(out)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+
+	for i := 0; i < 10000; i++ {
+		q.WithRequestID(fmt.Sprintf("req-%d", i)).Errorf("query %d failed", i)
+	}
+	if n := len(syncEntries(t, out)); n != threshold {
+		t.Fatalf("%d lines logged before the window closed, want %d", n, threshold)
+	}
+
+	now = now.Add(time.Second)
+	summarize()
+	entries := syncEntries(t, out)
+	if len(entries) != threshold+1 {
+		t.Fatalf("%d lines logged, want %d", len(entries), threshold+1)
+	}
+	summary := entries[threshold]
+	if summary["repeat_count"] != float64(10000-threshold) {
+		t.Fatalf("repeat_count = %v, want %d", summary["repeat_count"], 10000-threshold)
+	}
+	if summary["level"] != "error" || summary["msg"] != fmt.Sprintf("query %%d failed (repeated %d more times)", 10000-threshold) {
+		t.Fatalf("summary = %v", summary)
+	}
+	if _, ok := summary["requestID"]; ok {
+		t.Fatalf("summary keeps the fields of an occurrence: %v", summary)
+	}
+	if summary["source"] != entries[0]["source"] {
+		t.Fatalf("summary source = %v, want %v", summary["source"], entries[0]["source"])
+	}
+
+	// The next window logs the first occurrences again
+	q.Errorf("query %d failed", 0)
+	if n := len(syncEntries(t, out)); n != threshold+2 {
+		t.Fatalf("%d lines logged, want the first occurrence of the next window", n)
+	}
+}
+
+func TestDedupDistinctEntries(t *testing.T) {
+	q, out := dedupLogger(t, logs.DedupOptions{Window: time.Minute, Threshold: 1})
+
+	for i := 0; i < 3; i++ {
+		q.Errorf("query %d failed", i)
+		q.Errorf("dial %d failed", i)
+	}
+	q.Warn("degraded")
+	q.Info("degraded")
+	q.Info("recovered")
+	q.Errorf("query %d failed", 4) // Another source
+
+	if entries := syncEntries(t, out); len(entries) != 6 {
+		t.Fatalf("%d lines logged, want the first of each message: %v", len(entries), entries)
+	}
+}
+
+func TestDedupFatal(t *testing.T) {
+	q, out := dedupLogger(t, logs.DedupOptions{Window: time.Minute, Threshold: 1})
+	q.SetExitFunc(func(int) {})
+
+	for i := 0; i < 5; i++ {
+		q.Fatal("crash")
+	}
+	if entries := syncEntries(t, out); len(entries) != 5 {
+		t.Fatalf("%d fatal lines logged, want all 5", len(entries))
+	}
+}
+
+func TestDedupMaxFingerprints(t *testing.T) {
+	q, out := dedupLogger(t, logs.DedupOptions{Window: time.Minute, Threshold: 1, MaxFingerprints: 1})
+
+	log := func(msg string) { q.Info(msg) }
+	log("a")
+	log("a") // Suppressed
+	log("b") // Forgets a
+	log("a")
+	if entries := syncEntries(t, out); len(entries) != 3 {
+		t.Fatalf("%d lines logged, want a logged again once forgotten", len(entries))
+	}
+}
@@ -0,0 +1,23 @@
+package logs
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// deprecationsLogged holds the features whose deprecation was already logged
+var deprecationsLogged sync.Map
+
+// Deprecatedf logs at level warn that feature is deprecated, with a
+// deprecated field naming it. Each feature is logged at most once per
+// process, later calls are dropped.
+func (q *CommonLogger) Deprecatedf(feature string, format string, args ...interface{}) {
+	if !q.enabled(logrus.WarnLevel) {
+		return
+	}
+	if _, logged := deprecationsLogged.LoadOrStore(feature, struct{}{}); logged {
+		return
+	}
+	q.decorateLog().WithField("deprecated", feature).Warnf(format, args...)
+}
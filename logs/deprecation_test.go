@@ -0,0 +1,44 @@
+package logs_test
+
+import (
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestDeprecatedf(t *testing.T) {
+	logs.ResetDeprecations()
+	q, buf := newTestLogger()
+	q.Deprecatedf("test.v1-client", "%s is deprecated, use the v2 client", "v1 client")
+	entry := lastEntry(t, buf)
+	if entry["level"] != "warning" || entry["deprecated"] != "test.v1-client" || entry["msg"] != "v1 client is deprecated, use the v2 client" {
+		t.Fatalf("entry = %v", entry)
+	}
+
+	for i := 0; i < 10; i++ {
+		q.Deprecatedf("test.v1-client", "again")
+	}
+	other, otherBuf := newTestLogger()
+	other.Deprecatedf("test.v1-client", "from another logger")
+	if n := len(decodeEntries(t, buf)); n != 1 || otherBuf.Len() != 0 {
+		t.Fatalf("repeats logged: %d entries, %q", n, otherBuf.String())
+	}
+
+	q.Deprecatedf("test.legacy-flag", "legacy flag")
+	if entry := lastEntry(t, buf); entry["deprecated"] != "test.legacy-flag" {
+		t.Fatalf("another feature suppressed, last entry %v", entry)
+	}
+}
+
+func TestDeprecatedfBelowLevel(t *testing.T) {
+	logs.ResetDeprecations()
+	q, buf := newTestLogger()
+	q.SetLevel(gommonLog.ERROR)
+	q.Deprecatedf("test.quiet", "quiet")
+	q.SetLevel(gommonLog.INFO)
+	q.Deprecatedf("test.quiet", "logged once enabled")
+	if entry := lastEntry(t, buf); entry["msg"] != "logged once enabled" {
+		t.Fatalf("entry = %v", entry)
+	}
+}
@@ -0,0 +1,294 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorEvent is an error level entry delivered to the error destinations
+type ErrorEvent struct {
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	Prefix    string            `json:"prefix,omitempty"`
+	RequestID string            `json:"requestID,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	// Fields are the fields attached to the logger
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	// Err is the error logged, the first error argument or the one given to
+	// WithError, and Error its message
+	Err   error     `json:"-"`
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+
+	// sentryEvent is the exception event built with the stack trace of the
+	// logging goroutine
+	sentryEvent *sentry.Event
+	// hub is the Sentry hub of the request the event was logged in
+	hub *sentry.Hub
+}
+
+// ErrorDestination receives every Error, Fatal and Panic entry in addition to
+// the log output
+type ErrorDestination interface {
+	Send(event ErrorEvent) error
+}
+
+// ErrorDestinationFunc adapts a function to an ErrorDestination
+type ErrorDestinationFunc func(event ErrorEvent) error
+
+// Send calls f(event)
+func (f ErrorDestinationFunc) Send(event ErrorEvent) error {
+	return f(event)
+}
+
+type namedDestination struct {
+	name        string
+	destination ErrorDestination
+}
+
+// sentryDestination names the built-in Sentry destination
+const sentryDestination = "sentry"
+
+var (
+	destinationsMu sync.RWMutex
+	destinations   = []namedDestination{
+		{name: sentryDestination, destination: ErrorDestinationFunc(sendToSentry)},
+	}
+)
+
+// AddErrorDestination registers an additional destination for error entries,
+// Sentry being always the first one. A destination failing or panicking never
+// prevents the others from receiving the event.
+func AddErrorDestination(name string, destination ErrorDestination) {
+	destinationsMu.Lock()
+	defer destinationsMu.Unlock()
+	destinations = append(destinations, namedDestination{name: name, destination: destination})
+}
+
+// NewWebhookDestination returns a destination posting every event as JSON to url
+func NewWebhookDestination(url string, timeout time.Duration) ErrorDestination {
+	client := &http.Client{Timeout: timeout}
+	return ErrorDestinationFunc(func(event ErrorEvent) error {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("webhook responded %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// report fans an error entry out to every destination and logs the
+// destinations that failed at level debug
+func (q *CommonLogger) report(level logrus.Level, message string, err error, tags map[string]string) {
+	if q.noReport || !q.reported() {
+		return
+	}
+	if err == nil {
+		err, _ = q.fields[logrus.ErrorKey].(error)
+	}
+	rd := q.redactor()
+	event := ErrorEvent{
+		Level:     level.String(),
+		Message:   truncate(rd.scrub(message)),
+		Prefix:    q.prefix,
+		RequestID: q.requestID,
+		Tags:      tags,
+		Source:    q.callerSource(),
+		Err:       err,
+		Time:      time.Now(),
+	}
+	if err != nil {
+		event.Error = rd.scrub(err.Error())
+	}
+	if len(q.fields) > 0 {
+		event.Fields = make(map[string]interface{}, len(q.fields))
+		for k, v := range q.fields {
+			if k == logrus.ErrorKey {
+				continue
+			}
+			event.Fields[k] = v
+		}
+		event.Fields = rd.redactFields(event.Fields)
+	}
+
+	if !q.noSentry {
+		event.hub = q.sentryHub()
+	}
+	if err != nil && !q.noSentry {
+		// The stack trace is taken here, the delivery happening on another
+		// goroutine
+		if client := event.hub.Client(); client != nil {
+			event.sentryEvent = client.EventFromException(err, sentryLevel(event.Level))
+			trimLogsFrames(event.sentryEvent)
+			for i := range event.sentryEvent.Exception {
+				ex := &event.sentryEvent.Exception[i]
+				ex.Value = rd.scrub(ex.Value)
+			}
+			if root := rootCause(err); root != err {
+				// Group on the root cause, the wrapping messages varying
+				// with the path the error took
+				event.sentryEvent.Fingerprint = []string{fmt.Sprintf("%T", root), rd.scrub(root.Error())}
+			}
+		}
+	}
+
+	// Fatal and Panic entries terminate the process, deliver them before
+	if level <= logrus.FatalLevel {
+		q.deliver(event)
+		return
+	}
+	reports.push(reportJob{logger: q, event: event})
+}
+
+// reported reports whether a destination receives the error entries of q:
+// one added with AddErrorDestination, or Sentry once initialized. The event
+// isn't built otherwise.
+func (q *CommonLogger) reported() bool {
+	destinationsMu.RLock()
+	targets := destinations
+	destinationsMu.RUnlock()
+
+	for _, target := range targets {
+		if target.name != sentryDestination {
+			return true
+		}
+	}
+	return !q.noSentry && q.sentryHub().Client() != nil
+}
+
+// deliver sends event to every destination
+func (q *CommonLogger) deliver(event ErrorEvent) {
+	destinationsMu.RLock()
+	targets := destinations
+	destinationsMu.RUnlock()
+
+	var errs []error
+	for _, target := range targets {
+		if q.noSentry && target.name == sentryDestination {
+			continue
+		}
+		if err := send(target.destination, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target.name, err))
+		}
+	}
+	if len(errs) > 0 && q.enabled(logrus.DebugLevel) {
+		q.logger.WithError(errors.Join(errs...)).Debugf("Failed to deliver error to %d of %d destinations", len(errs), len(targets))
+	}
+}
+
+// findError returns the first error among the arguments of a logging call
+func findError(args []interface{}) error {
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func send(destination ErrorDestination, event ErrorEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return destination.Send(event)
+}
+
+// sendToSentry captures errors as exceptions, carrying their stack trace or
+// the one of the current goroutine, and the other events as messages. The
+// request scoped values go to tags and extras, which Sentry does not group
+// on, so the same code path always lands in the same issue.
+func sendToSentry(event ErrorEvent) error {
+	hub := event.hub
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	// Not initialized, see InitSentry
+	if hub.Client() == nil {
+		return nil
+	}
+	if !sentryLimiter.allow(event) {
+		sentryDropped.Add(1)
+		return nil
+	}
+	var id *sentry.EventID
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentryLevel(event.Level))
+		scope.SetTags(event.Tags)
+		if event.Prefix != "" {
+			scope.SetTag("prefix", event.Prefix)
+		}
+		if event.Source != "" {
+			scope.SetTag("source", event.Source)
+		}
+		if event.RequestID != "" {
+			scope.SetExtra("requestID", event.RequestID)
+		}
+		for k, v := range event.Fields {
+			scope.SetExtra(k, v)
+		}
+		switch {
+		case event.sentryEvent != nil:
+			scope.SetExtra("message", event.Message)
+			id = hub.CaptureEvent(event.sentryEvent)
+		case event.Err != nil:
+			scope.SetExtra("message", event.Message)
+			id = hub.CaptureException(event.Err)
+		default:
+			id = hub.CaptureMessage(event.Message)
+		}
+	})
+	if id == nil {
+		sentryDropped.Add(1)
+		return errors.New("event dropped")
+	}
+	sentrySent.Add(1)
+	return nil
+}
+
+func sentryLevel(level string) sentry.Level {
+	if level == logrus.PanicLevel.String() {
+		return sentry.LevelFatal
+	}
+	return sentry.Level(level)
+}
+
+// logsModule is the module of the frames of this package
+const logsModule = "github.com/rohanchauhan02/common/logs"
+
+// trimLogsFrames drops the innermost frames of the logs package from the
+// stack trace taken by EventFromException
+func trimLogsFrames(event *sentry.Event) {
+	if len(event.Exception) == 0 {
+		return
+	}
+	st := event.Exception[len(event.Exception)-1].Stacktrace
+	if st == nil {
+		return
+	}
+	n := len(st.Frames)
+	for n > 0 && st.Frames[n-1].Module == logsModule {
+		n--
+	}
+	if n > 0 {
+		st.Frames = st.Frames[:n]
+	}
+}
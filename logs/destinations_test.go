@@ -0,0 +1,122 @@
+package logs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// eventRecorder is an error destination keeping the events it receives
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []logs.ErrorEvent
+}
+
+func (r *eventRecorder) Send(event logs.ErrorEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *eventRecorder) Events() []logs.ErrorEvent {
+	logs.Flush(time.Second)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]logs.ErrorEvent(nil), r.events...)
+}
+
+func TestErrorDestinationsIsolated(t *testing.T) {
+	defer logs.SaveErrorDestinations()()
+	sentry := recordSentry(t)
+
+	rec := &eventRecorder{}
+	logs.AddErrorDestination("failing", logs.ErrorDestinationFunc(func(logs.ErrorEvent) error {
+		return errors.New("unreachable")
+	}))
+	logs.AddErrorDestination("panicking", logs.ErrorDestinationFunc(func(logs.ErrorEvent) error {
+		panic("bug")
+	}))
+	logs.AddErrorDestination("recorder", rec)
+
+	q, _ := newTestLogger("billing")
+	q.WithRequestID("req-1").Errorf("charge failed: %v", errors.New("card declined"))
+
+	events := rec.Events()
+	if len(events) != 1 {
+		t.Fatalf("recorder got %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Level != "error" || e.Message != "charge failed: card declined" || e.Prefix != "billing" || e.RequestID != "req-1" {
+		t.Fatalf("event = %+v", e)
+	}
+	if e.Error != "card declined" {
+		t.Fatalf("event error = %q", e.Error)
+	}
+	if n := len(sentry.Events()); n != 1 {
+		t.Fatalf("Sentry got %d events, want 1", n)
+	}
+}
+
+func TestWebhookDestination(t *testing.T) {
+	received := make(chan logs.ErrorEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e logs.ErrorEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- e
+	}))
+	defer srv.Close()
+
+	dest := logs.NewWebhookDestination(srv.URL, time.Second)
+	if err := dest.Send(logs.ErrorEvent{Level: "error", Message: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-received; e.Message != "boom" {
+		t.Fatalf("webhook got %+v", e)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	if err := logs.NewWebhookDestination(failing.URL, time.Second).Send(logs.ErrorEvent{}); err == nil {
+		t.Fatal("no error for a 503")
+	}
+}
+
+func TestReportWithoutDestination(t *testing.T) {
+	defer logs.SaveErrorDestinations()()
+	hub := sentry.CurrentHub()
+	client := hub.Client()
+	hub.BindClient(nil)
+	defer hub.BindClient(client)
+	q, _ := newTestLogger()
+
+	if q.Reported() {
+		t.Fatal("errors reported with Sentry not initialized and no other destination")
+	}
+
+	rec := recordSentry(t)
+	if !q.Reported() || q.WithoutSentry().Reported() {
+		t.Fatal("want the errors reported to Sentry once initialized, unless opted out")
+	}
+	q.Error(errors.New("boom"))
+	if n := len(rec.Events()); n != 1 {
+		t.Fatalf("%d events, want 1", n)
+	}
+
+	logs.AddErrorDestination("recorder", &eventRecorder{})
+	if !q.WithoutSentry().Reported() {
+		t.Fatal("errors not reported to the added destination")
+	}
+}
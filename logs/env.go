@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// The environment variables read by ConfigFromEnv, next to levelEnv
+const (
+	appEnvEnv    = "APP_ENV"
+	formatEnv    = "LOG_FORMAT"
+	sentryDSNEnv = "SENTRY_DSN"
+)
+
+// developmentEnvs are the APP_ENV values selecting the development profile
+var developmentEnvs = map[string]struct{}{
+	"development": {},
+	"dev":         {},
+	"local":       {},
+}
+
+// ConfigFromEnv returns the configuration of the profile selected by APP_ENV.
+// The development profile, for development, dev or local, logs colored text
+// from level debug without Sentry. Any other value, an empty one included,
+// selects the production profile: JSON with UTC timestamps from level info,
+// reported to Sentry when SENTRY_DSN is set, with APP_ENV as environment.
+// LOG_LEVEL and LOG_FORMAT override the level and format of both profiles.
+func ConfigFromEnv() Config {
+	appEnv := strings.TrimSpace(os.Getenv(appEnvEnv))
+
+	var cfg Config
+	if _, ok := developmentEnvs[strings.ToLower(appEnv)]; ok {
+		cfg = Config{
+			Format: FormatText,
+			Level:  "debug",
+		}
+	} else {
+		cfg = Config{
+			Format:            FormatJSON,
+			UTC:               true,
+			Level:             "info",
+			Environment:       appEnv,
+			SentryDSN:         os.Getenv(sentryDSNEnv),
+			SentryEnvironment: appEnv,
+		}
+	}
+	if level := os.Getenv(levelEnv); level != "" {
+		cfg.Level = level
+	}
+	if format := os.Getenv(formatEnv); format != "" {
+		cfg.Format = Format(format)
+	}
+	return cfg
+}
+
+// NewFromEnv is a drop-in replacement of NewCommonLog configured with
+// ConfigFromEnv. Invalid settings, an unknown LOG_FORMAT or Sentry failing
+// to initialize, are skipped with a warning rather than failing, the other
+// ones being applied.
+func NewFromEnv(prefix ...string) *CommonLogger {
+	cfg := ConfigFromEnv()
+	if len(prefix) > 0 {
+		cfg.Prefix = prefix[0]
+	}
+
+	q := NewCommonLog()
+	var errs []error
+	for _, opt := range cfg.options() {
+		if err := opt(q); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		q.WithError(errors.Join(errs...)).Warnf("Skipped %d invalid logging settings", len(errs))
+	}
+	return q
+}
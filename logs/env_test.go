@@ -0,0 +1,100 @@
+package logs_test
+
+import (
+	"testing"
+	"time"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// setEnv sets the variables read by ConfigFromEnv, unset when empty
+func setEnv(t *testing.T, appEnv, level, format, dsn string) {
+	t.Setenv("APP_ENV", appEnv)
+	t.Setenv("LOG_LEVEL", level)
+	t.Setenv("LOG_FORMAT", format)
+	t.Setenv("SENTRY_DSN", dsn)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	const dsn = "https://key@sentry.example.com/1"
+	for _, tt := range []struct {
+		name                     string
+		appEnv, level, format    string
+		dsn                      string
+		wantFormat               logs.Format
+		wantLevel, wantSentryDSN string
+		wantUTC                  bool
+	}{
+		{"production", "production", "", "", dsn, logs.FormatJSON, "info", dsn, true},
+		{"production without DSN", "production", "", "", "", logs.FormatJSON, "info", "", true},
+		{"development", "development", "", "", dsn, logs.FormatText, "debug", "", false},
+		{"local", "Local", "", "", "", logs.FormatText, "debug", "", false},
+		{"unknown is production", "qa-7", "", "", "", logs.FormatJSON, "info", "", true},
+		{"unset is production", "", "", "", "", logs.FormatJSON, "info", "", true},
+		{"level override", "production", "warn", "", "", logs.FormatJSON, "warn", "", true},
+		{"format override", "dev", "", "json", "", logs.FormatJSON, "debug", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.appEnv, tt.level, tt.format, tt.dsn)
+			cfg := logs.ConfigFromEnv()
+			if cfg.Format != tt.wantFormat || cfg.Level != tt.wantLevel || cfg.UTC != tt.wantUTC {
+				t.Fatalf("format %q level %q UTC %v", cfg.Format, cfg.Level, cfg.UTC)
+			}
+			if cfg.SentryDSN != tt.wantSentryDSN {
+				t.Fatalf("Sentry DSN %q, want %q", cfg.SentryDSN, tt.wantSentryDSN)
+			}
+		})
+	}
+}
+
+func TestNewFromEnvDevelopment(t *testing.T) {
+	q, _ := captureShared(t)
+	setEnv(t, "development", "", "", "")
+
+	logs.NewFromEnv("api")
+	if _, ok := q.Formatter().(*prefixed.TextFormatter); !ok {
+		t.Fatalf("formatter %T, want the prefixed text one", q.Formatter())
+	}
+	if q.Level() != gommonLog.DEBUG {
+		t.Fatalf("level %v, want DEBUG", q.Level())
+	}
+}
+
+func TestNewFromEnvProduction(t *testing.T) {
+	q, buf := captureShared(t)
+	defer logs.SetGlobalFields(nil)
+	defer logs.WithTimeLocation(time.Local)(q)
+	setEnv(t, "staging", "error", "", "")
+
+	l := logs.NewFromEnv("api")
+	if _, ok := q.Formatter().(*logrus.JSONFormatter); !ok {
+		t.Fatalf("formatter %T, want JSON", q.Formatter())
+	}
+	if q.Level() != gommonLog.ERROR {
+		t.Fatalf("level %v, want ERROR from LOG_LEVEL", q.Level())
+	}
+	l.Error("failed")
+	if e := lastEntry(t, buf); e["env"] != "staging" || e["prefix"] != "api" {
+		t.Fatalf("entry = %v, want the environment and prefix", e)
+	}
+}
+
+func TestNewFromEnvInvalidFormat(t *testing.T) {
+	q, buf := captureShared(t)
+	defer logs.SetGlobalFields(nil)
+	defer logs.WithTimeLocation(time.Local)(q)
+	setEnv(t, "production", "", "yaml", "")
+
+	if logs.NewFromEnv() == nil {
+		t.Fatal("NewFromEnv returned nil")
+	}
+	if e := lastEntry(t, buf); e["level"] != "warning" || e["msg"] != "Skipped 1 invalid logging settings" {
+		t.Fatalf("entry = %v, want the skipped setting warning", e)
+	}
+	if q.Level() != gommonLog.INFO {
+		t.Fatalf("level %v, want the other settings applied", q.Level())
+	}
+}
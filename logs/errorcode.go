@@ -0,0 +1,34 @@
+package logs
+
+import "github.com/sirupsen/logrus"
+
+// errorCodeField is the field and Sentry tag carrying the API error code
+const errorCodeField = "error_code"
+
+// ErrorCode logs at level error with the given API error code as a dedicated
+// error_code field, and reports it to Sentry tagged with the same code. The
+// entry is sampled, deduplicated and reported as Error does.
+func (q *CommonLogger) ErrorCode(code string, i ...interface{}) {
+	q.logError(code, i)
+}
+
+// ErrorCodef is the format variant of ErrorCode
+func (q *CommonLogger) ErrorCodef(code string, format string, args ...interface{}) {
+	q.logErrorf(code, format, args)
+}
+
+// withErrorCode adds the error_code field to e, unless code is empty
+func withErrorCode(e *logrus.Entry, code string) *logrus.Entry {
+	if code == "" {
+		return e
+	}
+	return e.WithField(errorCodeField, code)
+}
+
+// errorCodeTags returns the Sentry tags of code, nil when empty
+func errorCodeTags(code string) map[string]string {
+	if code == "" {
+		return nil
+	}
+	return map[string]string{errorCodeField: code}
+}
@@ -0,0 +1,76 @@
+package logs_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestErrorCode(t *testing.T) {
+	rec := recordSentry(t)
+	q, buf := newTestLogger()
+
+	q.ErrorCodef("PAY-402", "payment of order %d declined", 42)
+
+	if code := lastEntry(t, buf)["error_code"]; code != "PAY-402" {
+		t.Fatalf("error_code = %v, want PAY-402", code)
+	}
+	events := rec.Events()
+	if len(events) != 1 {
+		t.Fatalf("%d events, want 1", len(events))
+	}
+	if tag := events[0].Tags["error_code"]; tag != "PAY-402" {
+		t.Fatalf("Sentry tag error_code = %q, want PAY-402", tag)
+	}
+}
+
+func TestErrorCodeWithError(t *testing.T) {
+	rec := recordSentry(t)
+	q, buf := newTestLogger()
+
+	q.ErrorCode("DB-500", "query failed: ", errors.New("connection reset"))
+
+	e := lastEntry(t, buf)
+	if e["error_code"] != "DB-500" || e["msg"] != "query failed: connection reset" {
+		t.Fatalf("entry = %v", e)
+	}
+	events := rec.Events()
+	if len(events) != 1 || events[0].Tags["error_code"] != "DB-500" {
+		t.Fatalf("events = %+v, want one tagged DB-500", events)
+	}
+	if len(events[0].Exception) == 0 {
+		t.Fatal("the error was not reported as an exception")
+	}
+}
+
+func TestErrorCodeLikeError(t *testing.T) {
+	rec := recordSentry(t)
+	q, buf := newTestLogger()
+
+	err := errors.New("connection reset")
+	q.Error(err)
+	logs.ResetSentryLimits()
+	q.ErrorCode("DB-500", err)
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 2 || entries[0]["msg"] != entries[1]["msg"] {
+		t.Fatalf("entries = %v, want the same message", entries)
+	}
+	if messages := rec.Messages(); len(messages) != 2 || messages[0] != messages[1] {
+		t.Fatalf("Sentry messages = %q, want the same message", messages)
+	}
+}
+
+func TestErrorCodeDedup(t *testing.T) {
+	q, out := dedupLogger(t, logs.DedupOptions{Window: time.Minute, Threshold: 1})
+
+	for i := 0; i < 3; i++ {
+		q.ErrorCode("DB-500", "query failed")
+		q.ErrorCodef("PAY-402", "payment of order %d declined", i)
+	}
+	if entries := syncEntries(t, out); len(entries) != 2 {
+		t.Fatalf("%d lines logged, want the repeats suppressed: %v", len(entries), entries)
+	}
+}
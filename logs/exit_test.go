@@ -0,0 +1,137 @@
+package logs_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// bufferedTransport is a Sentry transport buffering the events like the HTTP
+// one does, sending them once flushed for delay
+type bufferedTransport struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	pending int
+	sent    int
+}
+
+func (b *bufferedTransport) Configure(sentry.ClientOptions) {}
+
+func (b *bufferedTransport) SendEvent(*sentry.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending++
+}
+
+func (b *bufferedTransport) Flush(timeout time.Duration) bool {
+	if timeout < b.delay {
+		time.Sleep(timeout)
+		return false
+	}
+	time.Sleep(b.delay)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent += b.pending
+	b.pending = 0
+	return true
+}
+
+func (b *bufferedTransport) Sent() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent
+}
+
+// useBufferedTransport reports to Sentry through a bufferedTransport until
+// the test ends
+func useBufferedTransport(t *testing.T, delay time.Duration) *bufferedTransport {
+	t.Helper()
+	transport := &bufferedTransport{delay: delay}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.CurrentHub()
+	hub.PushScope()
+	hub.BindClient(client)
+	logs.ResetSentryLimits()
+	t.Cleanup(func() {
+		logs.Flush(10 * time.Second)
+		hub.PopScope()
+		logs.ResetSentryLimits()
+	})
+	return transport
+}
+
+func TestFatalFlushesBeforeExit(t *testing.T) {
+	transport := useBufferedTransport(t, 20*time.Millisecond)
+	q, buf := newTestLogger()
+	sentAtExit, code := -1, 0
+	q.SetExitFunc(func(c int) {
+		sentAtExit, code = transport.Sent(), c
+	})
+
+	for name, fatal := range map[string]func(){
+		"Fatal":  func() { q.Fatal(errors.New("crash")) },
+		"Fatalf": func() { q.Fatalf("crash: %v", errors.New("disk full")) },
+		"Fatalj": func() { q.Fatalj(gommonLog.JSON{"msg": "crash"}) },
+		"Fatalw": func() { q.Fatalw("crash", "error", errors.New("disk full")) },
+	} {
+		before := transport.Sent()
+		sentAtExit = -1
+		fatal()
+		if sentAtExit != before+1 {
+			t.Errorf("%s: %d events sent at exit, want %d", name, sentAtExit, before+1)
+		}
+		if code != 1 {
+			t.Errorf("%s: exit code %d", name, code)
+		}
+		if e := lastEntry(t, buf); e["level"] != "fatal" {
+			t.Errorf("%s: entry = %v, want it logged before exit", name, e)
+		}
+	}
+}
+
+func TestPanicFlushesAndRepanics(t *testing.T) {
+	transport := useBufferedTransport(t, 20*time.Millisecond)
+	q, _ := newTestLogger()
+
+	defer func() {
+		r := recover()
+		entry, ok := r.(*logrus.Entry)
+		if !ok || entry.Message != "corrupted state" {
+			t.Fatalf("recovered %v, want the original panic", r)
+		}
+		if transport.Sent() != 1 {
+			t.Fatalf("%d events sent when the panic propagated, want 1", transport.Sent())
+		}
+	}()
+	q.Panic("corrupted state")
+}
+
+func TestExitFlushTimeout(t *testing.T) {
+	transport := useBufferedTransport(t, 500*time.Millisecond)
+	logs.SetExitFlushTimeout(50 * time.Millisecond)
+	defer logs.SetExitFlushTimeout(0)
+	q, _ := newTestLogger()
+	var exitedAfter time.Duration
+	start := time.Now()
+	q.SetExitFunc(func(int) {
+		exitedAfter = time.Since(start)
+	})
+
+	q.Fatal("crash")
+	if exitedAfter > 300*time.Millisecond {
+		t.Fatalf("exited after %v, want the flush bounded by 50ms", exitedAfter)
+	}
+	if transport.Sent() != 0 {
+		t.Fatal("event flushed past the timeout")
+	}
+}
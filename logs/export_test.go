@@ -0,0 +1,144 @@
+package logs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Internals exposed to the tests of package logs_test, which sit outside this
+// package so the caller detection reports their own frames
+
+var (
+	Truncate         = truncate
+	TruncatedMarker  = truncatedMarker
+	ParseTraceparent = parseTraceparent
+)
+
+// Formatter returns the formatter of the logrus logger of q
+func (q *CommonLogger) Formatter() logrus.Formatter {
+	return q.logger.Formatter
+}
+
+// ResetSentryLimits resets the limits of the Sentry reporting, forgets the
+// fingerprints seen so far and cancels the pending summary
+func ResetSentryLimits() {
+	sentryLimiter.mu.Lock()
+	sentryLimiter.fingerprints = make(map[string]*fingerprintState)
+	if sentryLimiter.summary != nil {
+		sentryLimiter.summary.Stop()
+		sentryLimiter.summary = nil
+	}
+	sentryLimiter.mu.Unlock()
+	SetSentryLimits(SentryLimits{})
+}
+
+// SaveErrorDestinations returns a function restoring the error destinations
+// registered at the time of the call
+func SaveErrorDestinations() func() {
+	destinationsMu.RLock()
+	saved := append([]namedDestination(nil), destinations...)
+	destinationsMu.RUnlock()
+	return func() {
+		destinationsMu.Lock()
+		destinations = saved
+		destinationsMu.Unlock()
+	}
+}
+
+// Reported reports whether the error entries of q are reported, see report
+func (q *CommonLogger) Reported() bool {
+	return q.reported()
+}
+
+// ResetDeprecations forgets the deprecations logged so far
+func ResetDeprecations() {
+	deprecationsLogged.Range(func(key, _ interface{}) bool {
+		deprecationsLogged.Delete(key)
+		return true
+	})
+}
+
+var (
+	ToLogrusLevel = toLogrusLevel
+	ToEchoLevel   = toEchoLevel
+)
+
+// DisableDatadogCorrelation turns WithDatadogCorrelation off again
+func DisableDatadogCorrelation() {
+	datadogCorrelation.Store(false)
+}
+
+// BackupName returns the name of the next backup of w rotated at t
+func (w *RotatingWriter) BackupName(t time.Time) string {
+	return w.backupName(t)
+}
+
+// ResetBadKeyWarning lets the next malformed *w call warn again
+func ResetBadKeyWarning() {
+	badKeyWarning = sync.Once{}
+}
+
+// SetExitFunc replaces os.Exit for the Fatal methods of q
+func (q *CommonLogger) SetExitFunc(exit func(int)) {
+	q.logger.ExitFunc = exit
+}
+
+// NewMetricsHook returns the hook registered by WithMetrics, to measure it
+// alone
+func NewMetricsHook() logrus.Hook {
+	return &metricsHook{}
+}
+
+// RegisterSink registers the fake sinks of the tests, see registerSink
+var RegisterSink = registerSink
+
+// ResetClose lets Close run again, with no sink registered, and returns a
+// function restoring the sinks registered at the time of the call
+func ResetClose() func() {
+	sinksMu.Lock()
+	saved := sinks
+	sinks = nil
+	sinksMu.Unlock()
+	closeOnce, closeErr = sync.Once{}, nil
+	return func() {
+		sinksMu.Lock()
+		sinks = saved
+		sinksMu.Unlock()
+		closeOnce, closeErr = sync.Once{}, nil
+	}
+}
+
+// SampledAwayAt reports whether the sampler set with SetSampling drops an
+// entry logged at t
+func SampledAwayAt(prefix string, level logrus.Level, template string, t time.Time) bool {
+	return sampler.Load().sampledAwayAt(prefix, level, template, t.UnixNano())
+}
+
+// SetDedupClock sets the dedup filter to opts with now as its clock, the
+// summaries scheduled being logged by the returned function instead of once
+// their window closes
+func SetDedupClock(opts DedupOptions, now func() time.Time) (summarize func()) {
+	var (
+		mu      sync.Mutex
+		pending []func()
+	)
+	f := newDedupFilter(opts)
+	f.now = now
+	f.afterFunc = func(_ time.Duration, fn func()) {
+		mu.Lock()
+		pending = append(pending, fn)
+		mu.Unlock()
+	}
+	dedup.Store(f)
+	return func() {
+		mu.Lock()
+		fns := pending
+		pending = nil
+		mu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ContextExtractor returns the fields to add to the entries of a logger bound
+// to ctx, the IDs of the active span typically. It is called on every entry
+// and must return nil when ctx carries nothing of interest.
+type ContextExtractor func(ctx context.Context) map[string]interface{}
+
+var contextExtractor atomic.Pointer[ContextExtractor]
+
+// SetContextExtractor sets the extractor called with the context of the
+// loggers bound to one with WithContext, or by MiddlewareLoggerRequestID to
+// the request context. Its fields take precedence over the trace_id and
+// span_id parsed from the traceparent header. nil removes it.
+func SetContextExtractor(extract ContextExtractor) {
+	if extract == nil {
+		contextExtractor.Store(nil)
+		return
+	}
+	contextExtractor.Store(&extract)
+}
+
+// WithContextExtractor is the option form of SetContextExtractor
+func WithContextExtractor(extract ContextExtractor) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetContextExtractor(extract)
+		return nil
+	}
+}
+
+// extractedFields returns the fields of the context extractor for ctx
+func extractedFields(ctx context.Context) map[string]interface{} {
+	extract := contextExtractor.Load()
+	if extract == nil {
+		return nil
+	}
+	return (*extract)(ctx)
+}
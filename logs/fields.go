@@ -0,0 +1,149 @@
+package logs
+
+import (
+	"errors"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+// reservedFields are set by decorateLog on every entry and always take
+// precedence over user supplied fields
+var reservedFields = map[string]struct{}{
+	"source":    {},
+	"prefix":    {},
+	"requestID": {},
+	"trace_id":  {},
+	"span_id":   {},
+
+	goroutineIDField: {},
+
+	datadogTraceIDField: {},
+	datadogSpanIDField:  {},
+}
+
+// WithFields returns a child logger that adds the given fields to every entry.
+// Fields given on successive calls are merged, later keys winning.
+//
+// A field named like one of the reserved fields (source, prefix, requestID,
+// trace_id, span_id, goroutine_id, dd.trace_id, dd.span_id) never overwrites
+// it; its value is logged under "fields.<name>" instead, the same way logrus
+// handles clashes with its own time, msg and level keys.
+func (q *CommonLogger) WithFields(fields map[string]interface{}) *CommonLogger {
+	child := q.clone()
+	for k, v := range fields {
+		child.fields[k] = v
+	}
+	return child
+}
+
+// WithField is like WithFields for a single field
+func (q *CommonLogger) WithField(key string, value interface{}) *CommonLogger {
+	return q.WithFields(map[string]interface{}{key: value})
+}
+
+// WithError returns a child logger adding err under the "error" field, and
+// walking its Unwrap chain, the message of the root cause under error_cause
+// when err wraps another error, and the first Code() string and
+// HTTPStatus() int found in the chain under error_code and error_status. A
+// nil err returns q unchanged.
+func (q *CommonLogger) WithError(err error) *CommonLogger {
+	if err == nil {
+		return q
+	}
+	fields := map[string]interface{}{logrus.ErrorKey: err}
+	if root := rootCause(err); root != err {
+		fields[errorCauseField] = root.Error()
+	}
+	var coded interface{ Code() string }
+	if errors.As(err, &coded) {
+		fields[errorCodeField] = coded.Code()
+	}
+	var status interface{ HTTPStatus() int }
+	if errors.As(err, &status) {
+		fields[errorStatusField] = status.HTTPStatus()
+	}
+	return q.WithFields(fields)
+}
+
+// Fields set by WithError next to the error one
+const (
+	errorCauseField  = "error_cause"
+	errorStatusField = "error_status"
+)
+
+// rootCause returns the innermost error of the Unwrap chain of err
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+func (q *CommonLogger) clone() *CommonLogger {
+	fields := make(logrus.Fields, len(q.fields))
+	for k, v := range q.fields {
+		fields[k] = v
+	}
+	return &CommonLogger{
+		logger:     q.logger,
+		prefix:     q.prefix,
+		requestID:  q.requestID,
+		traceID:    q.traceID,
+		spanID:     q.spanID,
+		fields:     fields,
+		noReport:   q.noReport,
+		noSentry:   q.noSentry,
+		callerSkip: q.callerSkip,
+		ctx:        q.ctx,
+		redaction:  q.redaction,
+		boostLevel: q.boostLevel,
+	}
+}
+
+// withoutReserved moves fields clashing with the reserved ones under a
+// "fields." namespace
+func withoutReserved(fields logrus.Fields) logrus.Fields {
+	out := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		if _, ok := reservedFields[k]; ok {
+			k = "fields." + k
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// ToLogrusFields converts a gommon JSON map into logrus fields. Nested values
+// are kept as they are.
+func ToLogrusFields(j gommonLog.JSON) logrus.Fields {
+	fields := make(logrus.Fields, len(j))
+	for k, v := range j {
+		fields[k] = v
+	}
+	return fields
+}
+
+// ToJSON converts logrus fields into a gommon JSON map for the *j methods
+func ToJSON(fields logrus.Fields) gommonLog.JSON {
+	j := make(gommonLog.JSON, len(fields))
+	for k, v := range fields {
+		j[k] = v
+	}
+	return j
+}
+
+// LogMap logs a plain map at the given level the same way the matching *j
+// method does, without converting it to gommon JSON first
+func (q *CommonLogger) LogMap(level gommonLog.Lvl, m map[string]interface{}) {
+	fields, msg := q.jsonFields(m)
+	if lvl := toLogrusLevel(level); q.enabled(lvl) {
+		q.decorateLog().WithFields(fields).Log(lvl, msg)
+	}
+	if level == gommonLog.ERROR {
+		q.report(logrus.ErrorLevel, q.jsonMessage(m, msg), nil, nil)
+	}
+}
@@ -0,0 +1,234 @@
+package logs_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithFieldsKeepsReserved(t *testing.T) {
+	q, buf := newTestLogger("billing")
+	q.WithFields(map[string]interface{}{
+		"source": "user value",
+		"prefix": "other",
+		"order":  42,
+	}).Info("charged")
+
+	e := lastEntry(t, buf)
+	if source, _ := e["source"].(string); !strings.Contains(source, "fields_test.go") {
+		t.Errorf("source = %q, overwritten by the user field", source)
+	}
+	if e["fields.source"] != "user value" {
+		t.Errorf("fields.source = %v, want the user value", e["fields.source"])
+	}
+	if e["prefix"] != "billing" || e["fields.prefix"] != "other" {
+		t.Errorf("prefix = %v, fields.prefix = %v", e["prefix"], e["fields.prefix"])
+	}
+	if e["order"] != float64(42) {
+		t.Errorf("order = %v, other fields must be kept as is", e["order"])
+	}
+}
+
+func TestWithFieldsReservedIDs(t *testing.T) {
+	q, buf := newTestLogger()
+	q.WithRequestID("req-1").WithFields(map[string]interface{}{
+		"requestID": "spoofed",
+		"trace_id":  "spoofed",
+	}).Info("hello")
+
+	e := lastEntry(t, buf)
+	if e["requestID"] != "req-1" || e["fields.requestID"] != "spoofed" {
+		t.Errorf("requestID = %v, fields.requestID = %v", e["requestID"], e["fields.requestID"])
+	}
+	if _, ok := e["trace_id"]; ok {
+		t.Errorf("trace_id = %v, a user field must not set it", e["trace_id"])
+	}
+	if e["fields.trace_id"] != "spoofed" {
+		t.Errorf("fields.trace_id = %v", e["fields.trace_id"])
+	}
+}
+
+func TestFieldConversions(t *testing.T) {
+	nested := map[string]interface{}{
+		"user":  map[string]interface{}{"id": 7, "roles": []string{"admin"}},
+		"count": 3,
+		"nil":   nil,
+	}
+	tests := []struct {
+		name string
+		j    gommonLog.JSON
+	}{
+		{"nested", nested},
+		{"empty", gommonLog.JSON{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := logs.ToLogrusFields(tt.j)
+			if !reflect.DeepEqual(map[string]interface{}(fields), map[string]interface{}(tt.j)) {
+				t.Fatalf("ToLogrusFields = %v, want %v", fields, tt.j)
+			}
+			back := logs.ToJSON(fields)
+			if !reflect.DeepEqual(back, tt.j) {
+				t.Fatalf("ToJSON = %v, want %v", back, tt.j)
+			}
+		})
+	}
+
+	if fields := logs.ToLogrusFields(nil); fields == nil || len(fields) != 0 {
+		t.Fatalf("ToLogrusFields(nil) = %#v, want an empty map", fields)
+	}
+	if j := logs.ToJSON(logrus.Fields(nil)); j == nil || len(j) != 0 {
+		t.Fatalf("ToJSON(nil) = %#v, want an empty map", j)
+	}
+}
+
+func TestLogMap(t *testing.T) {
+	q, buf := newTestLogger()
+	q.LogMap(gommonLog.WARN, map[string]interface{}{
+		"msg":  "quota low",
+		"user": map[string]interface{}{"id": 7},
+		"left": 2,
+	})
+
+	e := lastEntry(t, buf)
+	if e["level"] != "warning" || e["msg"] != "quota low" {
+		t.Fatalf("entry = %v", e)
+	}
+	if e["user"] != `{"id":7}` || e["left"] != float64(2) {
+		t.Fatalf("user = %v, left = %v", e["user"], e["left"])
+	}
+
+	buf.Reset()
+	q.LogMap(gommonLog.INFO, map[string]interface{}{})
+	if e := lastEntry(t, buf); e["msg"] != "" {
+		t.Fatalf("msg = %v, want empty", e["msg"])
+	}
+}
+
+func TestWithFieldsMerge(t *testing.T) {
+	q, buf := newTestLogger("orders")
+	base := q.WithFields(map[string]interface{}{"order": 1, "user": "ann"})
+	child := base.WithFields(map[string]interface{}{"order": 2, "item": "book"}).WithField("qty", 3)
+
+	_, _, line, _ := runtime.Caller(0)
+	child.WithRequestID("req-1").Info("added")
+	e := lastEntry(t, buf)
+	if e["order"] != float64(2) || e["user"] != "ann" || e["item"] != "book" || e["qty"] != float64(3) {
+		t.Fatalf("merged fields = %v", e)
+	}
+	if e["prefix"] != "orders" || e["requestID"] != "req-1" {
+		t.Fatalf("decoration lost: %v", e)
+	}
+	// The source is the call of Info, not of WithFields
+	if want := fmt.Sprintf("fields_test.go:%d:TestWithFieldsMerge()", line+1); e["source"] != want {
+		t.Fatalf("source = %v, want %s", e["source"], want)
+	}
+
+	// Children never modify their parent
+	base.Info("base")
+	if e := lastEntry(t, buf); e["order"] != float64(1) || e["item"] != nil {
+		t.Fatalf("parent fields changed: %v", e)
+	}
+}
+
+func TestWithErrorField(t *testing.T) {
+	q, buf := newTestLogger()
+	q.WithError(errors.New("boom")).Warnf("retrying %d", 2)
+	if e := lastEntry(t, buf); e["error"] != "boom" || e["msg"] != "retrying 2" || e["level"] != "warning" {
+		t.Fatalf("entry = %v", e)
+	}
+	if q.WithError(nil) != q {
+		t.Fatal("WithError(nil) returned a new logger")
+	}
+}
+
+// paymentError carries a code and an HTTP status
+type paymentError struct {
+	code   string
+	status int
+}
+
+func (e *paymentError) Error() string   { return "payment " + e.code }
+func (e *paymentError) Code() string    { return e.code }
+func (e *paymentError) HTTPStatus() int { return e.status }
+
+// codedError carries a code only
+type codedError string
+
+func (e codedError) Error() string { return "coded " + string(e) }
+func (e codedError) Code() string  { return string(e) }
+
+func TestWithErrorChain(t *testing.T) {
+	reset := errors.New("connection reset")
+	for _, tc := range []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{"nil", nil, map[string]interface{}{}},
+		{"single", errors.New("boom"), map[string]interface{}{"error": "boom"}},
+		{"three deep", fmt.Errorf("charge: %w", fmt.Errorf("query: %w", fmt.Errorf("dial: %w", reset))), map[string]interface{}{
+			"error":       "charge: query: dial: connection reset",
+			"error_cause": "connection reset",
+		}},
+		{"status and code", fmt.Errorf("charge: %w", &paymentError{code: "PAY-402", status: 402}), map[string]interface{}{
+			"error":        "charge: payment PAY-402",
+			"error_cause":  "payment PAY-402",
+			"error_code":   "PAY-402",
+			"error_status": float64(402),
+		}},
+		{"code at the root", codedError("DB-500"), map[string]interface{}{
+			"error":      "coded DB-500",
+			"error_code": "DB-500",
+		}},
+		{"first code of the chain", fmt.Errorf("retry: %w", &paymentError{code: "PAY-500", status: 503}), map[string]interface{}{
+			"error":        "retry: payment PAY-500",
+			"error_cause":  "payment PAY-500",
+			"error_code":   "PAY-500",
+			"error_status": float64(503),
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, buf := newTestLogger()
+			q.WithError(tc.err).Warn("failed")
+			entry := lastEntry(t, buf)
+			for _, key := range []string{"error", "error_cause", "error_code", "error_status"} {
+				want, ok := tc.want[key]
+				if got, set := entry[key]; set != ok || got != want {
+					t.Errorf("%s = %#v, want %#v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithErrorSentryGrouping(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger()
+
+	reset := errors.New("connection reset")
+	q.WithError(fmt.Errorf("charge order 1: %w", reset)).Error("failed")
+	q.Error(fmt.Errorf("refund order 2: %w", fmt.Errorf("query: %w", reset)))
+	events := rec.Events()
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+	for _, event := range events {
+		if !reflect.DeepEqual(event.Fingerprint, events[0].Fingerprint) || len(event.Fingerprint) == 0 {
+			t.Fatalf("fingerprints %v and %v, want the ones of the root cause", events[0].Fingerprint, event.Fingerprint)
+		}
+		if len(event.Exception) == 0 {
+			t.Fatal("the error was not reported as an exception")
+		}
+	}
+	if fp := events[0].Fingerprint; fp[len(fp)-1] != "connection reset" {
+		t.Fatalf("fingerprint = %v, want the root cause", fp)
+	}
+}
@@ -0,0 +1,259 @@
+package logs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tinylib/msgp/msgp"
+)
+
+const (
+	defaultFluentdPort       = 24224
+	defaultFluentdTag        = "app"
+	defaultFluentdBufferSize = 8192
+	defaultFluentdRetryWait  = 500 * time.Millisecond
+
+	fluentdMaxRetryWait = 30 * time.Second
+	fluentdMaxRetries   = 8
+	fluentdTimeout      = 5 * time.Second
+)
+
+// FluentdOptions configures WithFluentd
+type FluentdOptions struct {
+	// Host and Port are the address of the Fluentd agent, port 24224 by
+	// default
+	Host string
+	Port int
+	// Tag is the tag of the entries, "app" by default
+	Tag string
+	// BufferSize is the number of entries buffered while the agent is slow
+	// or down, the ones logged once it is full being dropped. Defaults to
+	// 8192.
+	BufferSize int
+	// RetryWait is the wait before the first reconnection, doubled on every
+	// failed one up to 30s. Defaults to 500ms.
+	RetryWait time.Duration
+}
+
+// FluentdHook ships entries to a Fluentd agent over the forward protocol,
+// the fields flattened into the record along with the message and level. It
+// is a BatchHook, so logging never blocks on the agent.
+type FluentdHook struct {
+	*BatchHook
+	sender *fluentdSender
+}
+
+// NewFluentdHook starts a FluentdHook, to register with AddHook, see
+// WithFluentd. The agent is connected to lazily and reconnected to with an
+// exponential backoff, a batch failing 8 times in a row being dropped. A
+// batch sent while Flush or Close runs is tried once only.
+func NewFluentdHook(opts FluentdOptions) *FluentdHook {
+	if opts.Port <= 0 {
+		opts.Port = defaultFluentdPort
+	}
+	if opts.Tag == "" {
+		opts.Tag = defaultFluentdTag
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultFluentdBufferSize
+	}
+	if opts.RetryWait <= 0 {
+		opts.RetryWait = defaultFluentdRetryWait
+	}
+
+	sender := &fluentdSender{
+		addr:    net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+		tag:     opts.Tag,
+		wait:    opts.RetryWait,
+		wake:    make(chan struct{}, 1),
+		closing: make(chan struct{}),
+	}
+	h := NewBatchHook(sender.send, BatchOptions{QueueSize: opts.BufferSize})
+	h.encode = encodeFluentdEntry
+	return &FluentdHook{BatchHook: h, sender: sender}
+}
+
+// WithFluentd also ships every entry to a Fluentd agent, see NewFluentdHook.
+// Flush sends the buffered entries.
+func WithFluentd(opts FluentdOptions) Option {
+	return func(q *CommonLogger) error {
+		if opts.Host == "" {
+			return errors.New("logs: fluentd host must not be empty")
+		}
+		if q.dryRun {
+			return nil
+		}
+		h := NewFluentdHook(opts)
+		q.addHook(h)
+		registerSink(h, "fluentd", h.Flush, h.Close)
+		return nil
+	}
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+// or the agent unreachable
+func (h *FluentdHook) Dropped() int64 {
+	return h.BatchHook.Dropped() + h.sender.dropped.Load()
+}
+
+// Flush sends the buffered entries and waits for the send to return, trying
+// once only when the agent is down
+func (h *FluentdHook) Flush() {
+	h.sender.flushing.Add(1)
+	defer h.sender.flushing.Add(-1)
+	// Cuts short the backoff of a batch sent in the background
+	select {
+	case h.sender.wake <- struct{}{}:
+	default:
+	}
+	h.BatchHook.Flush()
+}
+
+// Close sends the buffered entries, trying once only when the agent is
+// down, and stops the hook
+func (h *FluentdHook) Close() error {
+	unregisterSink(h)
+	h.sender.closeOnce.Do(func() {
+		close(h.sender.closing)
+	})
+	err := h.BatchHook.Close()
+	h.sender.close()
+	return err
+}
+
+// encodeFluentdEntry encodes e as a [time, record] forward protocol entry
+func encodeFluentdEntry(e *logrus.Entry) ([]byte, error) {
+	b := msgp.AppendArrayHeader(nil, 2)
+	b = appendEventTime(b, e.Time)
+	b = msgp.AppendMapHeader(b, uint32(len(e.Data)+2))
+	b = msgp.AppendString(b, "message")
+	b = msgp.AppendString(b, e.Message)
+	b = msgp.AppendString(b, "level")
+	b = msgp.AppendString(b, e.Level.String())
+	for k, v := range e.Data {
+		b = msgp.AppendString(b, k)
+		v = flatten(v)
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		encoded, err := msgp.AppendIntf(b, v)
+		if err != nil {
+			encoded = msgp.AppendString(b, fmt.Sprintf("%+v", v))
+		}
+		b = encoded
+	}
+	return b, nil
+}
+
+// appendEventTime appends t as the EventTime extension, which keeps the
+// nanoseconds
+func appendEventTime(b []byte, t time.Time) []byte {
+	// fixext 8 of type 0
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// fluentdSender sends the batches in forward mode, reconnecting as needed
+type fluentdSender struct {
+	addr string
+	tag  string
+	wait time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	dropped atomic.Int64
+
+	// flushing counts the FluentdHook.Flush calls running, wake cuts the
+	// backoff short for them
+	flushing  atomic.Int32
+	wake      chan struct{}
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *fluentdSender) send(batch [][]byte) error {
+	msg := msgp.AppendArrayHeader(nil, 2)
+	msg = msgp.AppendString(msg, s.tag)
+	msg = msgp.AppendArrayHeader(msg, uint32(len(batch)))
+	for _, entry := range batch {
+		msg = append(msg, entry...)
+	}
+
+	// A flush or Close waiting on the send gets a single attempt, the
+	// backoff is only for the batches sent in the background
+	wait := s.wait
+	var err error
+	for attempt := 1; ; attempt++ {
+		flush := flushStarting()
+		if err = s.write(msg); err == nil {
+			return nil
+		}
+		if attempt == fluentdMaxRetries || s.stopping() {
+			break
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.closing:
+		case <-flush:
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+		if s.stopping() {
+			break
+		}
+		if wait *= 2; wait > fluentdMaxRetryWait {
+			wait = fluentdMaxRetryWait
+		}
+	}
+	s.dropped.Add(int64(len(batch)))
+	return fmt.Errorf("logs: fluentd: %w", err)
+}
+
+// stopping reports whether the hook is closing or the entries being flushed,
+// which must not wait for the agent to come back
+func (s *fluentdSender) stopping() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return s.flushing.Load() > 0 || flushInProgress()
+	}
+}
+
+// write writes msg to the agent
+func (s *fluentdSender) write(msg []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.DialTimeout("tcp", s.addr, fluentdTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(fluentdTimeout))
+	if _, err := s.conn.Write(msg); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *fluentdSender) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
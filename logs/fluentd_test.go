@@ -0,0 +1,157 @@
+package logs_test
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// forwardEntry is an entry decoded from a forward protocol message
+type forwardEntry struct {
+	tag    string
+	time   time.Time
+	record map[string]interface{}
+}
+
+// fakeFluentd decodes the forward mode messages sent to it
+func fakeFluentd(t *testing.T) (port int, entries <-chan forwardEntry) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	ch := make(chan forwardEntry, 64)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := msgp.NewReader(conn)
+		for {
+			if _, err := r.ReadArrayHeader(); err != nil {
+				return
+			}
+			tag, _ := r.ReadString()
+			n, _ := r.ReadArrayHeader()
+			for i := uint32(0); i < n; i++ {
+				r.ReadArrayHeader()
+				// EventTime: fixext 8 of type 0, seconds then nanoseconds
+				ext, err := r.R.Next(10)
+				if err != nil || ext[0] != 0xd7 || ext[1] != 0 {
+					t.Errorf("time %x, want an EventTime", ext)
+					return
+				}
+				at := time.Unix(int64(binary.BigEndian.Uint32(ext[2:6])), int64(binary.BigEndian.Uint32(ext[6:])))
+				record := map[string]interface{}{}
+				if err := r.ReadMapStrIntf(record); err != nil {
+					t.Errorf("record: %v", err)
+					return
+				}
+				ch <- forwardEntry{tag: tag, time: at, record: record}
+			}
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestFluentdHook(t *testing.T) {
+	port, entries := fakeFluentd(t)
+	h := logs.NewFluentdHook(logs.FluentdOptions{Host: "127.0.0.1", Port: port, Tag: "billing.api"})
+	defer h.Close()
+	q, _ := newTestLogger("billing")
+	q.AddHook(h)
+
+	before := time.Now()
+	q.WithRequestID("req-1").WithField("items", []int{1, 2}).Warnf("shipped %d", 2)
+	h.Flush()
+
+	var e forwardEntry
+	select {
+	case e = <-entries:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no entry received")
+	}
+	if e.tag != "billing.api" {
+		t.Fatalf("tag = %q", e.tag)
+	}
+	if d := e.time.Sub(before); d < 0 || d > time.Second || e.time.Nanosecond() == 0 {
+		t.Fatalf("time = %v, logged at %v", e.time, before)
+	}
+	for key, want := range map[string]interface{}{
+		"message":   "shipped 2",
+		"level":     "warning",
+		"prefix":    "billing",
+		"requestID": "req-1",
+		"items":     "[1,2]",
+	} {
+		if e.record[key] != want {
+			t.Errorf("%s = %#v, want %#v", key, e.record[key], want)
+		}
+	}
+	if h.Dropped() != 0 {
+		t.Fatalf("dropped %d entries", h.Dropped())
+	}
+}
+
+// downAgent returns a port nothing listens on
+func downAgent(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestFluentdFlushAgentDown(t *testing.T) {
+	h := logs.NewFluentdHook(logs.FluentdOptions{Host: "127.0.0.1", Port: downAgent(t), RetryWait: time.Minute})
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	q.Info("lost")
+	start := time.Now()
+	h.Flush()
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Flush waited %v for the agent", d)
+	}
+	if h.Dropped() != 1 {
+		t.Fatalf("dropped %d entries, want 1", h.Dropped())
+	}
+}
+
+func TestFluentdBufferFull(t *testing.T) {
+	h := logs.NewFluentdHook(logs.FluentdOptions{Host: "127.0.0.1", Port: downAgent(t), BufferSize: 2, RetryWait: time.Minute})
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	// The batch sent after the flush interval waits for the agent
+	q.Info("first")
+	time.Sleep(1200 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		q.Info("buffered")
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("logging blocked %v on the agent", d)
+	}
+	if h.Dropped() != 8 {
+		t.Fatalf("dropped %d entries, want the 8 over the buffer", h.Dropped())
+	}
+
+	start = time.Now()
+	h.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Close waited %v for the agent", d)
+	}
+	if h.Dropped() != 11 {
+		t.Fatalf("dropped %d entries after Close, want all 11", h.Dropped())
+	}
+}
@@ -0,0 +1,306 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gelfVersion = "1.1"
+	// gelfChunkSize is the payload of a UDP chunk, below the 8192 bytes
+	// Graylog accepts
+	gelfChunkSize = 8154
+	gelfMaxChunks = 128
+	gelfTimeout   = 5 * time.Second
+)
+
+// gelfChunkMagic opens every chunk of a chunked GELF message
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFOptions configures WithGELF
+type GELFOptions struct {
+	// Addr is the host:port of the Graylog input
+	Addr string
+	// Protocol is "udp", the default, or "tcp"
+	Protocol string
+	// CompressionType compresses the UDP messages, "gzip" by default, "zlib"
+	// or "none". TCP messages are never compressed.
+	CompressionType string
+}
+
+// GELFHook ships entries to Graylog in the GELF format, the fields turned
+// into additional fields prefixed with an underscore. It is a BatchHook, so
+// logging never blocks on Graylog, and the messages failing to be sent are
+// dropped and counted.
+type GELFHook struct {
+	*BatchHook
+	sender *gelfSender
+}
+
+// NewGELFHook starts a GELFHook, to register with AddHook, see WithGELF
+func NewGELFHook(opts GELFOptions) (*GELFHook, error) {
+	protocol, compression, err := opts.settings()
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	sender := &gelfSender{
+		addr:        opts.Addr,
+		protocol:    protocol,
+		compression: compression,
+	}
+	h := NewBatchHook(sender.send, BatchOptions{})
+	h.encode = func(e *logrus.Entry) ([]byte, error) {
+		return encodeGELF(e, host)
+	}
+	return &GELFHook{BatchHook: h, sender: sender}, nil
+}
+
+// settings validates opts and returns the protocol and compression with the
+// defaults applied
+func (opts GELFOptions) settings() (protocol, compression string, err error) {
+	if opts.Addr == "" {
+		return "", "", errors.New("logs: GELF address must not be empty")
+	}
+	protocol = strings.ToLower(opts.Protocol)
+	switch protocol {
+	case "":
+		protocol = "udp"
+	case "udp", "tcp":
+	default:
+		return "", "", fmt.Errorf("logs: unknown GELF protocol %q", opts.Protocol)
+	}
+	compression = strings.ToLower(opts.CompressionType)
+	switch compression {
+	case "":
+		compression = "gzip"
+	case "gzip", "zlib", "none":
+	default:
+		return "", "", fmt.Errorf("logs: unknown GELF compression %q", opts.CompressionType)
+	}
+	return protocol, compression, nil
+}
+
+// WithGELF also ships every entry to Graylog, see NewGELFHook. Flush sends
+// the buffered entries.
+func WithGELF(opts GELFOptions) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			_, _, err := opts.settings()
+			return err
+		}
+		h, err := NewGELFHook(opts)
+		if err != nil {
+			return err
+		}
+		q.addHook(h)
+		registerSink(h, "gelf", h.Flush, h.Close)
+		return nil
+	}
+}
+
+// Dropped returns the number of entries dropped because the buffer was full
+// or Graylog unreachable
+func (h *GELFHook) Dropped() int64 {
+	return h.BatchHook.Dropped() + h.sender.dropped.Load()
+}
+
+// Close sends the buffered entries and stops the hook
+func (h *GELFHook) Close() error {
+	unregisterSink(h)
+	err := h.BatchHook.Close()
+	h.sender.close()
+	return err
+}
+
+// encodeGELF encodes e as a GELF message
+func encodeGELF(e *logrus.Entry, host string) ([]byte, error) {
+	short, _, multiline := strings.Cut(e.Message, "\n")
+	msg := make(map[string]interface{}, len(e.Data)+6)
+	for k, v := range e.Data {
+		v = flatten(v)
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		msg[gelfFieldName(k)] = v
+	}
+	msg["version"] = gelfVersion
+	msg["host"] = host
+	msg["short_message"] = short
+	if multiline {
+		msg["full_message"] = e.Message
+	}
+	msg["timestamp"] = float64(e.Time.UnixNano()) / float64(time.Second)
+	msg["level"] = gelfLevel(e.Level)
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("logs: failed to marshal GELF message: %w", err)
+	}
+	return b, nil
+}
+
+// gelfFieldName returns the additional field name of key, its characters
+// outside [\w.-] replaced, _id being reserved by GELF
+func gelfFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, key)
+	if name == "id" {
+		name = "id_"
+	}
+	return "_" + name
+}
+
+// gelfLevel returns the syslog severity of level
+func gelfLevel(level logrus.Level) int {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return 7
+	case logrus.InfoLevel:
+		return 6
+	case logrus.WarnLevel:
+		return 4
+	case logrus.ErrorLevel:
+		return 3
+	}
+	return 2
+}
+
+// gelfSender sends the messages, one datagram or chunk sequence per message
+// over UDP and null byte delimited over TCP
+type gelfSender struct {
+	addr        string
+	protocol    string
+	compression string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	dropped atomic.Int64
+}
+
+func (s *gelfSender) send(batch [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var failed int
+	var last error
+	for _, msg := range batch {
+		if err := s.write(msg); err != nil {
+			failed++
+			last = err
+		}
+	}
+	if failed > 0 {
+		s.dropped.Add(int64(failed))
+		return fmt.Errorf("logs: GELF: %d messages dropped: %w", failed, last)
+	}
+	return nil
+}
+
+// write sends msg, s.mu held
+func (s *gelfSender) write(msg []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.protocol, s.addr, gelfTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(gelfTimeout))
+
+	var err error
+	if s.protocol == "tcp" {
+		_, err = s.conn.Write(append(msg, 0))
+	} else {
+		err = s.writeUDP(msg)
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *gelfSender) writeUDP(msg []byte) error {
+	payload, err := s.compress(msg)
+	if err != nil {
+		return err
+	}
+	if len(payload) <= gelfChunkSize {
+		_, err = s.conn.Write(payload)
+		return err
+	}
+
+	count := (len(payload) + gelfChunkSize - 1) / gelfChunkSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("message of %d bytes exceeds %d chunks", len(payload), gelfMaxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, 12+gelfChunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * gelfChunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*gelfChunkSize:end]...)
+		if _, err := s.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *gelfSender) compress(msg []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch s.compression {
+	case "gzip":
+		w := gzip.NewWriter(&buf)
+		w.Write(msg)
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "zlib":
+		w := zlib.NewWriter(&buf)
+		w.Write(msg)
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return msg, nil
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *gelfSender) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
@@ -0,0 +1,263 @@
+package logs_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// fakeGraylog decodes the GELF messages sent to it over UDP, reassembling
+// the chunked ones and inflating the compressed ones
+func fakeGraylog(t *testing.T) (addr string, messages <-chan map[string]interface{}) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ch := make(chan map[string]interface{}, 64)
+	go func() {
+		chunks := map[string][][]byte{}
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			payload := append([]byte(nil), buf[:n]...)
+			if bytes.HasPrefix(payload, []byte{0x1e, 0x0f}) {
+				id, seq, count := string(payload[2:10]), payload[10], payload[11]
+				if chunks[id] == nil {
+					chunks[id] = make([][]byte, count)
+				}
+				chunks[id][seq] = payload[12:]
+				complete := true
+				for _, c := range chunks[id] {
+					complete = complete && c != nil
+				}
+				if !complete {
+					continue
+				}
+				payload = bytes.Join(chunks[id], nil)
+				delete(chunks, id)
+			}
+			msg, err := decodeGELF(payload)
+			if err != nil {
+				t.Errorf("invalid GELF message: %v", err)
+				return
+			}
+			ch <- msg
+		}
+	}()
+	return conn.LocalAddr().String(), ch
+}
+
+// decodeGELF decodes payload, inflated first when it starts with the gzip or
+// zlib magic number
+func decodeGELF(payload []byte) (map[string]interface{}, error) {
+	var r io.Reader = bytes.NewReader(payload)
+	var err error
+	switch {
+	case bytes.HasPrefix(payload, []byte{0x1f, 0x8b}):
+		r, err = gzip.NewReader(r)
+	case payload[0] == 0x78:
+		r, err = zlib.NewReader(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msg map[string]interface{}
+	err = json.NewDecoder(r).Decode(&msg)
+	return msg, err
+}
+
+func receiveGELF(t *testing.T, messages <-chan map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no GELF message received")
+		return nil
+	}
+}
+
+func TestGELFHookUDP(t *testing.T) {
+	for _, compression := range []string{"", "zlib", "none"} {
+		t.Run("compression "+compression, func(t *testing.T) {
+			addr, messages := fakeGraylog(t)
+			h, err := logs.NewGELFHook(logs.GELFOptions{Addr: addr, CompressionType: compression})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer h.Close()
+			q, _ := newTestLogger("billing")
+			q.AddHook(h)
+
+			q.WithRequestID("req-1").WithField("items", []int{1, 2}).WithField("id", 7).Warnf("shipped %d", 2)
+			h.Flush()
+			msg := receiveGELF(t, messages)
+			for key, want := range map[string]interface{}{
+				"version":       "1.1",
+				"short_message": "shipped 2",
+				"level":         float64(4),
+				"_prefix":       "billing",
+				"_requestID":    "req-1",
+				"_items":        "[1,2]",
+				"_id_":          float64(7),
+			} {
+				if msg[key] != want {
+					t.Errorf("%s = %#v, want %#v", key, msg[key], want)
+				}
+			}
+			if source, _ := msg["_source"].(string); !strings.Contains(source, "gelf_test.go") {
+				t.Errorf("_source = %#v, want the caller", msg["_source"])
+			}
+			if _, ok := msg["full_message"]; ok {
+				t.Errorf("full_message set for a single line message")
+			}
+			if ts, _ := msg["timestamp"].(float64); time.Since(time.Unix(int64(ts), 0)) > time.Minute {
+				t.Errorf("timestamp = %v", msg["timestamp"])
+			}
+			if h.Dropped() != 0 {
+				t.Fatalf("dropped %d messages", h.Dropped())
+			}
+		})
+	}
+}
+
+func TestGELFFullMessage(t *testing.T) {
+	addr, messages := fakeGraylog(t)
+	h, err := logs.NewGELFHook(logs.GELFOptions{Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	q.Errorf("failed\n  at main.go:10")
+	h.Flush()
+	msg := receiveGELF(t, messages)
+	if msg["short_message"] != "failed" || msg["full_message"] != "failed\n  at main.go:10" || msg["level"] != float64(3) {
+		t.Fatalf("message = %v", msg)
+	}
+}
+
+func TestGELFChunking(t *testing.T) {
+	addr, messages := fakeGraylog(t)
+	h, err := logs.NewGELFHook(logs.GELFOptions{Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	// Random, so it stays over several chunks once compressed
+	random := make([]byte, 50000)
+	rand.Read(random)
+	large := hex.EncodeToString(random)
+	q.Info(large)
+	h.Flush()
+	msg := receiveGELF(t, messages)
+	if msg["short_message"] != large {
+		t.Fatalf("reassembled short_message of %d bytes, want %d", len(msg["short_message"].(string)), len(large))
+	}
+}
+
+func TestGELFHookTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	messages := make(chan map[string]interface{}, 8)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			frame, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal(frame[:len(frame)-1], &msg); err != nil {
+				t.Errorf("invalid GELF frame %q: %v", frame, err)
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	h, err := logs.NewGELFHook(logs.GELFOptions{Addr: l.Addr().String(), Protocol: "TCP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	q.Info("first")
+	q.Debug("filtered")
+	q.Warn("second")
+	h.Flush()
+	for _, want := range []string{"first", "second"} {
+		if msg := receiveGELF(t, messages); msg["short_message"] != want {
+			t.Fatalf("short_message = %v, want %q", msg["short_message"], want)
+		}
+	}
+}
+
+func TestGELFUnreachable(t *testing.T) {
+	h, err := logs.NewGELFHook(logs.GELFOptions{Addr: "127.0.0.1:1", Protocol: "tcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	q, _ := newTestLogger()
+	q.AddHook(h)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		q.Errorf("lost %d", i)
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("logging took %v while Graylog is down", d)
+	}
+	h.Flush()
+	if h.Dropped() != 3 {
+		t.Fatalf("dropped %d messages, want 3", h.Dropped())
+	}
+}
+
+func TestGELFOptions(t *testing.T) {
+	captureShared(t)
+	for _, opts := range []logs.GELFOptions{
+		{},
+		{Addr: "localhost:12201", Protocol: "http"},
+		{Addr: "localhost:12201", CompressionType: "brotli"},
+	} {
+		if _, err := logs.NewGELFHook(opts); err == nil {
+			t.Errorf("NewGELFHook(%+v) succeeded", opts)
+		}
+		if _, err := logs.NewCommonLogWithConfig(logs.Config{GELF: opts}); opts.Addr != "" && err == nil {
+			t.Errorf("Config.GELF %+v accepted", opts)
+		}
+	}
+}
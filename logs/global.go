@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+var globalFields atomic.Pointer[logrus.Fields]
+
+// SetGlobalFields adds fields to the entries of every logger, meant to be
+// called once at startup with the service metadata. Fields set on the logger
+// or the entry win over them. They are also set as tags of the Sentry scope.
+func SetGlobalFields(fields map[string]interface{}) {
+	global := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		global[k] = v
+	}
+	globalFields.Store(&global)
+
+	sentry.ConfigureScope(func(scope *sentry.Scope) {
+		for k, v := range global {
+			scope.SetTag(k, fmt.Sprint(v))
+		}
+	})
+}
+
+// WithServiceMetadata sets the service, env, version and host global fields,
+// the empty ones being left out and the host name looked up
+func WithServiceMetadata(service, env, version string) Option {
+	return func(q *CommonLogger) error {
+		fields := map[string]interface{}{}
+		if service != "" {
+			fields["service"] = service
+		}
+		if env != "" {
+			fields["env"] = env
+		}
+		if version != "" {
+			fields["version"] = version
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		fields["host"] = hostname
+		if q.dryRun {
+			return nil
+		}
+		SetGlobalFields(fields)
+		return nil
+	}
+}
+
+func loadGlobalFields() logrus.Fields {
+	if f := globalFields.Load(); f != nil {
+		return *f
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// goroutineIDField is the field of SetGoroutineID
+const goroutineIDField = "goroutine_id"
+
+var goroutineIDs atomic.Bool
+
+// SetGoroutineID adds the ID of the logging goroutine to every entry as the
+// goroutine_id field, to follow concurrent work through the logs. Off by
+// default: reading the ID means formatting the stack of the goroutine on
+// every entry, too slow to leave on outside of debugging sessions.
+func SetGoroutineID(enabled bool) {
+	goroutineIDs.Store(enabled)
+}
+
+// WithGoroutineID is the option form of SetGoroutineID(true)
+func WithGoroutineID() Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetGoroutineID(true)
+		return nil
+	}
+}
+
+// goroutineID parses the ID out of the "goroutine 42 [running]:" first line
+// of the stack of the current goroutine, 0 if it can't
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i != -1 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
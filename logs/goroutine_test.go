@@ -0,0 +1,47 @@
+package logs_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestGoroutineID(t *testing.T) {
+	q, buf := newTestLogger()
+	q.Info("off")
+	if _, ok := lastEntry(t, buf)["goroutine_id"]; ok {
+		t.Fatal("goroutine_id logged while off")
+	}
+
+	logs.SetGoroutineID(true)
+	defer logs.SetGoroutineID(false)
+	q.Info("first")
+	q.WithField("k", "v").Info("second")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Info("spawned")
+		}()
+	}
+	wg.Wait()
+
+	entries := decodeEntries(t, buf)[1:]
+	ids := map[float64]int{}
+	for _, entry := range entries {
+		id, ok := entry["goroutine_id"].(float64)
+		if !ok || id <= 0 {
+			t.Fatalf("entry %v, want a goroutine_id", entry)
+		}
+		ids[id]++
+	}
+	if entries[0]["goroutine_id"] != entries[1]["goroutine_id"] {
+		t.Fatalf("goroutine_id %v then %v on the same goroutine", entries[0]["goroutine_id"], entries[1]["goroutine_id"])
+	}
+	if len(ids) != 11 {
+		t.Fatalf("%d goroutine IDs across 11 goroutines: %v", len(ids), ids)
+	}
+}
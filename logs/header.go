@@ -0,0 +1,201 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// headerTags are the gommon header variables HeaderFormatter renders
+var headerTags = map[string]func(e *logrus.Entry) string{
+	"time_rfc3339":      func(e *logrus.Entry) string { return e.Time.Format(time.RFC3339) },
+	"time_rfc3339_nano": func(e *logrus.Entry) string { return e.Time.Format(time.RFC3339Nano) },
+	"time_unix":         func(e *logrus.Entry) string { return strconv.FormatInt(e.Time.Unix(), 10) },
+	"time_unix_nano":    func(e *logrus.Entry) string { return strconv.FormatInt(e.Time.UnixNano(), 10) },
+	"level":             func(e *logrus.Entry) string { return strings.ToUpper(e.Level.String()) },
+	"prefix":            func(e *logrus.Entry) string { prefix, _ := e.Data["prefix"].(string); return prefix },
+	"short_file":        func(e *logrus.Entry) string { file, _ := splitSource(e); return path.Base(file) },
+	"long_file":         longFile,
+	"line":              func(e *logrus.Entry) string { _, line := splitSource(e); return line },
+}
+
+// headerFields are the fields rendered by the header variables, left out of
+// the trailing key=value pairs
+var headerFields = map[string][]string{
+	"prefix":     {"prefix"},
+	"short_file": {"source", callerFileField},
+	"long_file":  {"source", callerFileField, longFileField},
+	"line":       {"source", callerLineField},
+}
+
+// longFileField is the field carrying the file in the long_file form, added
+// by the loggers whose HeaderFormatter renders it
+const longFileField = "long_file"
+
+// longFileLoggers are the logrus loggers whose formatter renders long_file
+var longFileLoggers sync.Map
+
+// recordLongFile records whether formatter, the formatter of l, renders
+// long_file
+func recordLongFile(l *logrus.Logger, formatter logrus.Formatter) {
+	if f, ok := formatter.(*HeaderFormatter); ok && f.longFile {
+		longFileLoggers.Store(l, struct{}{})
+		return
+	}
+	longFileLoggers.Delete(l)
+}
+
+// longFile renders the file with the import path of its package, the file
+// field of CallerSplit being already in that form
+func longFile(e *logrus.Entry) string {
+	if file, ok := e.Data[longFileField].(string); ok {
+		return file
+	}
+	file, _ := splitSource(e)
+	return file
+}
+
+// header is the template given to SetHeader, applied by SetFormat when the
+// text format is selected again
+var header atomic.Value
+
+// SetHeader implements echo.Logger, rendering the text entries from the
+// gommon header template h, e.g. "${time_rfc3339} ${level} ${prefix}
+// ${short_file}:${line}", followed by the message and the other fields, see
+// HeaderFormatter. While another format is active, the header is only
+// recorded, for SetFormat(FormatText). An empty one restores the default
+// text format.
+func (q *CommonLogger) SetHeader(h string) {
+	header.Store(h)
+	switch q.logger.Formatter.(type) {
+	case *prefixed.TextFormatter, *HeaderFormatter:
+	default:
+		return
+	}
+	if h == "" {
+		q.setFormatter(withColorMode(&prefixed.TextFormatter{
+			FullTimestamp: true,
+		}))
+		return
+	}
+	q.setFormatter(NewHeaderFormatter(h))
+}
+
+// headerSegment is a literal or, when render is set, a variable of a header
+type headerSegment struct {
+	literal string
+	render  func(e *logrus.Entry) string
+	field   string
+}
+
+// HeaderFormatter renders entries as a gommon header template followed by
+// the message and the fields not rendered by the header as sorted key=value
+// pairs. The time_rfc3339, time_rfc3339_nano, time_unix, time_unix_nano,
+// level, prefix, short_file, long_file and line variables are supported, the
+// file and line being taken from the source field, and unknown ones are kept
+// literally. short_file is the base name of the file, long_file the file
+// with the import path of its package, e.g.
+// github.com/acme/payments/handler/handler.go.
+type HeaderFormatter struct {
+	segments []headerSegment
+	// omitted are the fields rendered by the header
+	omitted map[string]struct{}
+	// longFile is set when the header renders long_file
+	longFile bool
+}
+
+// NewHeaderFormatter parses the header template h
+func NewHeaderFormatter(h string) *HeaderFormatter {
+	f := &HeaderFormatter{omitted: map[string]struct{}{}}
+	for h != "" {
+		start := strings.Index(h, "${")
+		if start == -1 {
+			f.segments = append(f.segments, headerSegment{literal: h})
+			break
+		}
+		end := strings.Index(h[start:], "}")
+		if end == -1 {
+			f.segments = append(f.segments, headerSegment{literal: h})
+			break
+		}
+		end += start
+		if start > 0 {
+			f.segments = append(f.segments, headerSegment{literal: h[:start]})
+		}
+		tag := h[start+2 : end]
+		if render, ok := headerTags[tag]; ok {
+			f.segments = append(f.segments, headerSegment{render: render})
+			for _, field := range headerFields[tag] {
+				f.omitted[field] = struct{}{}
+			}
+			f.longFile = f.longFile || tag == "long_file"
+		} else {
+			f.segments = append(f.segments, headerSegment{literal: h[start : end+1]})
+		}
+		h = h[end+1:]
+	}
+	return f
+}
+
+// Format implements logrus.Formatter
+func (f *HeaderFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	b := e.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	for _, s := range f.segments {
+		if s.render != nil {
+			b.WriteString(s.render(e))
+		} else {
+			b.WriteString(s.literal)
+		}
+	}
+	if e.Message != "" {
+		if len(f.segments) > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(e.Message)
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		if _, ok := f.omitted[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := e.Data[k]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		s := fmt.Sprint(v)
+		if strings.ContainsAny(s, " \"=") {
+			s = strconv.Quote(s)
+		}
+		fmt.Fprintf(b, " %s=%s", k, s)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// splitSource returns the file and line of the source field in the default
+// file:line:func() layout, or the file and line fields of CallerSplit
+func splitSource(e *logrus.Entry) (string, string) {
+	if file, ok := e.Data[callerFileField].(string); ok {
+		return file, fmt.Sprint(e.Data[callerLineField])
+	}
+	source, _ := e.Data["source"].(string)
+	file, rest, _ := strings.Cut(source, ":")
+	line, _, _ := strings.Cut(rest, ":")
+	return file, line
+}
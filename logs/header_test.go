@@ -0,0 +1,103 @@
+package logs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// textLogger returns a text logger writing to a buffer, the header being
+// reset when the test ends
+func textLogger(t *testing.T, prefix string) (*logs.CommonLogger, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf, prefix)
+	t.Cleanup(func() { q.SetHeader("") })
+	return q, buf
+}
+
+func TestSetHeader(t *testing.T) {
+	q, buf := textLogger(t, "billing")
+
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"${level} ${prefix} ${short_file}:${line}", `^INFO billing header_test\.go:\d+ charged items=2\n$`},
+		{"${line} ${short_file} [${level}]", `^\d+ header_test\.go \[INFO\] charged items=2 prefix=billing\n$`},
+		{"${prefix}|${level}", `^billing\|INFO charged items=2 source=header_test\.go:\d+`},
+		{"${level} ${remote_ip} ${prefix", `^INFO \$\{remote_ip\} \$\{prefix charged items=2 prefix=billing source=`},
+	} {
+		q.SetHeader(tc.header)
+		buf.Reset()
+		q.WithField("items", 2).Info("charged")
+		if !regexp.MustCompile(tc.want).MatchString(buf.String()) {
+			t.Errorf("header %q rendered %q, want %s", tc.header, buf.String(), tc.want)
+		}
+	}
+
+	q.SetHeader("${time_rfc3339} ${level}")
+	buf.Reset()
+	q.Warn("slow")
+	at, rest, _ := strings.Cut(buf.String(), " ")
+	if ts, err := time.Parse(time.RFC3339, at); err != nil || time.Since(ts) > time.Minute {
+		t.Fatalf("time %q: %v", at, err)
+	}
+	if !strings.HasPrefix(rest, "WARNING slow prefix=billing source=header_test.go:") {
+		t.Fatalf("rendered %q", buf.String())
+	}
+
+	// An empty header restores the default text format
+	q.SetHeader("")
+	if _, ok := q.Formatter().(*prefixed.TextFormatter); !ok {
+		t.Fatalf("formatter = %T, want the default text formatter", q.Formatter())
+	}
+}
+
+func TestHeaderFiles(t *testing.T) {
+	defer logs.SetCallerFormat(logs.CallerShort)
+	q, buf := textLogger(t, "")
+	q.SetHeader("${short_file} ${long_file}:${line}")
+
+	const want = `^header_test\.go github\.com/rohanchauhan02/common/logs_test/header_test\.go:\d+ charged( func=\S+)?\n$`
+	for _, f := range []logs.CallerFormat{logs.CallerShort, logs.CallerSplit} {
+		if err := logs.SetCallerFormat(f); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		q.Info("charged")
+		// The fields rendered are not repeated, only func is left with
+		// CallerSplit
+		if !regexp.MustCompile(want).MatchString(buf.String()) {
+			t.Errorf("%s caller format rendered %q, want %s", f, buf.String(), want)
+		}
+	}
+}
+
+func TestSetHeaderJSON(t *testing.T) {
+	q, buf := newTestLogger("billing")
+	t.Cleanup(func() { q.SetHeader("") })
+
+	q.SetHeader("${level} ${prefix}")
+	q.Info("charged")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry["msg"] != "charged" {
+		t.Fatalf("JSON output changed by the header: %q", buf.String())
+	}
+
+	// Recorded for the text format
+	if err := q.SetFormat(logs.FormatText); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	q.Info("charged")
+	if !strings.HasPrefix(buf.String(), "INFO billing charged") {
+		t.Fatalf("text format ignores the recorded header: %q", buf.String())
+	}
+}
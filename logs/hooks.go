@@ -0,0 +1,64 @@
+package logs
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// hooksMu serializes the hook registrations, so RemoveHooks can rebuild the
+// hooks of a logger while entries keep being logged
+var hooksMu sync.Mutex
+
+// userHook marks the hooks registered with AddHook, the only ones removed by
+// RemoveHooks
+type userHook struct {
+	logrus.Hook
+}
+
+// AddHook registers a logrus hook called with every entry of the underlying
+// logger, decorated with the source, prefix and requestID fields. It is safe
+// to call while other goroutines are logging.
+func (q *CommonLogger) AddHook(hook logrus.Hook) {
+	q.addHook(&userHook{Hook: hook})
+}
+
+// RemoveHooks unregisters every hook registered with AddHook, the hooks the
+// package installs for its own options being kept. Meant for tests.
+func (q *CommonLogger) RemoveHooks() {
+	q.removeHooks(func(*userHook) bool {
+		return true
+	})
+}
+
+// RemoveHook unregisters a hook registered with AddHook. hook must be of a
+// comparable type, a pointer typically.
+func (q *CommonLogger) RemoveHook(hook logrus.Hook) {
+	q.removeHooks(func(h *userHook) bool {
+		return h.Hook == hook
+	})
+}
+
+// removeHooks drops the hooks registered with AddHook matching remove
+func (q *CommonLogger) removeHooks(remove func(h *userHook) bool) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	kept := make(logrus.LevelHooks, len(q.logger.Hooks))
+	for level, hooks := range q.logger.Hooks {
+		for _, hook := range hooks {
+			if h, ok := hook.(*userHook); ok && remove(h) {
+				continue
+			}
+			kept[level] = append(kept[level], hook)
+		}
+	}
+	q.logger.ReplaceHooks(kept)
+}
+
+// addHook registers a hook of the package itself
+func (q *CommonLogger) addHook(hook logrus.Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	q.logger.AddHook(hook)
+}
@@ -0,0 +1,93 @@
+package logs_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// entryHook is the template of a hook: it keeps the fields of every entry
+type entryHook struct {
+	mu      sync.Mutex
+	entries []logrus.Fields
+}
+
+func (h *entryHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *entryHook) Fire(e *logrus.Entry) error {
+	fields := make(logrus.Fields, len(e.Data)+1)
+	for k, v := range e.Data {
+		fields[k] = v
+	}
+	fields["msg"] = e.Message
+	h.mu.Lock()
+	h.entries = append(h.entries, fields)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *entryHook) Entries() []logrus.Fields {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]logrus.Fields(nil), h.entries...)
+}
+
+func TestAddHook(t *testing.T) {
+	q, _ := newTestLogger("payments")
+	hook := &entryHook{}
+	q.AddHook(hook)
+
+	q.WithRequestID("req-1").Warn("declined")
+	entries := hook.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("hook got %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["msg"] != "declined" || entry["prefix"] != "payments" || entry["requestID"] != "req-1" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if source, _ := entry["source"].(string); source == "" {
+		t.Fatalf("entry = %v, want its source", entry)
+	}
+}
+
+func TestRemoveHooks(t *testing.T) {
+	q, buf := newTestLogger()
+	first, second := &entryHook{}, &entryHook{}
+	q.AddHook(first)
+	q.AddHook(second)
+
+	q.RemoveHook(first)
+	q.Info("one")
+	if len(first.Entries()) != 0 || len(second.Entries()) != 1 {
+		t.Fatalf("after RemoveHook: %d and %d entries", len(first.Entries()), len(second.Entries()))
+	}
+	q.RemoveHooks()
+	q.Info("two")
+	if len(second.Entries()) != 1 {
+		t.Fatal("hook called after RemoveHooks")
+	}
+	if len(decodeEntries(t, buf)) != 2 {
+		t.Fatal("removing the hooks stopped the logging")
+	}
+}
+
+func TestAddHookConcurrent(t *testing.T) {
+	q, _ := newTestLogger()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			q.AddHook(&entryHook{})
+		}()
+		go func() {
+			defer wg.Done()
+			q.Info("logged")
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,79 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultJSONMessageKey = "msg"
+
+var jsonMessageKey atomic.Value
+
+// SetJSONMessageKey changes the key of the *j maps whose value becomes the
+// message of the entry, "msg" by default
+func SetJSONMessageKey(key string) {
+	jsonMessageKey.Store(key)
+}
+
+func messageKey() string {
+	if key, ok := jsonMessageKey.Load().(string); ok && key != "" {
+		return key
+	}
+	return defaultJSONMessageKey
+}
+
+// jsonFields splits a map logged by a *j method into the message, taken from
+// the message key when set, and the fields. Nested maps, slices and structs
+// are encoded as JSON strings and keys clashing with the reserved fields are
+// renamed like WithFields does. Sensitive nested values are redacted before
+// the encoding hides them from the redaction hook.
+func (q *CommonLogger) jsonFields(j gommonLog.JSON) (logrus.Fields, string) {
+	rd := q.redactor()
+	key := messageKey()
+	var msg string
+	fields := make(logrus.Fields, len(j))
+	for k, v := range j {
+		if k == key {
+			msg = fmt.Sprint(v)
+			continue
+		}
+		fields[k] = flatten(rd.redactField(k, v))
+	}
+	return withoutReserved(fields), msg
+}
+
+// jsonMessage is the message of a *j call as reported to the error
+// destinations, the whole map as JSON when it has no message key
+func (q *CommonLogger) jsonMessage(j gommonLog.JSON, msg string) string {
+	if msg != "" {
+		return msg
+	}
+	b, err := json.Marshal(q.redactor().redactFields(j))
+	if err != nil {
+		return fmt.Sprintf("%+v", j)
+	}
+	return string(b)
+}
+
+func flatten(v interface{}) interface{} {
+	if v == nil {
+		return v
+	}
+	if _, ok := v.(error); ok {
+		return v
+	}
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%+v", v)
+		}
+		return string(b)
+	}
+	return v
+}
@@ -0,0 +1,73 @@
+package logs_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func nestedPayload() gommonLog.JSON {
+	return gommonLog.JSON{
+		"msg":    "login",
+		"user":   42,
+		"prefix": "spoofed",
+		"device": map[string]interface{}{"os": "linux", "ids": []int{1, 2}},
+		"roles":  []string{"admin", "ops"},
+	}
+}
+
+func TestInfojJSON(t *testing.T) {
+	q, buf := newTestLogger("auth")
+	q.Infoj(nestedPayload())
+	entry := lastEntry(t, buf)
+	for key, want := range map[string]interface{}{
+		"msg":           "login",
+		"user":          float64(42),
+		"prefix":        "auth",
+		"fields.prefix": "spoofed",
+		"device":        `{"ids":[1,2],"os":"linux"}`,
+		"roles":         `["admin","ops"]`,
+	} {
+		if entry[key] != want {
+			t.Errorf("%s = %#v, want %#v", key, entry[key], want)
+		}
+	}
+}
+
+func TestInfojText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf, "auth")
+	logs.WithFormatter(&logrus.TextFormatter{DisableColors: true, DisableTimestamp: true})(q)
+	q.Infoj(nestedPayload())
+	line := buf.String()
+	for _, want := range []string{
+		`msg=login`,
+		`user=42`,
+		`prefix=auth`,
+		`fields.prefix=spoofed`,
+		`device="{\"ids\":[1,2],\"os\":\"linux\"}"`,
+		`roles="[\"admin\",\"ops\"]"`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line %q lacks %s", line, want)
+		}
+	}
+	if strings.Contains(line, "map[") {
+		t.Errorf("line %q holds Go syntax", line)
+	}
+}
+
+func TestSetJSONMessageKey(t *testing.T) {
+	logs.SetJSONMessageKey("message")
+	defer logs.SetJSONMessageKey("")
+	q, buf := newTestLogger()
+	q.Infoj(gommonLog.JSON{"message": "custom", "msg": "kept"})
+	entry := lastEntry(t, buf)
+	if entry["msg"] != "custom" || entry["fields.msg"] != "kept" {
+		t.Fatalf("entry = %v, want the message from the custom key", entry)
+	}
+}
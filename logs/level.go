@@ -0,0 +1,174 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+// levelEnv is the environment variable NewCommonLogWithConfig reads the
+// level from when Config.Level is empty
+const levelEnv = "LOG_LEVEL"
+
+// The levels gommon defines but does not export, with the same values, and
+// TRACE, below DEBUG, which gommon lacks
+const (
+	PANIC gommonLog.Lvl = gommonLog.OFF + 1 + iota
+	FATAL
+	TRACE
+)
+
+// levelNames are the names ParseLevel accepts
+var levelNames = map[gommonLog.Lvl]string{
+	TRACE:           "trace",
+	gommonLog.DEBUG: "debug",
+	gommonLog.INFO:  "info",
+	gommonLog.WARN:  "warn",
+	gommonLog.ERROR: "error",
+	FATAL:           "fatal",
+	PANIC:           "panic",
+}
+
+// ParseLevel returns the level named s, case insensitively, among trace, debug,
+// info, warn (or warning), error, fatal and panic. Unknown names return an
+// error along with INFO.
+func ParseLevel(s string) (gommonLog.Lvl, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		return gommonLog.WARN, nil
+	}
+	for level, n := range levelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return gommonLog.INFO, fmt.Errorf("logs: unknown level %q", s)
+}
+
+// WithLevelName is like WithLevel with a level name parsed by ParseLevel. An
+// unknown name, e.g. a typo in LOG_LEVEL, fails NewCommonLogE and
+// NewCommonLogWithConfig. NewFromEnv, which can't fail, sets INFO instead and
+// logs a warning naming it.
+func WithLevelName(name string) Option {
+	return func(q *CommonLogger) error {
+		level, err := ParseLevel(name)
+		if q.dryRun {
+			return err
+		}
+		q.SetLevel(level)
+		if err != nil {
+			q.Warnf("Unknown log level %q, logging from info", name)
+		}
+		return nil
+	}
+}
+
+// Unset removes the override of a prefix given to SetLevelForPrefix
+const Unset gommonLog.Lvl = 0
+
+var (
+	// levelsMu serializes the level changes
+	levelsMu sync.Mutex
+	// configuredLevels holds the level set with SetLevel per logrus logger,
+	// whose own level is lowered to let the overrides through
+	configuredLevels sync.Map
+	// prefixLevels is an immutable map of the overridden levels per prefix,
+	// replaced on every change
+	prefixLevels atomic.Pointer[map[string]logrus.Level]
+)
+
+// SetLevelForPrefix makes the loggers with the given prefix log from level,
+// whatever the level set with SetLevel, e.g. debug for a single component
+// during an incident. Unset removes the override. Safe to call at any time.
+func SetLevelForPrefix(prefix string, level gommonLog.Lvl) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	overrides := make(map[string]logrus.Level)
+	if current := prefixLevels.Load(); current != nil {
+		for p, l := range *current {
+			overrides[p] = l
+		}
+	}
+	if level == Unset {
+		delete(overrides, prefix)
+	} else {
+		overrides[prefix] = toLogrusLevel(level)
+	}
+	prefixLevels.Store(&overrides)
+	applyLevelFloors()
+}
+
+// ClearLevelOverrides removes every override of SetLevelForPrefix
+func ClearLevelOverrides() {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	prefixLevels.Store(nil)
+	applyLevelFloors()
+}
+
+// configuredLevel returns the level set with SetLevel
+func (q *CommonLogger) configuredLevel() logrus.Level {
+	if l, ok := configuredLevels.Load(q.logger); ok {
+		return l.(logrus.Level)
+	}
+	return q.logger.GetLevel()
+}
+
+// prefixLevel returns the override of the prefix of q
+func (q *CommonLogger) prefixLevel() (logrus.Level, bool) {
+	return levelOverride(q.prefix)
+}
+
+func levelOverride(prefix string) (logrus.Level, bool) {
+	overrides := prefixLevels.Load()
+	if overrides == nil {
+		return 0, false
+	}
+	l, ok := (*overrides)[prefix]
+	return l, ok
+}
+
+// setConfiguredLevel records the level of q.logger, levelsMu must be held
+func (q *CommonLogger) setConfiguredLevel(level logrus.Level) {
+	configuredLevels.Store(q.logger, level)
+	applyLevelFloor(q.logger, level)
+}
+
+// applyLevelFloors lowers the level of every logrus logger to the most
+// verbose one needed, debug once requests may be boosted, levelsMu must be
+// held
+func applyLevelFloors() {
+	shared := NewCommonLog()
+	if _, ok := configuredLevels.Load(shared.logger); !ok {
+		configuredLevels.Store(shared.logger, shared.logger.GetLevel())
+	}
+	configuredLevels.Range(func(k, v interface{}) bool {
+		applyLevelFloor(k.(*logrus.Logger), v.(logrus.Level))
+		return true
+	})
+}
+
+func applyLevelFloor(l *logrus.Logger, configured logrus.Level) {
+	floor := configured
+	if overrides := prefixLevels.Load(); overrides != nil {
+		for _, level := range *overrides {
+			if level > floor {
+				floor = level
+			}
+		}
+	}
+	if debugBoosts.Load() && floor < logrus.DebugLevel {
+		floor = logrus.DebugLevel
+	}
+	// Audit entries are logged at info whatever the level, the methods of
+	// CommonLogger filter on the configured one
+	if floor < logrus.InfoLevel {
+		floor = logrus.InfoLevel
+	}
+	l.SetLevel(floor)
+}
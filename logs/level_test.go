@@ -0,0 +1,249 @@
+package logs_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDebugDisabledAllocs(t *testing.T) {
+	q := logs.NewCommonLogWithOutput(io.Discard)
+	q.SetLevel(gommonLog.INFO)
+	allocs := testing.AllocsPerRun(1000, func() {
+		q.Debug("dropped")
+		q.Debugf("dropped %s", "value")
+		q.Debugw("dropped")
+	})
+	if allocs != 0 {
+		t.Fatalf("disabled debug calls allocate %v times", allocs)
+	}
+}
+
+func BenchmarkDebugDisabled(b *testing.B) {
+	q := logs.NewCommonLogWithOutput(io.Discard)
+	q.SetLevel(gommonLog.INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Debugf("dropped %d", 42)
+	}
+}
+
+func BenchmarkInfoEnabled(b *testing.B) {
+	q := logs.NewCommonLogWithOutput(io.Discard)
+	q.SetLevel(gommonLog.INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Infof("logged %d", 42)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want gommonLog.Lvl
+	}{
+		{"trace", logs.TRACE},
+		{"debug", gommonLog.DEBUG},
+		{"info", gommonLog.INFO},
+		{"warn", gommonLog.WARN},
+		{"warning", gommonLog.WARN},
+		{"error", gommonLog.ERROR},
+		{"fatal", logs.FATAL},
+		{"panic", logs.PANIC},
+		{"DEBUG", gommonLog.DEBUG},
+		{"Warning", gommonLog.WARN},
+		{" eRRor ", gommonLog.ERROR},
+	} {
+		level, err := logs.ParseLevel(tt.name)
+		if err != nil || level != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.name, level, err, tt.want)
+		}
+	}
+	for _, name := range []string{"", "verbose", "off", "infoo"} {
+		level, err := logs.ParseLevel(name)
+		if err == nil || level != gommonLog.INFO {
+			t.Errorf("ParseLevel(%q) = %v, %v, want INFO and an error", name, level, err)
+		}
+	}
+}
+
+func TestLevelFromEnv(t *testing.T) {
+	q, _ := captureShared(t)
+	t.Setenv("LOG_LEVEL", "Debug")
+	if _, err := logs.NewCommonLogWithConfig(logs.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if q.Level() != gommonLog.DEBUG {
+		t.Fatalf("level = %v, want DEBUG from LOG_LEVEL", q.Level())
+	}
+
+	// Config.Level wins over the environment
+	if _, err := logs.NewCommonLogWithConfig(logs.Config{Level: "error"}); err != nil {
+		t.Fatal(err)
+	}
+	if q.Level() != gommonLog.ERROR {
+		t.Fatalf("level = %v, want ERROR from the config", q.Level())
+	}
+}
+
+func TestLevelFromEnvUnknown(t *testing.T) {
+	q, buf := captureShared(t)
+	q.SetLevel(gommonLog.ERROR)
+	t.Setenv("LOG_LEVEL", "verbose")
+	if _, err := logs.NewCommonLogWithConfig(logs.Config{}); err == nil || !strings.Contains(err.Error(), `"verbose"`) {
+		t.Fatalf("NewCommonLogWithConfig() = %v, want the unknown level error", err)
+	}
+	if _, err := logs.NewCommonLogE(logs.WithLevelName("verbose")); err == nil {
+		t.Fatal("NewCommonLogE accepted an unknown level")
+	}
+	if q.Level() != gommonLog.ERROR {
+		t.Fatalf("level = %v, changed by the failed constructors", q.Level())
+	}
+
+	// NewFromEnv falls back to INFO
+	defer logs.SetGlobalFields(nil)
+	defer logs.WithTimeLocation(time.Local)(q)
+	setEnv(t, "development", "verbose", "json", "")
+	logs.NewFromEnv()
+	if q.Level() != gommonLog.INFO {
+		t.Fatalf("level = %v, want the INFO fallback", q.Level())
+	}
+	entries := decodeEntries(t, buf)
+	var warned bool
+	for _, e := range entries {
+		warned = warned || e["msg"] == `Unknown log level "verbose", logging from info`
+	}
+	if !warned {
+		t.Fatalf("entries = %v, want the fallback warning", entries)
+	}
+}
+
+func TestTraceAndOffLevels(t *testing.T) {
+	q, buf := newTestLogger()
+	q.SetLevel(logs.TRACE)
+	q.Trace("traced")
+	if entry := lastEntry(t, buf); entry["level"] != "trace" {
+		t.Fatalf("entry = %v", entry)
+	}
+
+	buf.Reset()
+	q.SetLevel(gommonLog.OFF)
+	q.Info("dropped")
+	q.Warn("dropped")
+	if buf.Len() != 0 {
+		t.Fatalf("OFF logged %q", buf.String())
+	}
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	for _, level := range logrus.AllLevels {
+		if got := logs.ToLogrusLevel(logs.ToEchoLevel(level)); got != level {
+			t.Errorf("logrus %v round-trips to %v", level, got)
+		}
+		if logs.ToEchoLevel(level) == gommonLog.OFF {
+			t.Errorf("logrus %v maps to OFF", level)
+		}
+	}
+
+	q, _ := newTestLogger()
+	for _, level := range []gommonLog.Lvl{
+		logs.TRACE, gommonLog.DEBUG, gommonLog.INFO, gommonLog.WARN,
+		gommonLog.ERROR, logs.FATAL, logs.PANIC,
+	} {
+		if got := logs.ToEchoLevel(logs.ToLogrusLevel(level)); got != level {
+			t.Errorf("gommon %v round-trips to %v", level, got)
+		}
+		q.SetLevel(level)
+		q.SetLevel(q.Level())
+		if q.Level() != level {
+			t.Errorf("SetLevel(Level()) turned %v into %v", level, q.Level())
+		}
+	}
+}
+
+func TestTraceMethods(t *testing.T) {
+	q, buf := newTestLogger("tracer")
+	q.SetLevel(logs.TRACE)
+	q.Trace("payload")
+	q.Tracef("payload %d", 2)
+	q.Tracej(gommonLog.JSON{"payload": 3})
+	entries := decodeEntries(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["level"] != "trace" || entry["prefix"] != "tracer" {
+			t.Errorf("entry = %v, want trace with the prefix", entry)
+		}
+	}
+
+	buf.Reset()
+	q.SetLevel(gommonLog.DEBUG)
+	q.Trace("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("trace logged at DEBUG: %q", buf.String())
+	}
+}
+
+func TestSetLevelForPrefix(t *testing.T) {
+	defer logs.ClearLevelOverrides()
+	billing, billingBuf := newTestLogger("billing")
+	orders, ordersBuf := newTestLogger("orders")
+	billing.SetLevel(gommonLog.INFO)
+	orders.SetLevel(gommonLog.INFO)
+
+	logs.SetLevelForPrefix("billing", gommonLog.DEBUG)
+	billing.Debug("invoice payload")
+	billing.WithField("invoice", 1).Debugf("child %s", "payload")
+	orders.Debug("order payload")
+	if n := len(decodeEntries(t, billingBuf)); n != 2 {
+		t.Fatalf("billing logged %d debug entries, want 2", n)
+	}
+	if ordersBuf.Len() != 0 {
+		t.Fatalf("orders logged %q without an override", ordersBuf.String())
+	}
+	if billing.Level() != gommonLog.INFO {
+		t.Fatalf("Level() = %v, want the configured level", billing.Level())
+	}
+
+	// Overrides raise the level too
+	logs.SetLevelForPrefix("orders", gommonLog.ERROR)
+	orders.Warn("noisy")
+	if ordersBuf.Len() != 0 {
+		t.Fatalf("orders logged %q under an ERROR override", ordersBuf.String())
+	}
+
+	logs.SetLevelForPrefix("billing", logs.Unset)
+	billingBuf.Reset()
+	billing.Debug("hidden")
+	if billingBuf.Len() != 0 {
+		t.Fatalf("billing logged %q after Unset", billingBuf.String())
+	}
+	logs.ClearLevelOverrides()
+	orders.Warn("back")
+	if lastEntry(t, ordersBuf)["msg"] != "back" {
+		t.Fatal("orders override kept after ClearLevelOverrides")
+	}
+}
+
+func TestSetLevelForPrefixConcurrent(t *testing.T) {
+	defer logs.ClearLevelOverrides()
+	q := logs.NewCommonLogWithOutput(io.Discard, "billing")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			q.Debug("payload")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logs.SetLevelForPrefix("billing", gommonLog.DEBUG)
+		logs.SetLevelForPrefix("billing", logs.Unset)
+	}
+	<-done
+}
@@ -0,0 +1,31 @@
+package logs
+
+import (
+	"github.com/labstack/echo"
+	gommonLog "github.com/labstack/gommon/log"
+)
+
+// Logger is the method set of CommonLogger code should depend on, so tests
+// can inject the mock of the mocks package instead: the echo.Logger surface,
+// the trace level and the structured and timing helpers.
+//
+// WithFields, WithError and the other methods deriving a child logger return
+// the concrete *CommonLogger and are left out, the Debugw family attaching
+// fields through the interface instead.
+type Logger interface {
+	echo.Logger
+
+	Trace(i ...interface{})
+	Tracef(format string, args ...interface{})
+	Tracej(j gommonLog.JSON)
+
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Fatalw(msg string, keysAndValues ...interface{})
+
+	StartTimer(operation string, keysAndValues ...interface{}) func(err error)
+}
+
+var _ Logger = (*CommonLogger)(nil)
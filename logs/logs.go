@@ -1,14 +1,14 @@
 package logs
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"path"
-	"runtime"
-	"strings"
+	"os"
 	"sync"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/google/uuid"
 	"github.com/labstack/echo"
 	gommonLog "github.com/labstack/gommon/log"
 	"github.com/sirupsen/logrus"
@@ -20,53 +20,115 @@ type CommonLogger struct {
 	logger    *logrus.Logger
 	prefix    string
 	requestID string
+	traceID   string
+	spanID    string
+	fields    logrus.Fields
+	noReport  bool
+	noSentry  bool
+	// callerSkip is the number of frames skipped above the call site
+	callerSkip int
+	ctx        context.Context
+	// redaction are the rules added to the global ones by WithRedaction
+	redaction *redactor
+	// boostLevel is the level q logs from whatever the configured one, see
+	// RequestIDOptions.DebugAuthorizer. PanicLevel, the zero value, boosts
+	// nothing.
+	boostLevel logrus.Level
+	// dryRun is set on the logger NewCommonLogE checks the options with,
+	// the options then only validating their settings
+	dryRun bool
 }
 
 var (
-	logger   *logrus.Logger
-	once     sync.Once
-	instance *CommonLogger
+	logger *logrus.Logger
+	once   sync.Once
+
+	// outputMu serializes the output swaps, logrus only locking the write
+	outputMu sync.Mutex
 )
 
-// NewCommonLog is a factory that return  interface of log pakcage
+// NewCommonLog is a factory that return  interface of log pakcage.
+// Every call returns a new CommonLogger with its own prefix, all of them
+// sharing the same underlying logrus logger and so its output, level,
+// formatter and hooks.
 func NewCommonLog(prefix ...string) *CommonLogger {
 	once.Do(func() {
-		logger = logrus.New()
-		logger.Formatter = &prefixed.TextFormatter{
-			FullTimestamp: true,
-		}
-		instance = &CommonLogger{
-			logger: logger,
-		}
-		if len(prefix) > 0 {
-			instance.prefix = prefix[0]
-		}
+		logger = newLogrusLogger(os.Stderr)
 	})
-	// This will reset the prefix if the changes occured
-	// As it will not possible to set for the test suite
-	if len(prefix) > 0 && !strings.EqualFold(instance.prefix, prefix[0]) {
-		instance.prefix = prefix[0]
+
+	q := &CommonLogger{
+		logger: logger,
 	}
+	if len(prefix) > 0 {
+		q.prefix = prefix[0]
+	}
+	return q
+}
 
-	return instance
+// NewCommonLogWithOutput returns a logger independent of the one shared by
+// NewCommonLog, formatting entries the same way and writing them to w
+func NewCommonLogWithOutput(w io.Writer, prefix ...string) *CommonLogger {
+	q := &CommonLogger{
+		logger: newLogrusLogger(w),
+	}
+	if len(prefix) > 0 {
+		q.prefix = prefix[0]
+	}
+	return q
 }
 
-func (q *CommonLogger) decorateLog() *logrus.Entry {
-	var source string
-	if pc, file, line, ok := runtime.Caller(2); ok {
-		var funcName string
-		if fn := runtime.FuncForPC(pc); fn != nil {
-			funcName = fn.Name()
-			if i := strings.LastIndex(funcName, "."); i != -1 {
-				funcName = funcName[i+1:]
-			}
-		}
+// newLogrusLogger returns a logrus logger writing to w in the text format,
+// with the hooks every logger runs
+func newLogrusLogger(w io.Writer) *logrus.Logger {
+	l := logrus.New()
+	l.Out = w
+	l.Formatter = &prefixed.TextFormatter{
+		FullTimestamp: true,
+	}
+	l.AddHook(&redactHook{})
+	l.AddHook(&truncateHook{})
+	l.AddHook(&locationHook{})
+	l.AddHook(&breadcrumbHook{})
+	return l
+}
+
+// setFormatter replaces the formatter of q, and of the audit logger when q
+// is the shared logger
+func (q *CommonLogger) setFormatter(formatter logrus.Formatter) {
+	q.logger.SetFormatter(formatter)
+	if !q.dryRun {
+		recordLongFile(q.logger, formatter)
+	}
+	if q.logger != logger {
+		return
+	}
+	if w := auditOutput.Load(); w != nil && w.logger != nil {
+		w.logger.SetFormatter(formatter)
+	}
+}
 
-		source = fmt.Sprintf("%s:%v:%s()", path.Base(file), line, path.Base(funcName))
+// enabled reports whether entries at level are logged, checked before
+// decorating them so disabled levels cost nothing. The override of the
+// prefix of q, if any, decides over the level set with SetLevel, and the
+// boost of a request scoped logger lets more through.
+func (q *CommonLogger) enabled(level logrus.Level) bool {
+	if q.boostLevel >= level {
+		return true
+	}
+	if override, ok := q.prefixLevel(); ok {
+		return override >= level
+	}
+	return q.configuredLevel() >= level
+}
+
+func (q *CommonLogger) decorateLog() *logrus.Entry {
+	e := q.logger.WithFields(q.callerFields())
+	if goroutineIDs.Load() {
+		e = e.WithField(goroutineIDField, goroutineID())
+	}
+	if global := loadGlobalFields(); len(global) > 0 {
+		e = e.WithFields(withoutReserved(global))
 	}
-	e := q.logger.WithFields(logrus.Fields{
-		"source": source,
-	})
 	if q.prefix != "" {
 		e = e.WithFields(logrus.Fields{
 			"prefix": q.prefix,
@@ -77,147 +139,328 @@ func (q *CommonLogger) decorateLog() *logrus.Entry {
 			"requestID": q.requestID,
 		})
 	}
-	return e
+	if q.traceID != "" {
+		e = e.WithFields(logrus.Fields{
+			"trace_id": q.traceID,
+			"span_id":  q.spanID,
+		})
+	}
+	if q.ctx != nil {
+		e = e.WithContext(q.ctx)
+		if dd := datadogFields(q.ctx); dd != nil {
+			e = e.WithFields(dd)
+		}
+		if extracted := extractedFields(q.ctx); len(extracted) > 0 {
+			e = e.WithFields(extracted)
+		}
+	}
+	if len(q.fields) > 0 {
+		e = e.WithFields(withoutReserved(q.fields))
+	}
+	return q.withRedaction(e)
 }
 
 func (q *CommonLogger) Output() io.Writer {
 	return q.logger.Out
 }
 
-// SetOutput logger io.Writer
+// SetOutput replaces the writer entries are written to. It is safe to call
+// while other goroutines are logging, and applies to every logger sharing
+// the underlying logrus logger.
 func (q *CommonLogger) SetOutput(w io.Writer) {
-	// do nothing
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	q.logger.SetOutput(w)
+	q.redetectColor()
+}
+
+// AddOutput tees entries to w in addition to the current output, e.g. a file
+// next to stdout
+func (q *CommonLogger) AddOutput(w io.Writer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	q.logger.SetOutput(io.MultiWriter(q.logger.Out, w))
+	q.redetectColor()
 }
 
 func (q *CommonLogger) Prefix() string {
 	return q.prefix
 }
 
+// SetPrefix changes the prefix of this logger only
 func (q *CommonLogger) SetPrefix(p string) {
 	q.prefix = p
 }
 
 func (q *CommonLogger) Level() gommonLog.Lvl {
-	return toEchoLevel(q.logger.Level)
+	return toEchoLevel(q.configuredLevel())
 }
 
 func (q *CommonLogger) SetLevel(v gommonLog.Lvl) {
-	q.logger.Level = toLogrusLevel(v)
-}
-
-func (q *CommonLogger) SetHeader(h string) {
-	// do nothing
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	q.setConfiguredLevel(toLogrusLevel(v))
 }
 
 func (q *CommonLogger) Print(i ...interface{}) {
+	if !q.enabled(logrus.InfoLevel) || q.suppressedArgs(logrus.InfoLevel, i) {
+		return
+	}
 	q.decorateLog().Print(i...)
 }
 
 func (q *CommonLogger) Printf(format string, args ...interface{}) {
+	if !q.enabled(logrus.InfoLevel) || q.suppressed(logrus.InfoLevel, format) {
+		return
+	}
 	q.decorateLog().Printf(format, args...)
 }
 
 func (q *CommonLogger) Printj(j gommonLog.JSON) {
-	q.decorateLog().Printf("%+v", j)
+	if !q.enabled(logrus.InfoLevel) {
+		return
+	}
+	fields, msg := q.jsonFields(j)
+	if q.suppressed(logrus.InfoLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(fields).Print(msg)
+}
+
+// Trace logs below level debug, for the verbose dumps only enabled on
+// demand
+func (q *CommonLogger) Trace(i ...interface{}) {
+	if !q.enabled(logrus.TraceLevel) || q.suppressedArgs(logrus.TraceLevel, i) {
+		return
+	}
+	q.decorateLog().Trace(i...)
+}
+
+func (q *CommonLogger) Tracef(format string, args ...interface{}) {
+	if !q.enabled(logrus.TraceLevel) || q.suppressed(logrus.TraceLevel, format) {
+		return
+	}
+	q.decorateLog().Tracef(format, args...)
+}
+
+func (q *CommonLogger) Tracej(j gommonLog.JSON) {
+	if !q.enabled(logrus.TraceLevel) {
+		return
+	}
+	fields, msg := q.jsonFields(j)
+	if q.suppressed(logrus.TraceLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(fields).Trace(msg)
 }
 
 func (q *CommonLogger) Debug(i ...interface{}) {
+	if !q.enabled(logrus.DebugLevel) || q.suppressedArgs(logrus.DebugLevel, i) {
+		return
+	}
 	q.decorateLog().Debug(i...)
 }
 
 func (q *CommonLogger) Debugf(format string, args ...interface{}) {
+	if !q.enabled(logrus.DebugLevel) || q.suppressed(logrus.DebugLevel, format) {
+		return
+	}
 	q.decorateLog().Debugf(format, args...)
 }
 
 func (q *CommonLogger) Debugj(j gommonLog.JSON) {
-	q.decorateLog().Debugf("%+v", j)
+	if !q.enabled(logrus.DebugLevel) {
+		return
+	}
+	fields, msg := q.jsonFields(j)
+	if q.suppressed(logrus.DebugLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(fields).Debug(msg)
 }
 
 // Info is a logrus log message at level info on the standard logger
 func (q *CommonLogger) Info(i ...interface{}) {
+	if !q.enabled(logrus.InfoLevel) || q.suppressedArgs(logrus.InfoLevel, i) {
+		return
+	}
 	q.decorateLog().Info(i...)
 }
 
 // Infof is a logrus log message at level infof on the standard logger
 func (q *CommonLogger) Infof(format string, args ...interface{}) {
+	if !q.enabled(logrus.InfoLevel) || q.suppressed(logrus.InfoLevel, format) {
+		return
+	}
 	q.decorateLog().Infof(format, args...)
 }
 
 func (q *CommonLogger) Infoj(j gommonLog.JSON) {
-	q.decorateLog().Infof("%+v", j)
+	if !q.enabled(logrus.InfoLevel) {
+		return
+	}
+	fields, msg := q.jsonFields(j)
+	if q.suppressed(logrus.InfoLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(fields).Info(msg)
 }
 
 func (q *CommonLogger) Warn(i ...interface{}) {
+	if !q.enabled(logrus.WarnLevel) || q.suppressedArgs(logrus.WarnLevel, i) {
+		return
+	}
 	q.decorateLog().Warn(i...)
 }
 
 func (q *CommonLogger) Warnf(format string, args ...interface{}) {
+	if !q.enabled(logrus.WarnLevel) || q.suppressed(logrus.WarnLevel, format) {
+		return
+	}
 	q.decorateLog().Warnf(format, args...)
 }
 
 func (q *CommonLogger) Warnj(j gommonLog.JSON) {
-	q.decorateLog().Warnf("%+v", j)
+	if !q.enabled(logrus.WarnLevel) {
+		return
+	}
+	fields, msg := q.jsonFields(j)
+	if q.suppressed(logrus.WarnLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(fields).Warn(msg)
 }
 
 // Error is a logrus log message at level error on the standard logger
 func (q *CommonLogger) Error(i ...interface{}) {
-	q.decorateLog().Error(i...)
-	q.sentry(fmt.Sprintf("%+v", i...))
+	q.logError("", i)
 }
 
 // Errorf is a logrus log message at level errorf on the standard logger
 func (q *CommonLogger) Errorf(format string, args ...interface{}) {
-	q.decorateLog().Errorf(format, args...)
-	q.sentry(fmt.Sprintf(format, args...))
+	q.logErrorf("", format, args)
+}
+
+// logError is Error, adding the error_code field and Sentry tag when code is
+// not empty
+func (q *CommonLogger) logError(code string, i []interface{}) {
+	if q.enabled(logrus.ErrorLevel) && !q.suppressedArgs(logrus.ErrorLevel, i) {
+		withErrorCode(q.errorLog(findError(i)), code).Error(i...)
+	}
+	q.report(logrus.ErrorLevel, fmt.Sprintf("%+v", i...), findError(i), errorCodeTags(code))
+}
+
+// logErrorf is the format variant of logError
+func (q *CommonLogger) logErrorf(code string, format string, args []interface{}) {
+	if q.enabled(logrus.ErrorLevel) && !q.suppressed(logrus.ErrorLevel, format) {
+		withErrorCode(q.errorLog(findError(args)), code).Errorf(format, args...)
+	}
+	q.report(logrus.ErrorLevel, fmt.Sprintf(format, args...), findError(args), errorCodeTags(code))
 }
 
 func (q *CommonLogger) Errorj(j gommonLog.JSON) {
-	q.decorateLog().Errorf("%+v", j)
-	q.sentry(fmt.Sprintf("%+v", j))
+	fields, msg := q.jsonFields(j)
+	if q.enabled(logrus.ErrorLevel) && !q.suppressed(logrus.ErrorLevel, msg) {
+		q.errorLog(nil).WithFields(fields).Error(msg)
+	}
+	q.report(logrus.ErrorLevel, q.jsonMessage(j, msg), nil, nil)
 }
 
 func (q *CommonLogger) Fatal(i ...interface{}) {
-	q.decorateLog().Fatal(i...)
-	q.sentry(fmt.Sprintf("%+v", i...))
+	q.report(logrus.FatalLevel, fmt.Sprintf("%+v", i...), findError(i), nil)
+	flushBeforeExit()
+	q.errorLog(findError(i)).Fatal(i...)
 }
 
 func (q *CommonLogger) Fatalj(j gommonLog.JSON) {
-	q.decorateLog().Fatalf("%+v", j)
-	q.sentry(fmt.Sprintf("%+v", j))
+	fields, msg := q.jsonFields(j)
+	q.report(logrus.FatalLevel, q.jsonMessage(j, msg), nil, nil)
+	flushBeforeExit()
+	q.errorLog(nil).WithFields(fields).Fatal(msg)
 }
 
 func (q *CommonLogger) Fatalf(format string, args ...interface{}) {
-	q.decorateLog().Fatalf(format, args...)
-	q.sentry(fmt.Sprintf(format, args...))
+	q.report(logrus.FatalLevel, fmt.Sprintf(format, args...), findError(args), nil)
+	flushBeforeExit()
+	q.errorLog(findError(args)).Fatalf(format, args...)
 }
 
 func (q *CommonLogger) Panic(i ...interface{}) {
-	q.decorateLog().Panic(i...)
-	q.sentry(fmt.Sprintf("%+v", i...))
+	defer flushBeforeExit()
+	q.report(logrus.PanicLevel, fmt.Sprintf("%+v", i...), findError(i), nil)
+	q.errorLog(findError(i)).Panic(i...)
 }
 
 func (q *CommonLogger) Panicj(j gommonLog.JSON) {
-	q.decorateLog().Panicf("%+v", j)
-	q.sentry(fmt.Sprintf("%+v", j))
+	defer flushBeforeExit()
+	fields, msg := q.jsonFields(j)
+	q.report(logrus.PanicLevel, q.jsonMessage(j, msg), nil, nil)
+	q.errorLog(nil).WithFields(fields).Panic(msg)
 }
 
 func (q *CommonLogger) Panicf(format string, args ...interface{}) {
-	q.decorateLog().Panicf(format, args...)
-	q.sentry(fmt.Sprintf(format, args...))
+	defer flushBeforeExit()
+	q.report(logrus.PanicLevel, fmt.Sprintf(format, args...), findError(args), nil)
+	q.errorLog(findError(args)).Panicf(format, args...)
+}
+
+// MiddlewareLoggerRequestID binds a child logger to every request, stamped
+// with its X-Request-ID and traceparent IDs, that handlers retrieve with
+// FromEchoContext, and FromContext with the request context. q itself is
+// never modified, so concurrent requests don't see each other's IDs. A UUIDv4
+// request ID is generated when the header is missing, see
+// MiddlewareLoggerRequestIDWithOptions.
+//
+// Once Sentry is initialized, every request also gets its own clone of the
+// current Sentry hub, bound to the request context, so its tags and
+// breadcrumbs only go with its own events.
+//
+// See RequestIDOptions.DebugAuthorizer to log single requests at debug.
+func (q *CommonLogger) MiddlewareLoggerRequestID() echo.MiddlewareFunc {
+	return q.MiddlewareLoggerRequestIDWithOptions(RequestIDOptions{})
 }
 
-func (q *CommonLogger) sentry(message string) {
-	sentry.CaptureMessage(message)
-}
+// MiddlewareLoggerRequestIDWithOptions is MiddlewareLoggerRequestID
+// configured by opts. The request ID is also set on the X-Request-ID response
+// header.
+func (q *CommonLogger) MiddlewareLoggerRequestIDWithOptions(opts RequestIDOptions) echo.MiddlewareFunc {
+	generate := opts.Generator
+	if generate == nil {
+		generate = uuid.NewString
+	}
+	boost := debugBoost(opts)
 
-func (q *CommonLogger) MiddlewareLoggerRequestID() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			requestId := c.Request().Header.Get(echo.HeaderXRequestID)
-			q.requestID = requestId
-			sentry.ConfigureScope(func(scope *sentry.Scope) {
-				scope.SetTag("x-request-id", requestId)
-			})
+			var requestId string
+			if !opts.IgnoreInbound {
+				requestId = c.Request().Header.Get(echo.HeaderXRequestID)
+			}
+			if requestId == "" {
+				requestId = generate()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+			child := q.WithRequestID(requestId)
+			if boost != nil {
+				boost(c, child)
+			}
+			hub, _ := bindRequestHub(c)
+			// A missing or malformed traceparent leaves the IDs empty
+			traceID, spanID, ok := parseTraceparent(c.Request().Header.Get(headerTraceparent))
+			child.traceID, child.spanID = traceID, spanID
+			// The request context carries the span started by the tracing
+			// middleware, if any
+			bindRequestLogger(c, child)
+			if hub != nil {
+				hub.ConfigureScope(func(scope *sentry.Scope) {
+					scope.SetTag("x-request-id", requestId)
+					if ok {
+						scope.SetTag("trace_id", traceID)
+						scope.SetTag("span_id", spanID)
+						scope.SetExtra(headerTracestate, c.Request().Header.Get(headerTracestate))
+					}
+				})
+			}
 			return next(c)
 		}
 	}
@@ -226,6 +469,8 @@ func (q *CommonLogger) MiddlewareLoggerRequestID() echo.MiddlewareFunc {
 // To logrus.Level
 func toLogrusLevel(level gommonLog.Lvl) logrus.Level {
 	switch level {
+	case TRACE:
+		return logrus.TraceLevel
 	case gommonLog.DEBUG:
 		return logrus.DebugLevel
 	case gommonLog.INFO:
@@ -234,6 +479,11 @@ func toLogrusLevel(level gommonLog.Lvl) logrus.Level {
 		return logrus.WarnLevel
 	case gommonLog.ERROR:
 		return logrus.ErrorLevel
+	case FATAL:
+		return logrus.FatalLevel
+	case PANIC, gommonLog.OFF:
+		// logrus can't be turned off, panics are the closest to nothing
+		return logrus.PanicLevel
 	}
 	return logrus.InfoLevel
 }
@@ -241,6 +491,8 @@ func toLogrusLevel(level gommonLog.Lvl) logrus.Level {
 // To Echo.gommonLog.lvl
 func toEchoLevel(level logrus.Level) gommonLog.Lvl {
 	switch level {
+	case logrus.TraceLevel:
+		return TRACE
 	case logrus.DebugLevel:
 		return gommonLog.DEBUG
 	case logrus.InfoLevel:
@@ -249,6 +501,10 @@ func toEchoLevel(level logrus.Level) gommonLog.Lvl {
 		return gommonLog.WARN
 	case logrus.ErrorLevel:
 		return gommonLog.ERROR
+	case logrus.FatalLevel:
+		return FATAL
+	case logrus.PanicLevel:
+		return PANIC
 	}
 	return gommonLog.OFF
 }
@@ -0,0 +1,161 @@
+package logs_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/rohanchauhan02/common/logs/logstest"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestLogger returns a logger independent of the shared one, writing its
+// entries as JSON to the returned buffer
+func newTestLogger(prefix ...string) (*logs.CommonLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf, prefix...)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+	return q, buf
+}
+
+// captureShared writes the entries of the shared logger as JSON to the
+// returned buffer until the test ends, its output, formatter and level being
+// restored then
+func captureShared(t testing.TB, prefix ...string) (*logs.CommonLogger, *bytes.Buffer) {
+	t.Helper()
+	q := logs.NewCommonLog(prefix...)
+	out, formatter, level := q.Output(), q.Formatter(), q.Level()
+	t.Cleanup(func() {
+		q.SetOutput(out)
+		logs.WithFormatter(formatter)(q)
+		q.SetLevel(level)
+	})
+
+	buf := &bytes.Buffer{}
+	q.SetOutput(buf)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+	return q, buf
+}
+
+// recordSentry records the events reported until the test ends, with fresh
+// Sentry limits so the events of the previous tests don't count
+func recordSentry(t testing.TB) *logstest.SentryRecorder {
+	t.Helper()
+	logs.ResetSentryLimits()
+	rec, restore := logstest.RecordSentry()
+	t.Cleanup(func() {
+		rec.Events()
+		restore()
+	})
+	return rec
+}
+
+// decodeEntries returns the JSON entries written to buf
+func decodeEntries(t testing.TB, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid entry %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// lastEntry returns the last JSON entry written to buf
+func lastEntry(t testing.TB, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	entries := decodeEntries(t, buf)
+	if len(entries) == 0 {
+		t.Fatal("nothing logged")
+	}
+	return entries[len(entries)-1]
+}
+
+func TestPrefixesPerLogger(t *testing.T) {
+	payments, buf := captureShared(t, "payments")
+	ledger := logs.NewCommonLog("ledger")
+
+	var wg sync.WaitGroup
+	for _, q := range []*logs.CommonLogger{payments, ledger} {
+		wg.Add(1)
+		go func(q *logs.CommonLogger) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				q.Infof("from %s", q.Prefix())
+			}
+		}(q)
+	}
+	wg.Wait()
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 400 {
+		t.Fatalf("%d entries, want 400", len(entries))
+	}
+	for _, e := range entries {
+		if e["msg"] != "from "+e["prefix"].(string) {
+			t.Fatalf("entry of another logger: %v", e)
+		}
+	}
+
+	ledger.SetPrefix("ledger-v2")
+	if payments.Prefix() != "payments" || ledger.Prefix() != "ledger-v2" {
+		t.Fatalf("prefixes = %q, %q", payments.Prefix(), ledger.Prefix())
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	q, first := newTestLogger()
+	q.Info("to the first buffer")
+
+	second := &bytes.Buffer{}
+	q.SetOutput(second)
+	if q.Output() != second {
+		t.Fatal("Output does not return the writer set")
+	}
+	q.Info("to the second buffer")
+	if entry := lastEntry(t, second); entry["msg"] != "to the second buffer" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if n := len(decodeEntries(t, first)); n != 1 {
+		t.Fatalf("first buffer got %d entries after SetOutput, want 1", n)
+	}
+}
+
+func TestAddOutput(t *testing.T) {
+	q, first := newTestLogger()
+	second := &bytes.Buffer{}
+	q.AddOutput(second)
+	q.Info("teed")
+	for _, buf := range []*bytes.Buffer{first, second} {
+		if entry := lastEntry(t, buf); entry["msg"] != "teed" {
+			t.Fatalf("entry = %v", entry)
+		}
+	}
+}
+
+func TestSetOutputWhileLogging(t *testing.T) {
+	q, _ := newTestLogger()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				q.Info("racing")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		q.SetOutput(&bytes.Buffer{})
+		q.AddOutput(&bytes.Buffer{})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,89 @@
+package logstest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// CapturedEntry is an entry recorded by a Capture
+type CapturedEntry struct {
+	Level   logrus.Level
+	Message string
+	// Fields holds every field of the entry, the decorated source, prefix
+	// and requestID included
+	Fields map[string]interface{}
+	Time   time.Time
+}
+
+// Capture is a logrus hook recording the entries it receives
+type Capture struct {
+	mu      sync.Mutex
+	entries []CapturedEntry
+}
+
+// NewTestLogger returns the shared logger, the one every NewCommonLog call
+// writes to, and a Capture recording its entries until the test ends. As the
+// logger is shared, entries logged by tests running in parallel are captured
+// too.
+func NewTestLogger(t testing.TB, prefix ...string) (*logs.CommonLogger, *Capture) {
+	q := logs.NewCommonLog(prefix...)
+	c := &Capture{}
+	q.AddHook(c)
+	t.Cleanup(func() {
+		q.RemoveHook(c)
+	})
+	return q, c
+}
+
+// Levels implements logrus.Hook
+func (c *Capture) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (c *Capture) Fire(e *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(e.Data))
+	for k, v := range e.Data {
+		fields[k] = v
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, CapturedEntry{
+		Level:   e.Level,
+		Message: e.Message,
+		Fields:  fields,
+		Time:    e.Time,
+	})
+	return nil
+}
+
+// Entries returns the entries captured so far
+func (c *Capture) Entries() []CapturedEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]CapturedEntry, len(c.entries))
+	copy(entries, c.entries)
+	return entries
+}
+
+// Contains reports whether an entry at level has a message containing substr
+func (c *Capture) Contains(level logrus.Level, substr string) bool {
+	for _, e := range c.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset drops the captured entries
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
@@ -0,0 +1,60 @@
+package logstest
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// signup stands for the code under test
+func signup(q interface{ Warnf(string, ...interface{}) }, user string) {
+	q.Warnf("signup of %s rejected: weak password", user)
+}
+
+func TestNewTestLogger(t *testing.T) {
+	q, capture := NewTestLogger(t, "signup")
+	out := q.Output()
+	q.SetOutput(io.Discard)
+	defer q.SetOutput(out)
+
+	signup(q.WithRequestID("req-1"), "ada")
+	if !capture.Contains(logrus.WarnLevel, "weak password") {
+		t.Fatalf("entries = %v, want the rejection warning", capture.Entries())
+	}
+	if capture.Contains(logrus.ErrorLevel, "weak password") {
+		t.Fatal("Contains matched another level")
+	}
+	entries := capture.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("captured %d entries, want 1", len(entries))
+	}
+	fields := entries[0].Fields
+	if fields["requestID"] != "req-1" || fields["prefix"] != "signup" {
+		t.Fatalf("fields = %v, want the request ID and prefix", fields)
+	}
+	if fields["source"] == nil {
+		t.Fatalf("fields = %v, want the source", fields)
+	}
+
+	capture.Reset()
+	if len(capture.Entries()) != 0 {
+		t.Fatal("Reset kept entries")
+	}
+}
+
+func TestNewTestLoggerCleanup(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	q, capture := NewTestLogger(tb)
+	out := q.Output()
+	q.SetOutput(io.Discard)
+	defer q.SetOutput(out)
+
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	q.Info("after the test")
+	if len(capture.Entries()) != 0 {
+		t.Fatal("captured after the test completed")
+	}
+}
@@ -0,0 +1,54 @@
+package logstest
+
+import (
+	"sync"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// LevelCounter is a logrus hook tallying the entries logged per level
+type LevelCounter struct {
+	mu     sync.Mutex
+	counts map[logrus.Level]int
+}
+
+// NewLevelCounter returns an empty LevelCounter, to register with AddHook
+func NewLevelCounter() *LevelCounter {
+	return &LevelCounter{counts: make(map[logrus.Level]int)}
+}
+
+// CountLevels registers a new LevelCounter on q, typically a logger built with
+// NewTBLogger, and returns it
+func CountLevels(q *logs.CommonLogger) *LevelCounter {
+	c := NewLevelCounter()
+	q.AddHook(c)
+	return c
+}
+
+// Levels implements logrus.Hook
+func (c *LevelCounter) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (c *LevelCounter) Fire(e *logrus.Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[e.Level]++
+	return nil
+}
+
+// Count returns the number of entries logged at lvl
+func (c *LevelCounter) Count(lvl logrus.Level) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[lvl]
+}
+
+// Reset sets every count back to zero
+func (c *LevelCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = make(map[logrus.Level]int)
+}
@@ -0,0 +1,43 @@
+package logstest
+
+import (
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLevelCounter(t *testing.T) {
+	q := NewTBLogger(t, "levels")
+	q.SetLevel(gommonLog.DEBUG)
+	c := CountLevels(q)
+
+	q.Debug("one")
+	q.Debugf("two")
+	q.Info("one")
+	q.Infow("two", "k", "v")
+	q.Infoj(gommonLog.JSON{"n": 3})
+	q.Warn("only warn")
+	q.Trace("below the level")
+
+	for lvl, want := range map[logrus.Level]int{
+		logrus.DebugLevel: 2,
+		logrus.InfoLevel:  3,
+		logrus.WarnLevel:  1,
+		logrus.ErrorLevel: 0,
+		logrus.TraceLevel: 0,
+	} {
+		if n := c.Count(lvl); n != want {
+			t.Errorf("Count(%s) = %d, want %d", lvl, n, want)
+		}
+	}
+
+	c.Reset()
+	if n := c.Count(logrus.InfoLevel); n != 0 {
+		t.Fatalf("Count after Reset = %d", n)
+	}
+	q.Warnf("after reset")
+	if n := c.Count(logrus.WarnLevel); n != 1 {
+		t.Fatalf("Count(warning) after Reset = %d, want 1", n)
+	}
+}
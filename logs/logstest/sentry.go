@@ -0,0 +1,82 @@
+// Package logstest provides helpers for testing code that uses the logs package
+package logstest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// SentryRecorder is a Sentry transport that keeps every event in memory
+type SentryRecorder struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+// RecordSentry binds a client using a SentryRecorder to the current Sentry
+// hub, in a new scope, so every event captured by the logs package is
+// recorded instead of sent. The returned function restores the previous
+// client and scope.
+func RecordSentry() (*SentryRecorder, func()) {
+	rec := &SentryRecorder{}
+	// Creating a client without DSN never fails
+	client, _ := sentry.NewClient(sentry.ClientOptions{
+		Transport: rec,
+	})
+
+	hub := sentry.CurrentHub()
+	hub.PushScope()
+	hub.BindClient(client)
+
+	return rec, hub.PopScope
+}
+
+// Configure implements sentry.Transport
+func (r *SentryRecorder) Configure(options sentry.ClientOptions) {}
+
+// Flush implements sentry.Transport, events are recorded synchronously
+func (r *SentryRecorder) Flush(timeout time.Duration) bool {
+	return true
+}
+
+// SendEvent implements sentry.Transport
+func (r *SentryRecorder) SendEvent(event *sentry.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// flushTimeout bounds the wait for the events queued by the logs package
+const flushTimeout = time.Second
+
+// Events returns the events recorded so far, once the events queued by the
+// logs package are delivered
+func (r *SentryRecorder) Events() []*sentry.Event {
+	logs.Flush(flushTimeout)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]*sentry.Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Messages returns the message of every event recorded so far, like Events
+func (r *SentryRecorder) Messages() []string {
+	logs.Flush(flushTimeout)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := make([]string, 0, len(r.events))
+	for _, e := range r.events {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+// Reset drops the recorded events
+func (r *SentryRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
@@ -0,0 +1,40 @@
+package logstest
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestRecordSentry(t *testing.T) {
+	rec, restore := RecordSentry()
+	q := logs.NewCommonLogWithOutput(io.Discard)
+
+	q.Errorf("recorded: %v", errors.New("boom"))
+	events := rec.Events()
+	if len(events) != 1 {
+		t.Fatalf("%d events, want 1", len(events))
+	}
+	if events[0].Level != sentry.LevelError {
+		t.Fatalf("level = %v, want error", events[0].Level)
+	}
+	if len(events[0].Exception) == 0 || events[0].Exception[len(events[0].Exception)-1].Value != "boom" {
+		t.Fatalf("exception = %+v, want the boom error", events[0].Exception)
+	}
+
+	rec.Reset()
+	q.Errorf("message only")
+	if messages := rec.Messages(); len(messages) != 1 || messages[0] != "message only" {
+		t.Fatalf("messages = %q", messages)
+	}
+
+	restore()
+	rec.Reset()
+	q.Errorf("after restore")
+	if events := rec.Events(); len(events) != 0 {
+		t.Fatalf("%d events recorded after restore", len(events))
+	}
+}
@@ -0,0 +1,41 @@
+package logstest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// NewTBLogger returns a logger writing every entry through t.Log, so the
+// output is attributed to the test and only shown when it fails or runs
+// verbosely. Entries logged after the test completed are dropped instead of
+// panicking.
+func NewTBLogger(t testing.TB, prefix ...string) *logs.CommonLogger {
+	w := &tbWriter{t: t}
+	t.Cleanup(w.finish)
+	return logs.NewCommonLogWithOutput(w, prefix...)
+}
+
+// tbWriter forwards writes to t.Log until the test is finished
+type tbWriter struct {
+	mu       sync.Mutex
+	t        testing.TB
+	finished bool
+}
+
+func (w *tbWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.finished {
+		w.t.Log(strings.TrimRight(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w *tbWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+}
@@ -0,0 +1,50 @@
+package logstest
+
+import (
+	"strings"
+	"testing"
+)
+
+// fakeTB records what the logger passes to Log and the cleanups it registers
+type fakeTB struct {
+	testing.TB
+	lines    []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Log(args ...interface{}) {
+	for _, arg := range args {
+		f.lines = append(f.lines, arg.(string))
+	}
+}
+
+func (f *fakeTB) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func TestNewTBLogger(t *testing.T) {
+	// Shown with -v, attributed to this test
+	NewTBLogger(t, "tb").Info("logged through t.Log")
+
+	tb := &fakeTB{TB: t}
+	q := NewTBLogger(tb, "tb")
+	q.Infof("first %d", 1)
+	q.Warn("second")
+	if len(tb.lines) != 2 {
+		t.Fatalf("t.Log got %q, want 2 lines", tb.lines)
+	}
+	if !strings.Contains(tb.lines[0], "first 1") || !strings.Contains(tb.lines[1], "second") {
+		t.Fatalf("t.Log got %q", tb.lines)
+	}
+	if strings.HasSuffix(tb.lines[0], "\n") {
+		t.Fatalf("line %q keeps its newline", tb.lines[0])
+	}
+
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	q.Info("after the test")
+	if len(tb.lines) != 2 {
+		t.Fatalf("logged after the test completed: %q", tb.lines[2:])
+	}
+}
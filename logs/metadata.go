@@ -0,0 +1,42 @@
+package logs
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IncludeProcessMetadata adds hostname and pid fields to every entry.
+// The hostname is resolved once, when the option is applied.
+func IncludeProcessMetadata() Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		q.addHook(&processHook{
+			hostname: hostname,
+			pid:      os.Getpid(),
+		})
+		return nil
+	}
+}
+
+// processHook stamps entries with the process metadata
+type processHook struct {
+	hostname string
+	pid      int
+}
+
+func (h *processHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *processHook) Fire(e *logrus.Entry) error {
+	e.Data["hostname"] = h.hostname
+	e.Data["pid"] = h.pid
+	return nil
+}
@@ -0,0 +1,77 @@
+package logs_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestIncludeProcessMetadata(t *testing.T) {
+	q, buf := newTestLogger()
+	if err := logs.IncludeProcessMetadata()(q); err != nil {
+		t.Fatal(err)
+	}
+	q.Info("hello")
+
+	hostname, _ := os.Hostname()
+	e := lastEntry(t, buf)
+	if e["hostname"] != hostname {
+		t.Errorf("hostname = %v, want %q", e["hostname"], hostname)
+	}
+	if e["pid"] != float64(os.Getpid()) {
+		t.Errorf("pid = %v, want %d", e["pid"], os.Getpid())
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	defer logs.SetGlobalFields(nil)
+	sentry := recordSentry(t)
+	logs.SetGlobalFields(map[string]interface{}{"service": "payments", "region": "eu"})
+
+	q, buf := newTestLogger("billing")
+	q.Info("global")
+	e := lastEntry(t, buf)
+	if e["service"] != "payments" || e["region"] != "eu" || e["prefix"] != "billing" {
+		t.Fatalf("entry = %v, want the global fields", e)
+	}
+
+	q.WithField("region", "us").Info("logger field")
+	if e := lastEntry(t, buf); e["region"] != "us" || e["service"] != "payments" {
+		t.Fatalf("entry = %v, want the logger field to win", e)
+	}
+	q.Infow("call field", "service", "refunds")
+	if e := lastEntry(t, buf); e["service"] != "refunds" {
+		t.Fatalf("entry = %v, want the entry field to win", e)
+	}
+
+	q.Error(errors.New("boom"))
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if tags := events[0].Tags; tags["service"] != "payments" || tags["region"] != "eu" {
+		t.Fatalf("Sentry tags = %v, want the global fields", tags)
+	}
+}
+
+func TestWithServiceMetadata(t *testing.T) {
+	defer logs.SetGlobalFields(nil)
+	q, _ := newTestLogger()
+	if err := logs.WithServiceMetadata("payments", "production", "")(q); err != nil {
+		t.Fatal(err)
+	}
+	// Applied to every logger, not only the configured one
+	child, buf := newTestLogger("child")
+	child.Info("hello")
+
+	hostname, _ := os.Hostname()
+	e := lastEntry(t, buf)
+	if e["service"] != "payments" || e["env"] != "production" || e["host"] != hostname {
+		t.Fatalf("entry = %v, want the service metadata", e)
+	}
+	if _, ok := e["version"]; ok {
+		t.Fatalf("entry = %v, empty version logged", e)
+	}
+}
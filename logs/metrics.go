@@ -0,0 +1,184 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levelCounters counts the entries of a prefix, indexed by logrus level
+type levelCounters [logrus.TraceLevel + 1]atomic.Int64
+
+var (
+	metricsOnce sync.Once
+	// metricsEnabled is set by WithMetrics
+	metricsEnabled atomic.Bool
+
+	entryCountersMu sync.RWMutex
+	entryCounters   = map[string]*levelCounters{}
+	sampledCounters = map[string]*levelCounters{}
+
+	sentrySent    atomic.Int64
+	sentryDropped atomic.Int64
+)
+
+// EntryCount is the number of entries logged at a level with a prefix
+type EntryCount struct {
+	Level  string
+	Prefix string
+	Count  int64
+}
+
+// MetricsSnapshot holds the counters of the logs package
+type MetricsSnapshot struct {
+	// Entries are the entries logged per level and prefix, counted once
+	// WithMetrics is applied
+	Entries []EntryCount
+	// Sampled are the entries sampled away per level and prefix, see
+	// SetSampling
+	Sampled []EntryCount
+	// SentrySent and SentryDropped are the events sent to Sentry and the
+	// ones rate limited or dropped by the client
+	SentrySent    int64
+	SentryDropped int64
+	// ReportsDropped is DroppedReports
+	ReportsDropped int64
+}
+
+// WithMetrics counts the entries logged per level and prefix, see Metrics.
+// Counting an entry takes a read lock and an atomic increment.
+func WithMetrics() Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		metricsOnce.Do(func() {
+			metricsEnabled.Store(true)
+			q.addHook(&metricsHook{})
+		})
+		return nil
+	}
+}
+
+// Metrics returns the current value of the counters, sorted by prefix then
+// level
+func Metrics() MetricsSnapshot {
+	snapshot := MetricsSnapshot{
+		SentrySent:     sentrySent.Load(),
+		SentryDropped:  sentryDropped.Load(),
+		ReportsDropped: DroppedReports(),
+	}
+	entryCountersMu.RLock()
+	snapshot.Entries = entryCounts(entryCounters)
+	snapshot.Sampled = entryCounts(sampledCounters)
+	entryCountersMu.RUnlock()
+	return snapshot
+}
+
+// entryCounts returns the non zero counters of counters, sorted by prefix
+// then level, entryCountersMu must be held
+func entryCounts(counters map[string]*levelCounters) []EntryCount {
+	var counts []EntryCount
+	for prefix, c := range counters {
+		for i := range c {
+			if n := c[i].Load(); n > 0 {
+				counts = append(counts, EntryCount{
+					Level:  logrus.Level(i).String(),
+					Prefix: prefix,
+					Count:  n,
+				})
+			}
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		if a.Prefix != b.Prefix {
+			return a.Prefix < b.Prefix
+		}
+		return a.Level < b.Level
+	})
+	return counts
+}
+
+// MetricsHandler serves Metrics in the Prometheus text format, for the
+// services scraped by Prometheus:
+//
+//	common_log_entries_total{level="error",prefix="billing"} 3
+//	common_log_sentry_events_total{outcome="sent"} 2
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		WritePrometheus(w, Metrics())
+	})
+}
+
+// WritePrometheus writes m in the Prometheus text format
+func WritePrometheus(w io.Writer, m MetricsSnapshot) error {
+	var b strings.Builder
+	b.WriteString("# HELP common_log_entries_total Log entries by level and prefix.\n")
+	b.WriteString("# TYPE common_log_entries_total counter\n")
+	for _, e := range m.Entries {
+		fmt.Fprintf(&b, "common_log_entries_total{level=%q,prefix=%q} %d\n", e.Level, e.Prefix, e.Count)
+	}
+	b.WriteString("# HELP common_log_entries_sampled_total Log entries sampled away by level and prefix.\n")
+	b.WriteString("# TYPE common_log_entries_sampled_total counter\n")
+	for _, e := range m.Sampled {
+		fmt.Fprintf(&b, "common_log_entries_sampled_total{level=%q,prefix=%q} %d\n", e.Level, e.Prefix, e.Count)
+	}
+	b.WriteString("# HELP common_log_sentry_events_total Events sent to or dropped before Sentry.\n")
+	b.WriteString("# TYPE common_log_sentry_events_total counter\n")
+	fmt.Fprintf(&b, "common_log_sentry_events_total{outcome=\"sent\"} %d\n", m.SentrySent)
+	fmt.Fprintf(&b, "common_log_sentry_events_total{outcome=\"dropped\"} %d\n", m.SentryDropped)
+	b.WriteString("# HELP common_log_reports_dropped_total Error reports dropped by the full report queue.\n")
+	b.WriteString("# TYPE common_log_reports_dropped_total counter\n")
+	fmt.Fprintf(&b, "common_log_reports_dropped_total %d\n", m.ReportsDropped)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// prefixCounters returns the counters of prefix in m, created on its first
+// entry
+func prefixCounters(m map[string]*levelCounters, prefix string) *levelCounters {
+	entryCountersMu.RLock()
+	counters, ok := m[prefix]
+	entryCountersMu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	entryCountersMu.Lock()
+	defer entryCountersMu.Unlock()
+	if counters, ok = m[prefix]; !ok {
+		counters = &levelCounters{}
+		m[prefix] = counters
+	}
+	return counters
+}
+
+// countSampled counts an entry sampled away once WithMetrics is applied
+func countSampled(prefix string, level logrus.Level) {
+	if metricsEnabled.Load() && level <= logrus.TraceLevel {
+		prefixCounters(sampledCounters, prefix)[level].Add(1)
+	}
+}
+
+// metricsHook counts the entries, see WithMetrics
+type metricsHook struct{}
+
+func (h *metricsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *metricsHook) Fire(e *logrus.Entry) error {
+	prefix, _ := e.Data["prefix"].(string)
+	if e.Level <= logrus.TraceLevel {
+		prefixCounters(entryCounters, prefix)[e.Level].Add(1)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package logs_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// entryCount returns the entries counted for level and prefix
+func entryCount(level, prefix string) int64 {
+	for _, e := range logs.Metrics().Entries {
+		if e.Level == level && e.Prefix == prefix {
+			return e.Count
+		}
+	}
+	return 0
+}
+
+func TestMetricsEntries(t *testing.T) {
+	billing, _ := captureShared(t, "metrics-billing")
+	logs.WithMetrics()(billing)
+	ledger := logs.NewCommonLog("metrics-ledger")
+
+	before := map[string]int64{}
+	for _, level := range []string{"debug", "info", "warning", "error"} {
+		before[level] = entryCount(level, "metrics-billing")
+	}
+	ledgerBefore := entryCount("info", "metrics-ledger")
+	billing.Debug("filtered by the level")
+	for i := 0; i < 3; i++ {
+		billing.Info("charged")
+	}
+	billing.Warn("slow")
+	billing.Warnf("slow %d", 2)
+	billing.Errorf("failed")
+	ledger.Info("posted")
+
+	for level, want := range map[string]int64{"debug": 0, "info": 3, "warning": 2, "error": 1} {
+		if got := entryCount(level, "metrics-billing") - before[level]; got != want {
+			t.Errorf("%s entries counted %d, want %d", level, got, want)
+		}
+	}
+	if got := entryCount("info", "metrics-ledger") - ledgerBefore; got != 1 {
+		t.Errorf("info entries of the other prefix counted %d, want 1", got)
+	}
+
+	want := fmt.Sprintf("common_log_entries_total{level=\"error\",prefix=\"metrics-billing\"} %d\n", entryCount("error", "metrics-billing"))
+	rec := httptest.NewRecorder()
+	logs.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, want) {
+		t.Fatalf("metrics output missing %q:\n%s", want, body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+}
+
+func TestMetricsSentryEvents(t *testing.T) {
+	// Registered first to run after the recorder delivered the queue
+	t.Cleanup(logs.ResetSentryLimits)
+	rec := recordSentry(t)
+	logs.SetSentryLimits(logs.SentryLimits{PerFingerprint: 1, Window: time.Minute})
+
+	before := logs.Metrics()
+	q, _ := newTestLogger()
+	for i := 0; i < 3; i++ {
+		q.Error(errors.New("duplicate"))
+	}
+	if events := rec.Events(); len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	after := logs.Metrics()
+	if sent := after.SentrySent - before.SentrySent; sent != 1 {
+		t.Errorf("sent events counted %d, want 1", sent)
+	}
+	if dropped := after.SentryDropped - before.SentryDropped; dropped != 2 {
+		t.Errorf("dropped events counted %d, want 2", dropped)
+	}
+
+	var b strings.Builder
+	if err := logs.WritePrometheus(&b, after); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		fmt.Sprintf("common_log_sentry_events_total{outcome=\"sent\"} %d\n", after.SentrySent),
+		fmt.Sprintf("common_log_sentry_events_total{outcome=\"dropped\"} %d\n", after.SentryDropped),
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("metrics output missing %q:\n%s", line, b.String())
+		}
+	}
+}
+
+func BenchmarkMetricsHook(b *testing.B) {
+	hook := logs.NewMetricsHook()
+	e := logrus.NewEntry(logrus.New()).WithField("prefix", "billing")
+	e.Level = logrus.InfoLevel
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			hook.Fire(e)
+		}
+	})
+	if perOp := b.Elapsed() / time.Duration(b.N); b.N > 1000 && perOp > 200*time.Nanosecond {
+		b.Errorf("counting an entry took %v, want under 200ns", perOp)
+	}
+}
@@ -0,0 +1,301 @@
+// Package mocks provides test doubles of the logs package interfaces
+package mocks
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// Call is a method call recorded by Logger
+type Call struct {
+	// Method is the name of the method called, e.g. "Errorf"
+	Method string
+	// Args are the arguments of the call, the format string first for the
+	// formatting methods
+	Args []interface{}
+	// Message is the message the call would have logged
+	Message string
+}
+
+// Logger is a logs.Logger recording its calls instead of logging them. The
+// Fatal methods return and the Panic ones panic with the message, once
+// recorded. The zero value is ready to use.
+type Logger struct {
+	mu     sync.Mutex
+	calls  []Call
+	out    io.Writer
+	prefix string
+	level  gommonLog.Lvl
+	header string
+}
+
+var _ logs.Logger = (*Logger)(nil)
+
+// NewLogger returns an empty Logger
+func NewLogger() *Logger {
+	return &Logger{}
+}
+
+// Calls returns the calls to method recorded so far, all of them when
+// method is empty
+func (m *Logger) Calls(method string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []Call
+	for _, c := range m.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Count returns the number of calls to method whose message contains substr
+func (m *Logger) Count(method, substr string) int {
+	n := 0
+	for _, c := range m.Calls(method) {
+		if strings.Contains(c.Message, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset drops the recorded calls
+func (m *Logger) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *Logger) record(method, message string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args, Message: message})
+}
+
+func (m *Logger) print(method string, i []interface{}) {
+	m.record(method, fmt.Sprint(i...), i...)
+}
+
+func (m *Logger) printf(method, format string, args []interface{}) {
+	m.record(method, fmt.Sprintf(format, args...), append([]interface{}{format}, args...)...)
+}
+
+func (m *Logger) printj(method string, j gommonLog.JSON) {
+	m.record(method, fmt.Sprint(j), j)
+}
+
+func (m *Logger) printw(method, msg string, keysAndValues []interface{}) {
+	m.record(method, msg, append([]interface{}{msg}, keysAndValues...)...)
+}
+
+// Output implements logs.Logger
+func (m *Logger) Output() io.Writer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.out
+}
+
+// SetOutput implements logs.Logger
+func (m *Logger) SetOutput(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.out = w
+}
+
+// Prefix implements logs.Logger
+func (m *Logger) Prefix() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prefix
+}
+
+// SetPrefix implements logs.Logger
+func (m *Logger) SetPrefix(p string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prefix = p
+}
+
+// Level implements logs.Logger
+func (m *Logger) Level() gommonLog.Lvl {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.level
+}
+
+// SetLevel implements logs.Logger
+func (m *Logger) SetLevel(v gommonLog.Lvl) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.level = v
+}
+
+// SetHeader implements logs.Logger
+func (m *Logger) SetHeader(h string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.header = h
+}
+
+// Print implements logs.Logger
+func (m *Logger) Print(i ...interface{}) {
+	m.print("Print", i)
+}
+
+// Printf implements logs.Logger
+func (m *Logger) Printf(format string, args ...interface{}) {
+	m.printf("Printf", format, args)
+}
+
+// Printj implements logs.Logger
+func (m *Logger) Printj(j gommonLog.JSON) {
+	m.printj("Printj", j)
+}
+
+// Trace implements logs.Logger
+func (m *Logger) Trace(i ...interface{}) {
+	m.print("Trace", i)
+}
+
+// Tracef implements logs.Logger
+func (m *Logger) Tracef(format string, args ...interface{}) {
+	m.printf("Tracef", format, args)
+}
+
+// Tracej implements logs.Logger
+func (m *Logger) Tracej(j gommonLog.JSON) {
+	m.printj("Tracej", j)
+}
+
+// Debug implements logs.Logger
+func (m *Logger) Debug(i ...interface{}) {
+	m.print("Debug", i)
+}
+
+// Debugf implements logs.Logger
+func (m *Logger) Debugf(format string, args ...interface{}) {
+	m.printf("Debugf", format, args)
+}
+
+// Debugj implements logs.Logger
+func (m *Logger) Debugj(j gommonLog.JSON) {
+	m.printj("Debugj", j)
+}
+
+// Info implements logs.Logger
+func (m *Logger) Info(i ...interface{}) {
+	m.print("Info", i)
+}
+
+// Infof implements logs.Logger
+func (m *Logger) Infof(format string, args ...interface{}) {
+	m.printf("Infof", format, args)
+}
+
+// Infoj implements logs.Logger
+func (m *Logger) Infoj(j gommonLog.JSON) {
+	m.printj("Infoj", j)
+}
+
+// Warn implements logs.Logger
+func (m *Logger) Warn(i ...interface{}) {
+	m.print("Warn", i)
+}
+
+// Warnf implements logs.Logger
+func (m *Logger) Warnf(format string, args ...interface{}) {
+	m.printf("Warnf", format, args)
+}
+
+// Warnj implements logs.Logger
+func (m *Logger) Warnj(j gommonLog.JSON) {
+	m.printj("Warnj", j)
+}
+
+// Error implements logs.Logger
+func (m *Logger) Error(i ...interface{}) {
+	m.print("Error", i)
+}
+
+// Errorf implements logs.Logger
+func (m *Logger) Errorf(format string, args ...interface{}) {
+	m.printf("Errorf", format, args)
+}
+
+// Errorj implements logs.Logger
+func (m *Logger) Errorj(j gommonLog.JSON) {
+	m.printj("Errorj", j)
+}
+
+// Fatal implements logs.Logger, without exiting
+func (m *Logger) Fatal(i ...interface{}) {
+	m.print("Fatal", i)
+}
+
+// Fatalj implements logs.Logger, without exiting
+func (m *Logger) Fatalj(j gommonLog.JSON) {
+	m.printj("Fatalj", j)
+}
+
+// Fatalf implements logs.Logger, without exiting
+func (m *Logger) Fatalf(format string, args ...interface{}) {
+	m.printf("Fatalf", format, args)
+}
+
+// Panic implements logs.Logger
+func (m *Logger) Panic(i ...interface{}) {
+	m.print("Panic", i)
+	panic(fmt.Sprint(i...))
+}
+
+// Panicj implements logs.Logger
+func (m *Logger) Panicj(j gommonLog.JSON) {
+	m.printj("Panicj", j)
+	panic(fmt.Sprint(j))
+}
+
+// Panicf implements logs.Logger
+func (m *Logger) Panicf(format string, args ...interface{}) {
+	m.printf("Panicf", format, args)
+	panic(fmt.Sprintf(format, args...))
+}
+
+// Debugw implements logs.Logger
+func (m *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	m.printw("Debugw", msg, keysAndValues)
+}
+
+// Infow implements logs.Logger
+func (m *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	m.printw("Infow", msg, keysAndValues)
+}
+
+// Warnw implements logs.Logger
+func (m *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	m.printw("Warnw", msg, keysAndValues)
+}
+
+// Errorw implements logs.Logger
+func (m *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	m.printw("Errorw", msg, keysAndValues)
+}
+
+// Fatalw implements logs.Logger, without exiting
+func (m *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	m.printw("Fatalw", msg, keysAndValues)
+}
+
+// StartTimer implements logs.Logger. The call is recorded when the returned
+// function is, with the operation as message and the error as last argument.
+func (m *Logger) StartTimer(operation string, keysAndValues ...interface{}) func(err error) {
+	return func(err error) {
+		m.record("StartTimer", operation, append(append([]interface{}{operation}, keysAndValues...), err)...)
+	}
+}
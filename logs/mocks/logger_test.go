@@ -0,0 +1,100 @@
+package mocks
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// fetch stands for the code under test, depending on the interface
+func fetch(l logs.Logger, err error) {
+	if err != nil {
+		l.Errorf("fetch failed: %v", err)
+		return
+	}
+	l.Infow("fetched", "items", 3)
+}
+
+func TestLoggerRecordsCalls(t *testing.T) {
+	m := NewLogger()
+	fetch(m, errors.New("i/o timeout"))
+	fetch(m, nil)
+
+	if n := m.Count("Errorf", "timeout"); n != 1 {
+		t.Fatalf("Errorf called %d times with timeout, want 1", n)
+	}
+	calls := m.Calls("Errorf")
+	if len(calls) != 1 || calls[0].Args[0] != "fetch failed: %v" || calls[0].Message != "fetch failed: i/o timeout" {
+		t.Fatalf("calls = %+v", calls)
+	}
+	infow := m.Calls("Infow")
+	if len(infow) != 1 || fmt.Sprint(infow[0].Args) != "[fetched items 3]" {
+		t.Fatalf("Infow calls = %+v", infow)
+	}
+	if n := len(m.Calls("")); n != 2 {
+		t.Fatalf("recorded %d calls, want 2", n)
+	}
+
+	m.Reset()
+	if len(m.Calls("")) != 0 {
+		t.Fatal("Reset kept calls")
+	}
+}
+
+func TestLoggerTerminating(t *testing.T) {
+	var m Logger
+	m.Fatalf("fatal %d", 1)
+	if m.Count("Fatalf", "fatal 1") != 1 {
+		t.Fatal("Fatalf not recorded")
+	}
+
+	defer func() {
+		if r := recover(); r != "corrupted 2" {
+			t.Fatalf("recovered %v, want the message", r)
+		}
+		if m.Count("Panicf", "corrupted") != 1 {
+			t.Fatal("Panicf not recorded before panicking")
+		}
+	}()
+	m.Panicf("corrupted %d", 2)
+}
+
+func TestLoggerSettings(t *testing.T) {
+	m := NewLogger()
+	m.SetLevel(gommonLog.WARN)
+	m.SetPrefix("billing")
+	if m.Level() != gommonLog.WARN || m.Prefix() != "billing" {
+		t.Fatalf("level %v prefix %q", m.Level(), m.Prefix())
+	}
+
+	done := m.StartTimer("charge", "order", "o-1")
+	if len(m.Calls("StartTimer")) != 0 {
+		t.Fatal("StartTimer recorded before the operation ended")
+	}
+	err := errors.New("declined")
+	done(err)
+	calls := m.Calls("StartTimer")
+	if len(calls) != 1 || calls[0].Message != "charge" || calls[0].Args[len(calls[0].Args)-1] != err {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
+
+func TestLoggerConcurrent(t *testing.T) {
+	m := NewLogger()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Warnf("retry %d", i)
+		}(i)
+	}
+	wg.Wait()
+	if n := m.Count("Warnf", "retry"); n != 50 {
+		t.Fatalf("recorded %d calls, want 50", n)
+	}
+}
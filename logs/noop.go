@@ -0,0 +1,20 @@
+package logs
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewNoopLogger returns a logger that discards every entry and never reports
+// to Sentry or the other error destinations, for libraries that embed this
+// package and must stay silent. Fatal still exits and Panic still panics.
+func NewNoopLogger() *CommonLogger {
+	l := logrus.New()
+	l.Out = io.Discard
+	l.Level = logrus.PanicLevel
+	return &CommonLogger{
+		logger:   l,
+		noReport: true,
+	}
+}
@@ -0,0 +1,66 @@
+package logs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestNoopLogger(t *testing.T) {
+	defer logs.SaveErrorDestinations()()
+	sentry := recordSentry(t)
+	rec := &eventRecorder{}
+	logs.AddErrorDestination("recorder", rec)
+
+	q := logs.NewNoopLogger()
+	buf := &bytes.Buffer{}
+	q.SetOutput(buf)
+
+	err := errors.New("boom")
+	j := gommonLog.JSON{"k": "v"}
+	q.Print("print")
+	q.Printf("%s", "print")
+	q.Printj(j)
+	q.Trace("trace")
+	q.Tracef("%s", "trace")
+	q.Tracej(j)
+	q.Debug("debug")
+	q.Debugf("%s", "debug")
+	q.Debugj(j)
+	q.Debugw("debug", "k", "v")
+	q.Info("info")
+	q.Infof("%s", "info")
+	q.Infoj(j)
+	q.Infow("info", "k", "v")
+	q.Warn("warn")
+	q.Warnf("%s", "warn")
+	q.Warnj(j)
+	q.Warnw("warn", "k", "v")
+	q.Error(err)
+	q.Errorf("failed: %v", err)
+	q.Errorj(j)
+	q.Errorw("failed", "error", err)
+	q.ErrorCode("E1", err)
+	q.ErrorCodef("E1", "failed: %v", err)
+	q.LogMap(gommonLog.ERROR, map[string]interface{}{"k": "v"})
+	q.Deprecatedf("old", "%s is deprecated", "old")
+	q.StartTimer("op")(err)
+	q.WithError(err).Error("failed")
+	q.WithField("k", "v").Errorf("failed")
+	q.WithRequestID("req-1").Error(err)
+	q.WithContext(context.Background()).Error(err)
+
+	if buf.Len() != 0 {
+		t.Fatalf("noop logger wrote %q", buf.String())
+	}
+	if events := sentry.Events(); len(events) != 0 {
+		t.Fatalf("noop logger reported %d events to Sentry", len(events))
+	}
+	if events := rec.Events(); len(events) != 0 {
+		t.Fatalf("noop logger sent %d error events", len(events))
+	}
+}
@@ -0,0 +1,74 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures a CommonLogger built by NewCommonLogE. NewCommonLogE
+// first calls every option on a scratch logger to validate them, an option
+// must then leave the package state untouched.
+type Option func(q *CommonLogger) error
+
+// WithPrefix sets the prefix field of the logger
+func WithPrefix(prefix string) Option {
+	return func(q *CommonLogger) error {
+		q.prefix = prefix
+		return nil
+	}
+}
+
+// WithLevel sets the minimum level that will be logged
+func WithLevel(level gommonLog.Lvl) Option {
+	return func(q *CommonLogger) error {
+		if _, ok := levelNames[level]; !ok && level != gommonLog.OFF {
+			return fmt.Errorf("logs: invalid level %d", level)
+		}
+		if q.dryRun {
+			return nil
+		}
+		q.SetLevel(level)
+		return nil
+	}
+}
+
+// WithFormatter replaces the formatter used to render log entries
+func WithFormatter(formatter logrus.Formatter) Option {
+	return func(q *CommonLogger) error {
+		if formatter == nil {
+			return errors.New("logs: formatter must not be nil")
+		}
+		q.setFormatter(formatter)
+		return nil
+	}
+}
+
+// NewCommonLogE is like NewCommonLog but applies the given options and returns
+// the first configuration error instead of silently ignoring it. Every option
+// is validated before any is applied, so an invalid one changes nothing. Only
+// opening a file or a connection can still fail once the options before it
+// are applied.
+func NewCommonLogE(opts ...Option) (*CommonLogger, error) {
+	q := NewCommonLog()
+
+	scratch := logrus.New()
+	scratch.SetOutput(io.Discard)
+	scratch.SetFormatter(q.logger.Formatter)
+	dryRun := &CommonLogger{logger: scratch, dryRun: true}
+	for _, opt := range opts {
+		if err := opt(dryRun); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, opt := range opts {
+		if err := opt(q); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,82 @@
+package logs_test
+
+import (
+	"strings"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestNewCommonLogEInvalidOption(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  logs.Option
+	}{
+		{"level", logs.WithLevel(gommonLog.Lvl(42))},
+		{"formatter", logs.WithFormatter(nil)},
+		{"format", logs.WithFormat("xml", "", "")},
+		{"color", logs.WithColor("sometimes")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := logs.NewCommonLogE(tt.opt)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if q != nil {
+				t.Fatal("logger returned along with the error")
+			}
+			if !strings.HasPrefix(err.Error(), "logs: ") {
+				t.Fatalf("error %q lacks the package prefix", err)
+			}
+		})
+	}
+}
+
+func TestNewCommonLogEAppliesNothingOnError(t *testing.T) {
+	shared, _ := captureShared(t)
+	shared.SetLevel(gommonLog.INFO)
+	formatter := shared.Formatter()
+
+	_, err := logs.NewCommonLogE(
+		logs.WithLevel(gommonLog.DEBUG),
+		logs.WithFormat(logs.FormatText, "", ""),
+		logs.WithFormatter(nil),
+	)
+	if err == nil {
+		t.Fatal("no error")
+	}
+	if got := shared.Level(); got != gommonLog.INFO {
+		t.Fatalf("level = %v, changed by the options before the invalid one", got)
+	}
+	if shared.Formatter() != formatter {
+		t.Fatal("formatter changed by the options before the invalid one")
+	}
+}
+
+func TestNewCommonLogE(t *testing.T) {
+	shared, _ := captureShared(t)
+
+	q, err := logs.NewCommonLogE(logs.WithPrefix("billing"), logs.WithLevel(gommonLog.WARN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Prefix() != "billing" {
+		t.Fatalf("prefix = %q", q.Prefix())
+	}
+	if shared.Level() != gommonLog.WARN {
+		t.Fatalf("level = %v, want WARN", shared.Level())
+	}
+}
+
+func TestNewCommonLogNeverPanics(t *testing.T) {
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("logs.NewCommonLog panicked: %v", p)
+		}
+	}()
+	if logs.NewCommonLog() == nil {
+		t.Fatal("nil logger")
+	}
+}
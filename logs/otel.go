@@ -0,0 +1,111 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OTel severity numbers from the OpenTelemetry log data model
+const (
+	otelSeverityTrace = 1
+	otelSeverityDebug = 5
+	otelSeverityInfo  = 9
+	otelSeverityWarn  = 13
+	otelSeverityError = 17
+	otelSeverityFatal = 21
+	otelSeverityPanic = 24
+)
+
+// OTelRecord is a log entry mapped to the OpenTelemetry log data model
+type OTelRecord struct {
+	Timestamp         time.Time
+	ObservedTimestamp time.Time
+	SeverityNumber    int
+	SeverityText      string
+	Body              string
+	// Attributes holds every field of the entry except the trace context
+	Attributes map[string]interface{}
+	// TraceID and SpanID are taken from the trace_id and span_id fields,
+	// empty when the entry has no trace context
+	TraceID string
+	SpanID  string
+}
+
+// OTelEmitter emits records through OpenTelemetry. It is meant to be a thin
+// adapter around a log.Logger obtained from the configured log.LoggerProvider,
+// so this package does not force the OTel SDK on the services that don't use it.
+type OTelEmitter interface {
+	Emit(ctx context.Context, record OTelRecord)
+}
+
+// WithOTelEmitter emits every entry as an OTel log record in addition to the
+// regular log output
+func WithOTelEmitter(emitter OTelEmitter) Option {
+	return func(q *CommonLogger) error {
+		if emitter == nil {
+			return errors.New("logs: OTel emitter must not be nil")
+		}
+		if q.dryRun {
+			return nil
+		}
+		q.addHook(&otelHook{emitter: emitter})
+		return nil
+	}
+}
+
+type otelHook struct {
+	emitter OTelEmitter
+}
+
+func (h *otelHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *otelHook) Fire(e *logrus.Entry) error {
+	record := OTelRecord{
+		Timestamp:         e.Time,
+		ObservedTimestamp: time.Now(),
+		SeverityNumber:    otelSeverity(e.Level),
+		SeverityText:      e.Level.String(),
+		Body:              e.Message,
+		Attributes:        make(map[string]interface{}, len(e.Data)),
+	}
+	for k, v := range e.Data {
+		switch k {
+		case "trace_id":
+			record.TraceID, _ = v.(string)
+		case "span_id":
+			record.SpanID, _ = v.(string)
+		default:
+			record.Attributes[k] = v
+		}
+	}
+
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h.emitter.Emit(ctx, record)
+	return nil
+}
+
+func otelSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.TraceLevel:
+		return otelSeverityTrace
+	case logrus.DebugLevel:
+		return otelSeverityDebug
+	case logrus.InfoLevel:
+		return otelSeverityInfo
+	case logrus.WarnLevel:
+		return otelSeverityWarn
+	case logrus.ErrorLevel:
+		return otelSeverityError
+	case logrus.FatalLevel:
+		return otelSeverityFatal
+	}
+	return otelSeverityPanic
+}
@@ -0,0 +1,102 @@
+package logs_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// memoryEmitter is an in-memory OTel exporter
+type memoryEmitter struct {
+	mu      sync.Mutex
+	records []logs.OTelRecord
+}
+
+func (m *memoryEmitter) Emit(_ context.Context, record logs.OTelRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+}
+
+func TestOTelEmitter(t *testing.T) {
+	emitter := &memoryEmitter{}
+	q, _ := newTestLogger("billing")
+	if err := logs.WithOTelEmitter(emitter)(q); err != nil {
+		t.Fatal(err)
+	}
+	q.SetLevel(logs.TRACE)
+
+	q.Trace("t")
+	q.Debug("d")
+	q.WithField("order", 42).Info("i")
+	q.Warn("w")
+	q.WithoutSentry().Error("e")
+
+	want := []struct {
+		number int
+		text   string
+		body   string
+	}{
+		{1, "trace", "t"},
+		{5, "debug", "d"},
+		{9, "info", "i"},
+		{13, "warning", "w"},
+		{17, "error", "e"},
+	}
+	if len(emitter.records) != len(want) {
+		t.Fatalf("%d records, want %d", len(emitter.records), len(want))
+	}
+	for i, w := range want {
+		r := emitter.records[i]
+		if r.SeverityNumber != w.number || r.SeverityText != w.text || r.Body != w.body {
+			t.Errorf("record %d = %d %q %q, want %d %q %q", i, r.SeverityNumber, r.SeverityText, r.Body, w.number, w.text, w.body)
+		}
+		if r.Attributes["prefix"] != "billing" {
+			t.Errorf("record %d prefix = %v", i, r.Attributes["prefix"])
+		}
+	}
+	if emitter.records[2].Attributes["order"] != 42 {
+		t.Errorf("order = %v, want 42", emitter.records[2].Attributes["order"])
+	}
+}
+
+func TestOTelEmitterTraceContext(t *testing.T) {
+	emitter := &memoryEmitter{}
+	q, _ := newTestLogger()
+	logs.WithOTelEmitter(emitter)(q)
+	logs.SetContextExtractor(func(ctx context.Context) map[string]interface{} {
+		if ctx.Value(traceKey{}) == nil {
+			return nil
+		}
+		return map[string]interface{}{
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+		}
+	})
+	defer logs.SetContextExtractor(nil)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, true)
+	q.WithContext(ctx).Info("traced")
+	q.WithContext(context.Background()).Info("untraced")
+
+	r := emitter.records[0]
+	if r.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || r.SpanID != "00f067aa0ba902b7" {
+		t.Fatalf("trace context = %q %q", r.TraceID, r.SpanID)
+	}
+	if _, ok := r.Attributes["trace_id"]; ok {
+		t.Fatal("trace_id kept among the attributes")
+	}
+	if r := emitter.records[1]; r.TraceID != "" || r.SpanID != "" {
+		t.Fatalf("untraced entry has trace context %q %q", r.TraceID, r.SpanID)
+	}
+}
+
+type traceKey struct{}
+
+func TestOTelEmitterNil(t *testing.T) {
+	if _, err := logs.NewCommonLogE(logs.WithOTelEmitter(nil)); err == nil {
+		t.Fatal("nil emitter accepted")
+	}
+}
@@ -0,0 +1,45 @@
+// Package otelctx correlates log entries with OpenTelemetry traces. It does
+// not import the OpenTelemetry API itself, so the logs package does not
+// force it on the services that don't use it, the span context functions
+// being passed in instead:
+//
+//	logs.SetContextExtractor(otelctx.Extractor[trace.SpanContext, trace.TraceID, trace.SpanID](
+//		trace.SpanContextFromContext,
+//	))
+package otelctx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// Fields set by Extractor, the same as the ones parsed from traceparent
+const (
+	TraceIDField = "trace_id"
+	SpanIDField  = "span_id"
+)
+
+// SpanContext is the method set of trace.SpanContext Extractor uses
+type SpanContext[T, S fmt.Stringer] interface {
+	IsValid() bool
+	TraceID() T
+	SpanID() S
+}
+
+// Extractor returns a logs.ContextExtractor adding the hex trace_id and
+// span_id of the span context returned by fromContext, trace.SpanContextFromContext
+// typically. Both fields are omitted when the span context is invalid.
+func Extractor[C SpanContext[T, S], T, S fmt.Stringer](fromContext func(ctx context.Context) C) logs.ContextExtractor {
+	return func(ctx context.Context) map[string]interface{} {
+		sc := fromContext(ctx)
+		if !sc.IsValid() {
+			return nil
+		}
+		return map[string]interface{}{
+			TraceIDField: sc.TraceID().String(),
+			SpanIDField:  sc.SpanID().String(),
+		}
+	}
+}
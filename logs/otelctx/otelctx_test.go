@@ -0,0 +1,120 @@
+package otelctx
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// traceID and spanID stand for trace.TraceID and trace.SpanID
+type (
+	traceID [16]byte
+	spanID  [8]byte
+)
+
+func (t traceID) String() string { return hex.EncodeToString(t[:]) }
+func (s spanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// spanContext stands for trace.SpanContext, valid when both IDs are set
+type spanContext struct {
+	traceID traceID
+	spanID  spanID
+}
+
+func (sc spanContext) IsValid() bool {
+	return sc.traceID != traceID{} && sc.spanID != spanID{}
+}
+func (sc spanContext) TraceID() traceID { return sc.traceID }
+func (sc spanContext) SpanID() spanID   { return sc.spanID }
+
+type spanKey struct{}
+
+// spanContextFromContext stands for trace.SpanContextFromContext
+func spanContextFromContext(ctx context.Context) spanContext {
+	sc, _ := ctx.Value(spanKey{}).(spanContext)
+	return sc
+}
+
+var active = spanContext{
+	traceID: traceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	spanID:  spanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+}
+
+func useExtractor(t *testing.T) (*logs.CommonLogger, *bytes.Buffer) {
+	t.Helper()
+	logs.SetContextExtractor(Extractor[spanContext, traceID, spanID](spanContextFromContext))
+	t.Cleanup(func() { logs.SetContextExtractor(nil) })
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf)
+	logs.WithFormatter(&logrus.JSONFormatter{})(q)
+	return q, buf
+}
+
+func lastEntry(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var entry map[string]interface{}
+	if err := json.Unmarshal(lines[len(lines)-1], &entry); err != nil {
+		t.Fatalf("invalid entry %q: %v", lines[len(lines)-1], err)
+	}
+	return entry
+}
+
+func TestExtractor(t *testing.T) {
+	q, buf := useExtractor(t)
+	ctx := context.WithValue(context.Background(), spanKey{}, active)
+	q.WithContext(ctx).Info("traced")
+	entry := lastEntry(t, buf)
+	if entry[TraceIDField] != "4bf92f3577b34da6a3ce929d0e0e4736" || entry[SpanIDField] != "00f067aa0ba902b7" {
+		t.Fatalf("entry = %v, want the span IDs", entry)
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"no span":      context.Background(),
+		"invalid span": context.WithValue(context.Background(), spanKey{}, spanContext{traceID: active.traceID}),
+	} {
+		q.WithContext(ctx).Info("untraced")
+		entry := lastEntry(t, buf)
+		if _, ok := entry[TraceIDField]; ok {
+			t.Errorf("%s: entry = %v, want no trace_id", name, entry)
+		}
+		if _, ok := entry[SpanIDField]; ok {
+			t.Errorf("%s: entry = %v, want no span_id", name, entry)
+		}
+	}
+}
+
+func TestExtractorMiddleware(t *testing.T) {
+	q, buf := useExtractor(t)
+	e := echo.New()
+	// Stands for the OTel middleware, starting the request span
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := context.WithValue(c.Request().Context(), spanKey{}, active)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.GET("/", func(c echo.Context) error {
+		logs.FromEchoContext(c).Info("handled")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	// The span of the extractor wins over the inbound traceparent
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	entry := lastEntry(t, buf)
+	if entry[TraceIDField] != active.traceID.String() || entry[SpanIDField] != active.spanID.String() {
+		t.Fatalf("entry = %v, want the IDs of the request span", entry)
+	}
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultReportQueueSize = 1000
+
+// DropPolicy selects the event dropped when the report queue is full
+type DropPolicy int
+
+const (
+	// DropNewest drops the event being reported, the default
+	DropNewest DropPolicy = iota
+	// DropOldest drops the event waiting the longest to make room
+	DropOldest
+)
+
+// ReportQueueOptions configures the queue error entries wait in to be
+// delivered to Sentry and the other error destinations
+type ReportQueueOptions struct {
+	// Size is the number of events the queue holds. Defaults to 1000.
+	Size   int
+	Policy DropPolicy
+}
+
+// reportJob is an event waiting for delivery
+type reportJob struct {
+	logger *CommonLogger
+	event  ErrorEvent
+}
+
+// reportQueue delivers the error events on a single worker goroutine, so
+// logging an error never waits on a slow destination
+type reportQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    []reportJob
+	size    int
+	policy  DropPolicy
+	busy    bool
+	started bool
+	dropped atomic.Int64
+}
+
+var reports = newReportQueue()
+
+func newReportQueue() *reportQueue {
+	rq := &reportQueue{size: defaultReportQueueSize}
+	rq.cond = sync.NewCond(&rq.mu)
+	return rq
+}
+
+// SetReportQueue resizes the report queue and changes its drop policy.
+// Events queued beyond the new size are kept.
+func SetReportQueue(opts ReportQueueOptions) {
+	reports.mu.Lock()
+	defer reports.mu.Unlock()
+	reports.size = opts.Size
+	if reports.size <= 0 {
+		reports.size = defaultReportQueueSize
+	}
+	reports.policy = opts.Policy
+}
+
+// WithReportQueue is the option form of SetReportQueue
+func WithReportQueue(opts ReportQueueOptions) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetReportQueue(opts)
+		return nil
+	}
+}
+
+// DroppedReports returns the number of error events dropped because the
+// report queue was full
+func DroppedReports() int64 {
+	return reports.dropped.Load()
+}
+
+// Flush writes out the buffered outputs and sinks, see WithBufferedOutput and
+// WithFluentd, then waits until the queued error events are delivered and the
+// Sentry transport sent its buffered events, for at most timeout overall, and
+// reports whether everything was sent
+func Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	if !flushSinks(deadline) {
+		return false
+	}
+	if !reports.wait(time.Until(deadline)) {
+		return false
+	}
+	return FlushSentry(time.Until(deadline))
+}
+
+func (rq *reportQueue) push(job reportJob) {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	if !rq.started {
+		rq.started = true
+		go rq.run()
+	}
+	if len(rq.jobs) >= rq.size {
+		rq.dropped.Add(1)
+		if rq.policy != DropOldest {
+			return
+		}
+		rq.jobs = rq.jobs[1:]
+	}
+	rq.jobs = append(rq.jobs, job)
+	rq.cond.Broadcast()
+}
+
+func (rq *reportQueue) run() {
+	rq.mu.Lock()
+	for {
+		for len(rq.jobs) == 0 {
+			rq.cond.Wait()
+		}
+		job := rq.jobs[0]
+		rq.jobs[0] = reportJob{}
+		rq.jobs = rq.jobs[1:]
+		rq.busy = true
+		rq.mu.Unlock()
+
+		job.logger.deliver(job.event)
+
+		rq.mu.Lock()
+		rq.busy = false
+		rq.cond.Broadcast()
+	}
+}
+
+// wait waits until the queue is empty and no event is being delivered
+func (rq *reportQueue) wait(timeout time.Duration) bool {
+	idle := make(chan struct{})
+	go func() {
+		rq.mu.Lock()
+		for len(rq.jobs) > 0 || rq.busy {
+			rq.cond.Wait()
+		}
+		rq.mu.Unlock()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
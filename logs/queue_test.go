@@ -0,0 +1,115 @@
+package logs_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// slowTransport is a Sentry transport taking delay to send every event
+type slowTransport struct {
+	delay time.Duration
+
+	mu   sync.Mutex
+	sent int
+}
+
+func (s *slowTransport) Configure(sentry.ClientOptions) {}
+
+func (s *slowTransport) SendEvent(*sentry.Event) {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent++
+}
+
+func (s *slowTransport) Flush(time.Duration) bool { return true }
+
+func (s *slowTransport) Sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+// useSlowTransport reports to Sentry through a slowTransport until the test
+// ends, without limits below 100 events
+func useSlowTransport(t *testing.T, delay time.Duration) *slowTransport {
+	t.Helper()
+	transport := &slowTransport{delay: delay}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.CurrentHub()
+	hub.PushScope()
+	hub.BindClient(client)
+	logs.ResetSentryLimits()
+	logs.SetSentryLimits(logs.SentryLimits{PerFingerprint: 100, PerSecond: 100})
+	t.Cleanup(func() {
+		logs.Flush(10 * time.Second)
+		hub.PopScope()
+		logs.ResetSentryLimits()
+		logs.SetReportQueue(logs.ReportQueueOptions{})
+	})
+	return transport
+}
+
+func TestAsyncReporting(t *testing.T) {
+	transport := useSlowTransport(t, 20*time.Millisecond)
+	q, _ := newTestLogger()
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		q.Errorf("failed: %v", errors.New("slow sentry"))
+	}
+	// 20 inline sends would take 400ms
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("20 Errorf calls took %v, blocked on the transport", elapsed)
+	}
+	if !logs.Flush(5 * time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	if n := transport.Sent(); n != 20 {
+		t.Fatalf("Flush delivered %d events, want 20", n)
+	}
+}
+
+func TestFlushTimeout(t *testing.T) {
+	useSlowTransport(t, 200*time.Millisecond)
+	q, _ := newTestLogger()
+	q.Error(errors.New("slow"))
+
+	start := time.Now()
+	if logs.Flush(20 * time.Millisecond) {
+		t.Fatal("Flush reported the slow event delivered")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("Flush took %v past its timeout", elapsed)
+	}
+}
+
+func TestReportQueueDrops(t *testing.T) {
+	transport := useSlowTransport(t, 50*time.Millisecond)
+	logs.SetReportQueue(logs.ReportQueueOptions{Size: 2})
+	q, _ := newTestLogger()
+
+	dropped := logs.DroppedReports()
+	for i := 0; i < 10; i++ {
+		q.Error(errors.New("burst"))
+	}
+	// Two queued and, once the worker picked the first, one in delivery
+	n := logs.DroppedReports() - dropped
+	if n < 7 || n > 8 {
+		t.Fatalf("%d events dropped, want the 7 or 8 beyond the queue", n)
+	}
+	if !logs.Flush(5 * time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	if sent := transport.Sent(); int64(sent) != 10-n {
+		t.Fatalf("%d events sent, want the %d not dropped", sent, 10-n)
+	}
+}
@@ -0,0 +1,82 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	recentMu sync.Mutex
+	recent   *recentBuffer
+)
+
+// recentBuffer is a ring of the last formatted entries
+type recentBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// WithRecentEntries keeps the last n formatted entries in memory so a panic
+// or fatal handler can emit them with DumpRecent
+func WithRecentEntries(n int) Option {
+	return func(q *CommonLogger) error {
+		if n <= 0 {
+			return errors.New("logs: recent entries size must be positive")
+		}
+		if q.dryRun {
+			return nil
+		}
+		buf := &recentBuffer{lines: make([]string, n)}
+		recentMu.Lock()
+		recent = buf
+		recentMu.Unlock()
+		q.addHook(buf)
+		return nil
+	}
+}
+
+// DumpRecent returns the last entries kept by WithRecentEntries, oldest first
+func DumpRecent() []string {
+	recentMu.Lock()
+	buf := recent
+	recentMu.Unlock()
+	if buf == nil {
+		return nil
+	}
+	return buf.dump()
+}
+
+func (b *recentBuffer) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (b *recentBuffer) Fire(e *logrus.Entry) error {
+	line, err := e.String()
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines[b.next] = strings.TrimRight(line, "\n")
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+	return nil
+}
+
+func (b *recentBuffer) dump() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		return append([]string(nil), b.lines[:b.next]...)
+	}
+	lines := make([]string, 0, len(b.lines))
+	lines = append(lines, b.lines[b.next:]...)
+	return append(lines, b.lines[:b.next]...)
+}
@@ -0,0 +1,68 @@
+package logs_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestDumpRecent(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithRecentEntries(3)(q); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Info("entry 0")
+	q.Info("entry 1")
+	if lines := logs.DumpRecent(); len(lines) != 2 || !strings.Contains(lines[0], "entry 0") {
+		t.Fatalf("DumpRecent = %q, want the 2 entries logged", lines)
+	}
+
+	for i := 2; i < 5; i++ {
+		q.Infof("entry %d", i)
+	}
+	lines := logs.DumpRecent()
+	if len(lines) != 3 {
+		t.Fatalf("DumpRecent returned %d lines, want 3", len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("entry %d", i+2); !strings.Contains(line, want) {
+			t.Errorf("line %d = %q, want %s", i, line, want)
+		}
+		if strings.HasSuffix(line, "\n") {
+			t.Errorf("line %d keeps its newline", i)
+		}
+	}
+}
+
+func TestDumpRecentConcurrent(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithRecentEntries(10)(q); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				q.Info("concurrent")
+				logs.DumpRecent()
+			}
+		}()
+	}
+	wg.Wait()
+	if lines := logs.DumpRecent(); len(lines) != 10 {
+		t.Fatalf("DumpRecent returned %d lines, want 10", len(lines))
+	}
+}
+
+func TestWithRecentEntriesInvalid(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithRecentEntries(0)(q); err == nil {
+		t.Fatal("size 0 accepted")
+	}
+}
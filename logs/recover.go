@@ -0,0 +1,76 @@
+package logs
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+)
+
+// RecoverOptions configures MiddlewareRecover
+type RecoverOptions struct {
+	// IncludeHeaders adds the request headers, Authorization and Cookie
+	// excluded, to the error reported to Sentry
+	IncludeHeaders bool
+}
+
+// MiddlewareRecover recovers the panics of the handlers, logs them at level
+// error with their stack trace and reports them as exceptions, then responds
+// 500. Registered after MiddlewareLoggerRequestID, the entry and the report
+// carry the request ID. http.ErrAbortHandler is panicked again, net/http
+// relying on it to abort the response.
+func (q *CommonLogger) MiddlewareRecover(opts RecoverOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				perr, ok := r.(error)
+				if !ok {
+					perr = fmt.Errorf("%v", r)
+				}
+				perr = fmt.Errorf("panic: %w", perr)
+
+				l, ok := c.Get(echoContextKey).(*CommonLogger)
+				if !ok {
+					l = q
+				}
+				l = l.WithError(perr)
+				if l.enabled(logrus.ErrorLevel) {
+					l.decorateLog().WithField("stack", string(debug.Stack())).Error("Recovered from panic")
+				}
+				reported := l
+				if opts.IncludeHeaders {
+					reported = l.WithField("request_headers", safeHeaders(c.Request().Header))
+				}
+				reported.report(logrus.ErrorLevel, perr.Error(), perr, nil)
+
+				c.Error(perr)
+				err = nil
+			}()
+			return next(c)
+		}
+	}
+}
+
+// safeHeaders flattens the headers, without the credentials
+func safeHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		switch http.CanonicalHeaderKey(k) {
+		case echo.HeaderAuthorization, "Cookie":
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package logs_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func panickingServer(q *logs.CommonLogger, opts logs.RecoverOptions, v interface{}) *echo.Echo {
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.Use(q.MiddlewareRecover(opts))
+	e.GET("/", func(c echo.Context) error {
+		panic(v)
+	})
+	return e
+}
+
+func TestMiddlewareRecover(t *testing.T) {
+	sentry := recordSentry(t)
+	q, buf := newTestLogger("api")
+	e := panickingServer(q, logs.RecoverOptions{IncludeHeaders: true}, "boom")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("responded %d, want 500", rec.Code)
+	}
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry["level"] != "error" || entry["msg"] != "Recovered from panic" || entry["requestID"] != "req-1" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if entry["error"] != "panic: boom" {
+		t.Fatalf("error = %v", entry["error"])
+	}
+	if stack, _ := entry["stack"].(string); !strings.Contains(stack, "recover_test.go") {
+		t.Fatalf("stack = %q, want the panicking handler", stack)
+	}
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Tags["x-request-id"] != "req-1" {
+		t.Fatalf("tags = %v, want the request ID", event.Tags)
+	}
+	if len(event.Exception) == 0 || event.Exception[len(event.Exception)-1].Value != "panic: boom" {
+		t.Fatalf("exception = %v", event.Exception)
+	}
+	headers := fmt.Sprint(event.Extra["request_headers"])
+	if !strings.Contains(headers, "application/json") || strings.Contains(headers, "secret") {
+		t.Fatalf("request_headers = %s, want them without Authorization", headers)
+	}
+}
+
+func TestMiddlewareRecoverWithoutHeaders(t *testing.T) {
+	sentry := recordSentry(t)
+	q, _ := newTestLogger()
+	e := panickingServer(q, logs.RecoverOptions{}, fmt.Errorf("wrapped"))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if _, ok := events[0].Extra["request_headers"]; ok {
+		t.Fatal("headers reported without IncludeHeaders")
+	}
+}
+
+func TestMiddlewareRecoverAbortHandler(t *testing.T) {
+	sentry := recordSentry(t)
+	q, buf := newTestLogger()
+	e := panickingServer(q, logs.RecoverOptions{}, http.ErrAbortHandler)
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler panicked again", r)
+		}
+		if buf.Len() != 0 || len(sentry.Events()) != 0 {
+			t.Fatal("aborted handler logged or reported")
+		}
+	}()
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
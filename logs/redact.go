@@ -0,0 +1,171 @@
+package logs
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+)
+
+// redactedValue replaces the redacted values
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactedFields are the fields redacted unless SetRedaction says
+// otherwise
+var DefaultRedactedFields = []string{"authorization", "password", "token", "card_number"}
+
+// Patterns commonly scrubbed from messages, to use in Redaction.Patterns
+var (
+	CreditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	JWTPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+// Redaction are the rules hiding sensitive data from the log output and the
+// error destinations
+type Redaction struct {
+	// Fields are the names, case insensitive, of the fields whose values
+	// are replaced by [REDACTED], at any depth of the maps logged by the *j
+	// methods
+	Fields []string
+	// Patterns replace their matches in messages by [REDACTED]
+	Patterns []*regexp.Regexp
+}
+
+// redactor is a compiled Redaction
+type redactor struct {
+	fields   map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(r Redaction) *redactor {
+	rd := &redactor{
+		fields:   make(map[string]struct{}, len(r.Fields)),
+		patterns: r.Patterns,
+	}
+	for _, f := range r.Fields {
+		rd.fields[strings.ToLower(f)] = struct{}{}
+	}
+	return rd
+}
+
+// with returns the rules of rd and other together
+func (rd *redactor) with(other *redactor) *redactor {
+	if other == nil {
+		return rd
+	}
+	merged := &redactor{
+		fields:   make(map[string]struct{}, len(rd.fields)+len(other.fields)),
+		patterns: append(append([]*regexp.Regexp(nil), rd.patterns...), other.patterns...),
+	}
+	for f := range rd.fields {
+		merged.fields[f] = struct{}{}
+	}
+	for f := range other.fields {
+		merged.fields[f] = struct{}{}
+	}
+	return merged
+}
+
+var globalRedactor atomic.Pointer[redactor]
+
+func init() {
+	globalRedactor.Store(newRedactor(Redaction{Fields: DefaultRedactedFields}))
+}
+
+// SetRedaction replaces the redaction rules applied to every logger
+func SetRedaction(r Redaction) {
+	globalRedactor.Store(newRedactor(r))
+}
+
+// WithRedaction adds rules on top of the global ones for the logger it is
+// applied to and its children
+func WithRedaction(r Redaction) Option {
+	return func(q *CommonLogger) error {
+		q.redaction = newRedactor(r).with(q.redaction)
+		return nil
+	}
+}
+
+// redactor returns the rules applying to q
+func (q *CommonLogger) redactor() *redactor {
+	return globalRedactor.Load().with(q.redaction)
+}
+
+func (rd *redactor) scrub(message string) string {
+	for _, p := range rd.patterns {
+		message = p.ReplaceAllString(message, redactedValue)
+	}
+	return message
+}
+
+// redactFields returns a copy of fields with the sensitive values replaced
+func (rd *redactor) redactFields(fields map[string]interface{}) map[string]interface{} {
+	if len(rd.fields) == 0 {
+		return fields
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		out[k] = rd.redactField(k, v)
+	}
+	return out
+}
+
+func (rd *redactor) redactField(key string, v interface{}) interface{} {
+	if _, ok := rd.fields[strings.ToLower(key)]; ok {
+		return redactedValue
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return rd.redactFields(v)
+	case gommonLog.JSON:
+		return rd.redactFields(v)
+	case logrus.Fields:
+		return rd.redactFields(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = rd.redactField("", item)
+		}
+		return out
+	}
+	return v
+}
+
+// redactionKey is the entry context key of the rules of the logger that
+// created the entry
+type redactionKey struct{}
+
+// withRedaction passes the rules of q on to the redaction hook
+func (q *CommonLogger) withRedaction(e *logrus.Entry) *logrus.Entry {
+	if q.redaction == nil {
+		return e
+	}
+	ctx := q.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return e.WithContext(context.WithValue(ctx, redactionKey{}, q.redaction))
+}
+
+// redactHook applies the redaction rules to entries before they are
+// formatted
+type redactHook struct{}
+
+func (h *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactHook) Fire(e *logrus.Entry) error {
+	rd := globalRedactor.Load()
+	if e.Context != nil {
+		if own, ok := e.Context.Value(redactionKey{}).(*redactor); ok {
+			rd = rd.with(own)
+		}
+	}
+	e.Data = logrus.Fields(rd.redactFields(e.Data))
+	e.Message = rd.scrub(e.Message)
+	return nil
+}
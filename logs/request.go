@@ -0,0 +1,77 @@
+package logs
+
+import (
+	"context"
+
+	"github.com/labstack/echo"
+)
+
+// echoContextKey is the echo.Context key of the request scoped logger
+const echoContextKey = "logs.requestLogger"
+
+// loggerKey is the context.Context key of the logger stored by IntoContext
+type loggerKey struct{}
+
+// RequestIDOptions configures MiddlewareLoggerRequestIDWithOptions
+type RequestIDOptions struct {
+	// Generator returns the ID of the requests without one. Defaults to
+	// UUIDv4.
+	Generator func() string
+	// IgnoreInbound always generates the ID, for services that must not
+	// trust the X-Request-ID sent by their clients
+	IgnoreInbound bool
+	// DebugAuthorizer lets the requests sending DebugHeader: true log at
+	// debug whatever the level, their entries marked with debug_boost=true.
+	// It must check the caller, e.g. against an allowlist or an API key. No
+	// request is boosted when nil, the default.
+	DebugAuthorizer func(c echo.Context) bool
+	// DebugHeader is the header requesting the debug boost, X-Debug-Log by
+	// default
+	DebugHeader string
+}
+
+// WithRequestID returns a child logger stamping every entry with the given
+// request ID
+func (q *CommonLogger) WithRequestID(id string) *CommonLogger {
+	child := q.clone()
+	child.requestID = id
+	return child
+}
+
+// FromEchoContext returns the logger bound to the request by
+// MiddlewareLoggerRequestID, or the default logger when the middleware did
+// not run
+func FromEchoContext(c echo.Context) *CommonLogger {
+	if q, ok := c.Get(echoContextKey).(*CommonLogger); ok {
+		return q
+	}
+	return NewCommonLog()
+}
+
+// IntoContext returns a copy of ctx carrying q, for FromContext to retrieve
+// in the code ctx is passed on to. MiddlewareLoggerRequestID stores the
+// request scoped logger in the request context this way.
+func IntoContext(ctx context.Context, q *CommonLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, q)
+}
+
+// FromContext returns the logger stored in ctx by IntoContext, or the
+// default logger when there is none, never nil
+func FromContext(ctx context.Context) *CommonLogger {
+	if ctx != nil {
+		if q, ok := ctx.Value(loggerKey{}).(*CommonLogger); ok && q != nil {
+			return q
+		}
+	}
+	return NewCommonLog()
+}
+
+// bindRequestLogger makes q the logger of the request, returned by
+// FromEchoContext and by FromContext with the request context, q being
+// bound to that context
+func bindRequestLogger(c echo.Context, q *CommonLogger) {
+	ctx := IntoContext(c.Request().Context(), q)
+	c.SetRequest(c.Request().WithContext(ctx))
+	q.ctx = ctx
+	c.Set(echoContextKey, q)
+}
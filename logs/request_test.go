@@ -0,0 +1,197 @@
+package logs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// charge stands for the code a handler calls, getting the logger from ctx
+func charge(ctx context.Context, step string) {
+	time.Sleep(time.Millisecond)
+	logs.FromContext(ctx).Infof("%s %s", step, ctx.Value(requestTag{}))
+}
+
+type requestTag struct{}
+
+func TestRequestIDParallelRequests(t *testing.T) {
+	q, buf := newTestLogger("api")
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.GET("/", func(c echo.Context) error {
+		id := c.Request().Header.Get(echo.HeaderXRequestID)
+		logs.FromEchoContext(c).Infof("handler %s", id)
+		charge(context.WithValue(c.Request().Context(), requestTag{}, id), "charge")
+		return c.NoContent(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, id)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if got := rec.Header().Get(echo.HeaderXRequestID); got != id {
+				t.Errorf("response request ID = %q, want %s", got, id)
+			}
+		}(fmt.Sprintf("req-%d", i))
+	}
+	wg.Wait()
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 200 {
+		t.Fatalf("%d entries, want 200", len(entries))
+	}
+	for _, entry := range entries {
+		id, _ := entry["requestID"].(string)
+		msg := entry["msg"].(string)
+		if msg != "handler "+id && msg != "charge "+id {
+			t.Fatalf("entry %q stamped with request ID %q", msg, id)
+		}
+	}
+	// The logger the middleware derives from is never modified
+	q.Info("outside")
+	if entry := lastEntry(t, buf); entry["requestID"] != nil {
+		t.Fatalf("request ID leaked to the parent logger: %v", entry)
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	if logs.FromContext(context.Background()) == nil || logs.FromContext(nil) == nil {
+		t.Fatal("FromContext returned nil")
+	}
+	_, shared := captureShared(t)
+	logs.FromContext(context.Background()).Info("unbound")
+	if entry := lastEntry(t, shared); entry["msg"] != "unbound" || entry["requestID"] != nil {
+		t.Fatalf("default logger entry = %v", entry)
+	}
+	// A nil logger stored is ignored too
+	logs.FromContext(logs.IntoContext(context.Background(), nil)).Info("nil logger")
+	if entry := lastEntry(t, shared); entry["msg"] != "nil logger" {
+		t.Fatalf("default logger entry = %v", entry)
+	}
+
+	q, buf := newTestLogger()
+	bound := q.WithField("user", "alice").WithRequestID("req-1")
+	ctx := logs.IntoContext(context.Background(), bound)
+	// Derived contexts carry it along
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, requestTag{}, "tag"), time.Minute)
+	defer cancel()
+	logs.FromContext(ctx).WithField("step", "charge").Info("bound")
+	entry := lastEntry(t, buf)
+	if entry["requestID"] != "req-1" || entry["user"] != "alice" || entry["step"] != "charge" {
+		t.Fatalf("entry = %v", entry)
+	}
+	if entry := lastEntry(t, shared); entry["msg"] != "nil logger" {
+		t.Fatalf("bound entry written to the default logger: %v", entry)
+	}
+}
+
+func TestFromContextGoroutine(t *testing.T) {
+	q, buf := newTestLogger()
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	// Stands for the authentication middleware
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			ctx = logs.IntoContext(ctx, logs.FromContext(ctx).WithField("user", "alice"))
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	e.GET("/", func(c echo.Context) error {
+		done := make(chan struct{})
+		go func(ctx context.Context) {
+			defer close(done)
+			logs.FromContext(ctx).Warnf("retrying %d", 1)
+		}(c.Request().Context())
+		<-done
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	entry := lastEntry(t, buf)
+	if entry["msg"] != "retrying 1" || entry["requestID"] != "req-1" || entry["user"] != "alice" {
+		t.Fatalf("entry = %v, want the request ID and user of the request", entry)
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		inbound string
+		opts    logs.RequestIDOptions
+		want    func(id string) bool
+	}{
+		{"present", "req-1", logs.RequestIDOptions{}, func(id string) bool { return id == "req-1" }},
+		{"absent", "", logs.RequestIDOptions{}, func(id string) bool {
+			parsed, err := uuid.Parse(id)
+			return err == nil && parsed.Version() == 4
+		}},
+		{"custom generator", "", logs.RequestIDOptions{Generator: func() string { return "gen-1" }},
+			func(id string) bool { return id == "gen-1" }},
+		{"inbound ignored", "req-1", logs.RequestIDOptions{
+			Generator:     func() string { return "gen-1" },
+			IgnoreInbound: true,
+		}, func(id string) bool { return id == "gen-1" }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q, buf := newTestLogger()
+			e := echo.New()
+			e.Use(q.MiddlewareLoggerRequestIDWithOptions(tt.opts))
+			e.GET("/", func(c echo.Context) error {
+				logs.FromEchoContext(c).Info("handled")
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.inbound != "" {
+				req.Header.Set(echo.HeaderXRequestID, tt.inbound)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			if !tt.want(id) {
+				t.Fatalf("response request ID = %q", id)
+			}
+			if entry := lastEntry(t, buf); entry["requestID"] != id {
+				t.Fatalf("entry stamped with %v, response header %q", entry["requestID"], id)
+			}
+		})
+	}
+}
+
+func TestRequestIDSentryTag(t *testing.T) {
+	sentry := recordSentry(t)
+	q, _ := newTestLogger()
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestIDWithOptions(logs.RequestIDOptions{Generator: func() string { return "gen-1" }}))
+	e.GET("/", func(c echo.Context) error {
+		logs.FromEchoContext(c).Error(errors.New("boom"))
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if tag := events[0].Tags["x-request-id"]; tag != "gen-1" {
+		t.Fatalf("x-request-id tag = %q, want the generated ID", tag)
+	}
+}
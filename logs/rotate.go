@@ -0,0 +1,353 @@
+package logs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	megabyte = 1024 * 1024
+	// backupTimeFormat is the UTC timestamp in the backup file names, e.g.
+	// app-2006-01-02T15-04-05.000.log, followed by -1, -2... when rotated
+	// more than once within a millisecond
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+)
+
+// FileOptions configures the log file written by a RotatingWriter
+type FileOptions struct {
+	Path string
+	// MaxSizeMB rotates the file before it grows past that size, never
+	// when 0
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept, all when 0
+	MaxBackups int
+	// MaxAgeDays removes the rotated files older than that, never when 0
+	MaxAgeDays int
+	// Compress gzips the rotated files
+	Compress bool
+}
+
+// RotatingWriter is an io.Writer appending to a file, rotated by size or on
+// demand. It is safe for concurrent use.
+type RotatingWriter struct {
+	opts FileOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// millMu serializes the compression and pruning of the backups,
+	// done in the background
+	millMu sync.Mutex
+}
+
+// NewRotatingWriter opens, or creates, the file at opts.Path for appending
+func NewRotatingWriter(opts FileOptions) (*RotatingWriter, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	w := &RotatingWriter{opts: opts}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (opts FileOptions) validate() error {
+	if opts.Path == "" {
+		return errors.New("logs: file path must not be empty")
+	}
+	if opts.MaxSizeMB < 0 || opts.MaxBackups < 0 || opts.MaxAgeDays < 0 {
+		return errors.New("logs: file limits must not be negative")
+	}
+	return nil
+}
+
+// WithFile writes the entries to the rotating file described by opts instead
+// of stderr
+func WithFile(opts FileOptions) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return opts.validate()
+		}
+		w, err := NewRotatingWriter(opts)
+		if err != nil {
+			return err
+		}
+		q.SetOutput(w)
+		registerSink(w, "file", nil, w.Close)
+		return nil
+	}
+}
+
+// Write implements io.Writer, rotating the file first when p would make it
+// exceed MaxSizeMB
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	limit := int64(w.opts.MaxSizeMB) * megabyte
+	if limit > 0 && w.size > 0 && w.size+int64(len(p)) > limit {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the current file to a timestamped backup and starts a new one
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file at its path, for logrotate moving it
+// away. See ReopenOnSIGHUP.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		w.file.Close()
+	}
+	return w.open()
+}
+
+// ReopenOnSIGHUP calls Reopen every time the process receives SIGHUP, until
+// the returned function is called
+func (w *RotatingWriter) ReopenOnSIGHUP() func() {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-sig:
+				if err := w.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "logs: failed to reopen %s: %v\n", w.opts.Path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sig)
+			close(done)
+		})
+	}
+}
+
+// Close closes the file, writes afterwards fail
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// open opens the file, w.mu must be held
+func (w *RotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.opts.Path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+// rotate renames the file and opens a new one, w.mu must be held
+func (w *RotatingWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	backup := w.backupName(time.Now())
+	if err := os.Rename(w.opts.Path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	go w.mill(backup)
+	return nil
+}
+
+// backupName returns the first backup name for t not taken yet, compressed
+// or not
+func (w *RotatingWriter) backupName(t time.Time) string {
+	dir := filepath.Dir(w.opts.Path)
+	base := filepath.Base(w.opts.Path)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.UTC().Format(backupTimeFormat)
+	for seq := 0; ; seq++ {
+		name := stamp
+		if seq > 0 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		path := filepath.Join(dir, name+ext)
+		if !fileExists(path) && !fileExists(path+".gz") {
+			return path
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+// mill compresses the new backup and prunes the old ones
+func (w *RotatingWriter) mill(backup string) {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	if w.opts.Compress {
+		if err := compress(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "logs: failed to compress %s: %v\n", backup, err)
+		}
+	}
+	if err := w.prune(); err != nil {
+		fmt.Fprintf(os.Stderr, "logs: failed to prune the backups of %s: %v\n", w.opts.Path, err)
+	}
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// parseBackupStamp parses the timestamp and sequence of a backup name, with
+// the prefix and extensions removed
+func parseBackupStamp(stamp string) (time.Time, int, bool) {
+	if len(stamp) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	rest := stamp[len(backupTimeFormat):]
+	if rest == "" {
+		return t, 0, true
+	}
+	seq, err := strconv.Atoi(strings.TrimPrefix(rest, "-"))
+	if err != nil || !strings.HasPrefix(rest, "-") || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+func (w *RotatingWriter) prune() error {
+	if w.opts.MaxBackups == 0 && w.opts.MaxAgeDays == 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(w.opts.Path)
+	base := filepath.Base(w.opts.Path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		stamp = strings.TrimSuffix(stamp, ext)
+		t, seq, ok := parseBackupStamp(stamp)
+		if !ok {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), time: t, seq: seq})
+	}
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+
+	cutoff := time.Now().AddDate(0, 0, -w.opts.MaxAgeDays)
+	var errs []error
+	for i, b := range backups {
+		tooMany := w.opts.MaxBackups > 0 && i >= w.opts.MaxBackups
+		tooOld := w.opts.MaxAgeDays > 0 && b.time.Before(cutoff)
+		if tooMany || tooOld {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compress gzips path to path.gz and removes path
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
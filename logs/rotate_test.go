@@ -0,0 +1,188 @@
+package logs_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// backups returns the rotated files next to path
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	ext := filepath.Ext(path)
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ext) + "-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+// waitBackups waits for the backups of path, compressed and pruned in the
+// background, to number n
+func waitBackups(t *testing.T, path string, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := backups(t, path)
+		if len(got) == n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRotatingWriterSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := logs.NewRotatingWriter(logs.FileOptions{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 4 goroutines writing 1MB each
+			for i := 0; i < 1024; i++ {
+				if _, err := w.Write(line); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := waitBackups(t, path, 2)
+	if len(got) != 2 {
+		t.Fatalf("backups = %v, want the 2 newest kept", got)
+	}
+	for _, b := range append(got, path) {
+		info, err := os.Stat(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1024*1024 || info.Size()%int64(len(line)) != 0 {
+			t.Errorf("%s holds %d bytes, want at most 1MB of whole lines", b, info.Size())
+		}
+	}
+}
+
+func TestRotatingWriterNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := logs.NewRotatingWriter(logs.FileOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	want := filepath.Join(dir, "app-2024-03-01T11-30-00.000.log")
+	if got := w.BackupName(at); got != want {
+		t.Fatalf("backup name = %s, want %s in UTC", got, want)
+	}
+	os.WriteFile(want, nil, 0o644)
+	if got := w.BackupName(at); got != filepath.Join(dir, "app-2024-03-01T11-30-00.000-1.log") {
+		t.Fatalf("second backup name = %s", got)
+	}
+	os.WriteFile(filepath.Join(dir, "app-2024-03-01T11-30-00.000-1.log.gz"), nil, 0o644)
+	if got := w.BackupName(at); got != filepath.Join(dir, "app-2024-03-01T11-30-00.000-2.log") {
+		t.Fatalf("name taken by a compressed backup reused: %s", got)
+	}
+
+	w.Write([]byte("line\n"))
+	before := time.Now().UTC().Truncate(time.Millisecond)
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range backups(t, path) {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(b), "app-"), ".log")
+		if strings.HasPrefix(stamp, "2024") {
+			continue
+		}
+		rotated, err := time.Parse("2006-01-02T15-04-05.000", stamp)
+		if err != nil {
+			t.Fatalf("backup %s: %v", b, err)
+		}
+		if d := rotated.Sub(before); d < 0 || d > time.Second {
+			t.Fatalf("backup stamped %v, want the UTC time of the rotation %v", rotated, before)
+		}
+	}
+}
+
+func TestRotatingWriterPruneAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := filepath.Join(dir, "app-"+time.Now().UTC().AddDate(0, 0, -10).Format("2006-01-02T15-04-05.000")+".log.gz")
+	unrelated := filepath.Join(dir, "app-notes.log")
+	os.WriteFile(old, nil, 0o644)
+	os.WriteFile(unrelated, nil, 0o644)
+
+	w, err := logs.NewRotatingWriter(logs.FileOptions{Path: path, MaxAgeDays: 1, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("line\n"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for exists(old) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if exists(old) {
+		t.Fatal("backup older than MaxAgeDays kept")
+	}
+	if !exists(unrelated) {
+		t.Fatal("file not named like a backup pruned")
+	}
+	got := waitBackups(t, path, 2)
+	var compressed bool
+	for _, b := range got {
+		compressed = compressed || (b != unrelated && strings.HasSuffix(b, ".log.gz"))
+	}
+	if !compressed {
+		t.Fatalf("backups = %v, want the new one compressed", got)
+	}
+}
+
+func TestRotatingWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := logs.NewRotatingWriter(logs.FileOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("before\n"))
+	// logrotate moving the file away
+	os.Rename(path, path+".1")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+	if b, _ := os.ReadFile(path); string(b) != "after\n" {
+		t.Fatalf("reopened file holds %q", b)
+	}
+
+	w.Close()
+	if _, err := w.Write([]byte("closed\n")); err == nil {
+		t.Fatal("write after Close succeeded")
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// samplerSlots is the number of counters per level, messages hashing to the
+// same slot sharing one
+const samplerSlots = 4096
+
+// samplingTick is the period the counters are reset with
+const samplingTick = time.Second
+
+// SamplingRule keeps, every second, the first Initial entries with a given
+// message then every Thereafter-th, none of them when Thereafter is zero. The
+// zero value keeps every entry.
+type SamplingRule struct {
+	Initial    int
+	Thereafter int
+}
+
+// Sampling selects the rule of every level up to warn, error and above being
+// never sampled. Prefixes replaces the rules for the loggers with the given
+// prefixes, their own Prefixes being ignored.
+type Sampling struct {
+	Trace SamplingRule
+	Debug SamplingRule
+	Info  SamplingRule
+	Warn  SamplingRule
+
+	Prefixes map[string]Sampling
+}
+
+// rule returns the rule of level, and whether it samples anything
+func (s *Sampling) rule(level logrus.Level) (SamplingRule, bool) {
+	var r SamplingRule
+	switch level {
+	case logrus.TraceLevel:
+		r = s.Trace
+	case logrus.DebugLevel:
+		r = s.Debug
+	case logrus.InfoLevel:
+		r = s.Info
+	case logrus.WarnLevel:
+		r = s.Warn
+	}
+	return r, r != SamplingRule{}
+}
+
+var sampler atomic.Pointer[entrySampler]
+
+// SetSampling samples the entries like zap does, counting them per level,
+// prefix and message template: the format of the formatting methods and the
+// message of the others. The entries sampled away are counted by
+// WithMetrics. nil disables sampling, the default.
+func SetSampling(s *Sampling) {
+	if s == nil {
+		sampler.Store(nil)
+		return
+	}
+	sampler.Store(&entrySampler{sampling: *s})
+}
+
+// WithSampling is the option form of SetSampling
+func WithSampling(s Sampling) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetSampling(&s)
+		return nil
+	}
+}
+
+type entrySampler struct {
+	sampling Sampling
+	counters [logrus.TraceLevel + 1][samplerSlots]sampleCounter
+}
+
+// sampleCounter counts the entries of a slot until resetAt, in unix nanos
+type sampleCounter struct {
+	resetAt atomic.Int64
+	count   atomic.Uint64
+}
+
+// inc counts an entry at now and returns the count of the current tick
+func (c *sampleCounter) inc(now int64) uint64 {
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+int64(samplingTick)) {
+		// Another entry started the tick
+		return c.count.Add(1)
+	}
+	return 1
+}
+
+// suppressed reports whether the entry at level with the given message
+// template is sampled away or suppressed by the dedup filter
+func (q *CommonLogger) suppressed(level logrus.Level, template string) bool {
+	if s := sampler.Load(); s != nil && s.sampledAway(q.prefix, level, template) {
+		return true
+	}
+	return q.duplicate(level, template)
+}
+
+// suppressedArgs is suppressed for the methods taking the message as
+// arguments, only formatted when sampling or the dedup filter is enabled
+func (q *CommonLogger) suppressedArgs(level logrus.Level, args []interface{}) bool {
+	if sampler.Load() == nil && dedup.Load() == nil {
+		return false
+	}
+	return q.suppressed(level, fmt.Sprint(args...))
+}
+
+// sampledAway reports whether the sampler drops the entry at level with the
+// given message template
+func (s *entrySampler) sampledAway(prefix string, level logrus.Level, template string) bool {
+	return s.sampledAwayAt(prefix, level, template, time.Now().UnixNano())
+}
+
+// sampledAwayAt is sampledAway for an entry logged at now, in unix nanos
+func (s *entrySampler) sampledAwayAt(prefix string, level logrus.Level, template string, now int64) bool {
+	if level <= logrus.ErrorLevel {
+		return false
+	}
+	sampling := &s.sampling
+	if p, ok := s.sampling.Prefixes[prefix]; ok {
+		sampling = &p
+	}
+	rule, ok := sampling.rule(level)
+	if !ok {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(prefix))
+	h.Write([]byte{0})
+	h.Write([]byte(template))
+	n := s.counters[level][h.Sum32()%samplerSlots].inc(now)
+	if n <= uint64(rule.Initial) {
+		return false
+	}
+	if rule.Thereafter > 0 && (n-uint64(rule.Initial))%uint64(rule.Thereafter) == 0 {
+		return false
+	}
+	countSampled(prefix, level)
+	return true
+}
@@ -0,0 +1,137 @@
+package logs_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func useSampling(t testing.TB, s logs.Sampling) {
+	t.Helper()
+	logs.SetSampling(&s)
+	t.Cleanup(func() { logs.SetSampling(nil) })
+}
+
+func TestSamplingSyntheticSecond(t *testing.T) {
+	useSampling(t, logs.Sampling{Info: logs.SamplingRule{Initial: 100, Thereafter: 100}})
+	start := time.Now()
+
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		at := start.Add(time.Duration(i) * 50 * time.Microsecond)
+		if !logs.SampledAwayAt("api", logrus.InfoLevel, "served", at) {
+			kept++
+		}
+	}
+	// The first 100, then every 100th of the other 9900
+	if kept != 199 {
+		t.Fatalf("kept %d of 10000 entries, want 199", kept)
+	}
+
+	// Other messages, prefixes and levels are counted apart
+	if logs.SampledAwayAt("api", logrus.InfoLevel, "charged", start) {
+		t.Fatal("another message sampled away")
+	}
+	if logs.SampledAwayAt("billing", logrus.InfoLevel, "served", start) {
+		t.Fatal("the message of another prefix sampled away")
+	}
+	for _, level := range []logrus.Level{logrus.WarnLevel, logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel} {
+		if logs.SampledAwayAt("api", level, "served", start) {
+			t.Fatalf("%s entry sampled away", level)
+		}
+	}
+
+	// A new second starts counting again
+	next := start.Add(time.Second)
+	if logs.SampledAwayAt("api", logrus.InfoLevel, "served", next) {
+		t.Fatal("first entry of the next second sampled away")
+	}
+}
+
+func TestSamplingConcurrent(t *testing.T) {
+	useSampling(t, logs.Sampling{Info: logs.SamplingRule{Initial: 100, Thereafter: 100}})
+	at := time.Now()
+
+	var kept atomic.Int64
+	// The first entry opens the second
+	if !logs.SampledAwayAt("api", logrus.InfoLevel, "served", at) {
+		kept.Add(1)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 99; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 101; i++ {
+				if !logs.SampledAwayAt("api", logrus.InfoLevel, "served", at) {
+					kept.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if kept.Load() != 199 {
+		t.Fatalf("kept %d of 10000 entries, want 199", kept.Load())
+	}
+}
+
+func TestSamplingLogger(t *testing.T) {
+	shared, buf := captureShared(t, "sampling-hot")
+	logs.WithMetrics()(shared)
+	useSampling(t, logs.Sampling{
+		Info:     logs.SamplingRule{Initial: 100, Thereafter: 100},
+		Prefixes: map[string]logs.Sampling{"sampling-hot": {Info: logs.SamplingRule{Initial: 2}}},
+	})
+	before := sampledCount("info", "sampling-hot")
+
+	for i := 0; i < 1000; i++ {
+		shared.Infof("request %d served", i)
+		shared.Warn("slow")
+		shared.Error("failed")
+	}
+	var info, warn, errs int
+	for _, entry := range decodeEntries(t, buf) {
+		switch entry["level"] {
+		case "info":
+			info++
+		case "warning":
+			warn++
+		case "error":
+			errs++
+		}
+	}
+	// The prefix rule keeps 2 per second, a second boundary adding 2 more
+	if info < 2 || info > 4 {
+		t.Fatalf("kept %d info entries, want the 2 of the prefix rule", info)
+	}
+	if warn != 1000 || errs != 1000 {
+		t.Fatalf("kept %d warn and %d error entries, want all", warn, errs)
+	}
+	if sampled := sampledCount("info", "sampling-hot") - before; sampled != int64(1000-info) {
+		t.Fatalf("counted %d entries sampled away, want %d", sampled, 1000-info)
+	}
+}
+
+// sampledCount returns the entries sampled away counted for level and prefix
+func sampledCount(level, prefix string) int64 {
+	for _, e := range logs.Metrics().Sampled {
+		if e.Level == level && e.Prefix == prefix {
+			return e.Count
+		}
+	}
+	return 0
+}
+
+func BenchmarkSampledAway(b *testing.B) {
+	useSampling(b, logs.Sampling{Info: logs.SamplingRule{Initial: 100, Thereafter: 100}})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logs.SampledAwayAt("api", logrus.InfoLevel, "served", time.Now())
+		}
+	})
+}
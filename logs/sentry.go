@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// InitSentry initializes the Sentry client error entries are reported to.
+// Until it succeeds, reporting to Sentry is a no-op.
+func InitSentry(dsn, env, release string) error {
+	if dsn == "" {
+		return errors.New("logs: sentry DSN must not be empty")
+	}
+	return sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: env,
+		Release:     release,
+	})
+}
+
+// WithSentry is the option form of InitSentry
+func WithSentry(dsn, env, release string) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			if dsn == "" {
+				return errors.New("logs: sentry DSN must not be empty")
+			}
+			_, err := sentry.NewDsn(dsn)
+			return err
+		}
+		return InitSentry(dsn, env, release)
+	}
+}
+
+// FlushSentry waits until the Sentry transport sent its buffered events, for
+// at most timeout, and reports whether they all were. Flush also delivers the
+// events still queued and is the one to call on shutdown.
+func FlushSentry(timeout time.Duration) bool {
+	if sentry.CurrentHub().Client() == nil {
+		return true
+	}
+	return sentry.Flush(timeout)
+}
+
+// WithoutSentry returns a child logger whose error entries are not reported
+// to Sentry, the other error destinations still receive them
+func (q *CommonLogger) WithoutSentry() *CommonLogger {
+	child := q.clone()
+	child.noSentry = true
+	return child
+}
+
+const defaultExitFlushTimeout = 2 * time.Second
+
+var exitFlushTimeout atomic.Int64
+
+// SetExitFlushTimeout bounds how long Fatal and Panic wait for the error
+// reports to be sent before the process terminates, 2s by default
+func SetExitFlushTimeout(timeout time.Duration) {
+	exitFlushTimeout.Store(int64(timeout))
+}
+
+// flushBeforeExit is called by the Fatal methods before logrus exits, and
+// deferred by the Panic methods to run while the panic unwinds, without
+// recovering it so the original value and stack trace are kept
+func flushBeforeExit() {
+	Flush(exitTimeout())
+}
+
+// exitTimeout returns the timeout set with SetExitFlushTimeout
+func exitTimeout() time.Duration {
+	timeout := time.Duration(exitFlushTimeout.Load())
+	if timeout <= 0 {
+		timeout = defaultExitFlushTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,173 @@
+package logs_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestSentryReporting(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger()
+
+	q.Error(errors.New("reported"))
+	q.WithoutSentry().Error(errors.New("batch job noise"))
+	q.Warn("warnings are not reported")
+	events := rec.Events()
+	if len(events) != 1 {
+		t.Fatalf("%d events reported, want only the error of the reporting logger", len(events))
+	}
+	if ex := events[0].Exception; len(ex) == 0 || ex[len(ex)-1].Value != "reported" {
+		t.Fatalf("exception = %+v", ex)
+	}
+	if !logs.FlushSentry(time.Second) {
+		t.Fatal("FlushSentry timed out")
+	}
+}
+
+func TestSentryNotInitialized(t *testing.T) {
+	hub := sentry.CurrentHub()
+	hub.PushScope()
+	defer hub.PopScope()
+	hub.BindClient(nil)
+
+	q, _ := newTestLogger()
+	q.Errorf("nowhere to report: %v", errors.New("boom"))
+	// Delivered before the scope is popped
+	if !logs.Flush(time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	if !logs.FlushSentry(time.Second) {
+		t.Fatal("FlushSentry without client must return at once")
+	}
+}
+
+func TestInitSentry(t *testing.T) {
+	if err := logs.InitSentry("", "test", "v1"); err == nil {
+		t.Fatal("empty DSN accepted")
+	}
+	if _, err := logs.NewCommonLogE(logs.WithSentry("not a dsn", "test", "v1")); err == nil {
+		t.Fatal("invalid DSN accepted")
+	}
+
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Init binds the client to the top scope, popped once done
+	hub := sentry.CurrentHub()
+	hub.PushScope()
+	defer hub.PopScope()
+	logs.ResetSentryLimits()
+	dsn := "http://public@" + strings.TrimPrefix(srv.URL, "http://") + "/1"
+	if err := logs.InitSentry(dsn, "test", "v1"); err != nil {
+		t.Fatalf("InitSentry: %v", err)
+	}
+
+	q, _ := newTestLogger()
+	q.Error(errors.New("shipped"))
+	if !logs.Flush(2 * time.Second) {
+		t.Fatal("Flush timed out")
+	}
+	if received.Load() != 1 {
+		t.Fatalf("server received %d events, want 1", received.Load())
+	}
+}
+
+func chargeFailed(q *logs.CommonLogger, requestID string) {
+	q.WithRequestID(requestID).WithField("order", 42).Errorf("charge failed: %v", errors.New("card declined"))
+}
+
+func TestSentryExceptionGrouping(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger("billing")
+
+	for _, id := range []string{"req-1", "req-2"} {
+		chargeFailed(q, id)
+	}
+	events := rec.Events()
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+	for _, e := range events {
+		if len(e.Exception) == 0 || e.Exception[len(e.Exception)-1].Stacktrace == nil {
+			t.Fatalf("event without exception stack trace: %+v", e.Exception)
+		}
+		for _, f := range e.Exception[len(e.Exception)-1].Stacktrace.Frames {
+			if f.Module == "github.com/rohanchauhan02/common/logs" {
+				t.Fatalf("frame of the logs package kept: %+v", f)
+			}
+		}
+		if e.Tags["prefix"] != "billing" || !strings.HasPrefix(e.Tags["source"], "sentry_test.go:") {
+			t.Fatalf("tags = %v", e.Tags)
+		}
+		if _, ok := e.Tags["requestID"]; ok {
+			t.Fatalf("request ID tagged, splitting the issue: %v", e.Tags)
+		}
+		if e.Extra["order"] != 42 || e.Extra["message"] != "charge failed: card declined" {
+			t.Fatalf("extras = %v", e.Extra)
+		}
+	}
+	if events[0].Extra["requestID"] != "req-1" || events[1].Extra["requestID"] != "req-2" {
+		t.Fatalf("request IDs = %v, %v", events[0].Extra["requestID"], events[1].Extra["requestID"])
+	}
+	// Same code path, same grouping inputs
+	a, b := events[0].Exception, events[1].Exception
+	if a[len(a)-1].Type != b[len(b)-1].Type || a[len(a)-1].Value != b[len(b)-1].Value ||
+		!sameFrames(a[len(a)-1].Stacktrace.Frames, b[len(b)-1].Stacktrace.Frames) {
+		t.Fatalf("events of the same code path differ:\n%+v\n%+v", a, b)
+	}
+}
+
+func sameFrames(a, b []sentry.Frame) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Function != b[i].Function || a[i].Lineno != b[i].Lineno {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSentryWrappedErrorFingerprint(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger()
+	root := errors.New("connection refused")
+	q.Error(fmt.Errorf("load user 1: %w", root))
+	q.Error(fmt.Errorf("load user 2: %w", root))
+
+	events := rec.Events()
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+	if fp := events[0].Fingerprint; len(fp) == 0 || fmt.Sprint(fp) != fmt.Sprint(events[1].Fingerprint) {
+		t.Fatalf("fingerprints %v and %v, want the same root cause one", fp, events[1].Fingerprint)
+	}
+}
+
+func TestSentryMessageExtras(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger("jobs")
+	q.WithRequestID("req-1").WithField("job", "export").Errorf("export stalled")
+
+	events := rec.Events()
+	if len(events) != 1 || events[0].Message != "export stalled" {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].Extra["job"] != "export" || events[0].Extra["requestID"] != "req-1" || events[0].Tags["prefix"] != "jobs" {
+		t.Fatalf("extras = %v, tags = %v", events[0].Extra, events[0].Tags)
+	}
+}
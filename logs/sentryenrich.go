@@ -0,0 +1,91 @@
+package logs
+
+import (
+	"fmt"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/labstack/echo"
+)
+
+// SentryEnrichOptions configures MiddlewareSentryEnrich. The user values are
+// read from the echo.Context keys set by the authentication middleware.
+type SentryEnrichOptions struct {
+	// UserIDKey, UserEmailKey and UsernameKey are the echo.Context keys of
+	// the ID, email and name of the authenticated user
+	UserIDKey    string
+	UserEmailKey string
+	UsernameKey  string
+	// HeaderTags maps request headers to the tags they are set on, e.g.
+	// {"X-Client-Version": "client_version"}
+	HeaderTags map[string]string
+}
+
+// MiddlewareSentryEnrich attaches the authenticated user and the configured
+// request headers to the Sentry events of the request, on its own hub, see
+// MiddlewareLoggerRequestID. Register it after the authentication middleware.
+// It does nothing until Sentry is initialized.
+func (q *CommonLogger) MiddlewareSentryEnrich(opts SentryEnrichOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			hub, bound := bindRequestHub(c)
+			if hub == nil {
+				return next(c)
+			}
+			if l, ok := c.Get(echoContextKey).(*CommonLogger); ok && bound {
+				bindRequestLogger(c, l.clone())
+			}
+
+			user := sentry.User{
+				ID:       contextString(c, opts.UserIDKey),
+				Email:    contextString(c, opts.UserEmailKey),
+				Username: contextString(c, opts.UsernameKey),
+			}
+			hub.ConfigureScope(func(scope *sentry.Scope) {
+				if user.ID != "" || user.Email != "" || user.Username != "" {
+					user.IPAddress = c.RealIP()
+					scope.SetUser(user)
+				}
+				for header, tag := range opts.HeaderTags {
+					if v := c.Request().Header.Get(header); v != "" {
+						scope.SetTag(tag, v)
+					}
+				}
+			})
+			return next(c)
+		}
+	}
+}
+
+// bindRequestHub returns the Sentry hub of the request, binding a clone of
+// the current hub to the request context the first time, so the scope of a
+// request is never seen by the others, and whether it did. It returns nil
+// until Sentry is initialized.
+func bindRequestHub(c echo.Context) (*sentry.Hub, bool) {
+	ctx := c.Request().Context()
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		return hub, false
+	}
+	hub := sentry.CurrentHub()
+	if hub.Client() == nil {
+		return nil, false
+	}
+	hub = hub.Clone()
+	c.SetRequest(c.Request().WithContext(sentry.SetHubOnContext(ctx, hub)))
+	return hub, true
+}
+
+// contextString returns the value of key in c as a string, empty when key is
+// empty or not set
+func contextString(c echo.Context, key string) string {
+	if key == "" {
+		return ""
+	}
+	switch v := c.Get(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
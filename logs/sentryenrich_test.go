@@ -0,0 +1,116 @@
+package logs_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// enrichServer authenticates the user of the X-User header, then enriches
+// the Sentry events of the request. Its handler waits until requests
+// handlers are running at once, then logs an error.
+func enrichServer(q *logs.CommonLogger, requests int) *echo.Echo {
+	var arrived sync.WaitGroup
+	arrived.Add(requests)
+	e := echo.New()
+	e.Use(q.MiddlewareLoggerRequestID())
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user := c.Request().Header.Get("X-User"); user != "" {
+				c.Set("user_id", user)
+				c.Set("user_email", user+"@example.com")
+			}
+			return next(c)
+		}
+	})
+	e.Use(q.MiddlewareSentryEnrich(logs.SentryEnrichOptions{
+		UserIDKey:    "user_id",
+		UserEmailKey: "user_email",
+		HeaderTags:   map[string]string{"X-Client-Version": "client_version"},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		arrived.Done()
+		arrived.Wait()
+		logs.FromEchoContext(c).Error(errors.New("boom"))
+		return c.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func TestSentryEnrichConcurrentUsers(t *testing.T) {
+	sentry := recordSentry(t)
+	q, _ := newTestLogger()
+	e := enrichServer(q, 2)
+
+	var wg sync.WaitGroup
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set(echo.HeaderXRequestID, "req-"+user)
+		req.Header.Set("X-Client-Version", user+"-1.0")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	events := sentry.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	seen := map[string]bool{}
+	for _, event := range events {
+		user := event.User.ID
+		if user != "alice" && user != "bob" {
+			t.Fatalf("event user = %+v, want alice or bob", event.User)
+		}
+		seen[user] = true
+		if event.User.Email != user+"@example.com" {
+			t.Errorf("%s: email = %q", user, event.User.Email)
+		}
+		if tag := event.Tags["x-request-id"]; tag != "req-"+user {
+			t.Errorf("%s: x-request-id tag = %q, want the ID of its own request", user, tag)
+		}
+		if tag := event.Tags["client_version"]; tag != user+"-1.0" {
+			t.Errorf("%s: client_version tag = %q, want the header of its own request", user, tag)
+		}
+	}
+	if len(seen) != 2 {
+		t.Fatalf("events attributed to %v, want one per user", seen)
+	}
+
+	// Nothing leaked to the scope shared outside the requests
+	q.Error(errors.New("outside"))
+	events = sentry.Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if last := events[2]; last.User.ID != "" || last.Tags["x-request-id"] != "" || last.Tags["client_version"] != "" {
+		t.Fatalf("event outside the requests carries user %+v and tags %v", last.User, last.Tags)
+	}
+}
+
+func TestSentryEnrichAnonymous(t *testing.T) {
+	sentry := recordSentry(t)
+	q, _ := newTestLogger()
+	e := enrichServer(q, 1)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	events := sentry.Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if events[0].User.ID != "" || events[0].User.IPAddress != "" {
+		t.Fatalf("anonymous request event carries user %+v", events[0].User)
+	}
+	if _, ok := events[0].Tags["client_version"]; ok {
+		t.Fatalf("tag set from a missing header: %v", events[0].Tags)
+	}
+}
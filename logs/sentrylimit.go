@@ -0,0 +1,177 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	defaultSentryPerFingerprint  = 10
+	defaultSentryWindow          = time.Minute
+	defaultSentryPerSecond       = 20
+	defaultSentrySummaryInterval = time.Minute
+)
+
+// SentryLimits caps the events reported to Sentry, so that an error logged
+// in a hot loop neither blows the quota nor slows down the callers. Events
+// sharing a fingerprint, their level and source, i.e. the call site and so
+// the message template, are deduplicated. Zero values take the defaults.
+type SentryLimits struct {
+	// PerFingerprint is the number of events of a fingerprint reported per
+	// Window. Defaults to 10 per minute.
+	PerFingerprint int
+	Window         time.Duration
+	// PerSecond caps the events reported overall. Defaults to 20.
+	PerSecond int
+	// SummaryInterval is how often an event counting the suppressed
+	// duplicates of every fingerprint is reported. Defaults to 1 minute.
+	SummaryInterval time.Duration
+}
+
+func (l SentryLimits) withDefaults() SentryLimits {
+	if l.PerFingerprint <= 0 {
+		l.PerFingerprint = defaultSentryPerFingerprint
+	}
+	if l.Window <= 0 {
+		l.Window = defaultSentryWindow
+	}
+	if l.PerSecond <= 0 {
+		l.PerSecond = defaultSentryPerSecond
+	}
+	if l.SummaryInterval <= 0 {
+		l.SummaryInterval = defaultSentrySummaryInterval
+	}
+	return l
+}
+
+var sentryLimiter = newSentryRateLimiter(SentryLimits{})
+
+// SetSentryLimits replaces the limits of the Sentry reporting
+func SetSentryLimits(limits SentryLimits) {
+	sentryLimiter.setLimits(limits)
+}
+
+// WithSentryLimits is the option form of SetSentryLimits
+func WithSentryLimits(limits SentryLimits) Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetSentryLimits(limits)
+		return nil
+	}
+}
+
+type fingerprintState struct {
+	windowStart time.Time
+	count       int
+	suppressed  int64
+	message     string
+}
+
+type sentryRateLimiter struct {
+	mu           sync.Mutex
+	limits       SentryLimits
+	fingerprints map[string]*fingerprintState
+	tokens       float64
+	last         time.Time
+	summary      *time.Timer
+}
+
+func newSentryRateLimiter(limits SentryLimits) *sentryRateLimiter {
+	limits = limits.withDefaults()
+	return &sentryRateLimiter{
+		limits:       limits,
+		fingerprints: make(map[string]*fingerprintState),
+		tokens:       float64(limits.PerSecond),
+	}
+}
+
+func (l *sentryRateLimiter) setLimits(limits SentryLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits.withDefaults()
+	l.tokens = float64(l.limits.PerSecond)
+}
+
+// allow reports whether event may be sent, counting it as suppressed
+// otherwise
+func (l *sentryRateLimiter) allow(event ErrorEvent) bool {
+	now := time.Now()
+	key := event.Level + "|" + event.Source
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.fingerprints[key]
+	if !ok {
+		state = &fingerprintState{windowStart: now}
+		l.fingerprints[key] = state
+	}
+	if now.Sub(state.windowStart) >= l.limits.Window {
+		state.windowStart = now
+		state.count = 0
+	}
+
+	// Refill the overall bucket for the elapsed time
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.limits.PerSecond)
+		if limit := float64(l.limits.PerSecond); l.tokens > limit {
+			l.tokens = limit
+		}
+	}
+	l.last = now
+
+	if state.count >= l.limits.PerFingerprint || l.tokens < 1 {
+		state.suppressed++
+		state.message = event.Message
+		if l.summary == nil {
+			l.summary = time.AfterFunc(l.limits.SummaryInterval, l.reportSuppressed)
+		}
+		return false
+	}
+	state.count++
+	l.tokens--
+	return true
+}
+
+// reportSuppressed sends one summary event per fingerprint that had
+// duplicates suppressed, and forgets the idle fingerprints
+func (l *sentryRateLimiter) reportSuppressed() {
+	type summary struct {
+		source  string
+		message string
+		count   int64
+	}
+	var summaries []summary
+
+	l.mu.Lock()
+	now := time.Now()
+	for key, state := range l.fingerprints {
+		if state.suppressed > 0 {
+			_, source, _ := strings.Cut(key, "|")
+			summaries = append(summaries, summary{source: source, message: state.message, count: state.suppressed})
+			state.suppressed = 0
+		} else if now.Sub(state.windowStart) >= l.limits.Window {
+			delete(l.fingerprints, key)
+		}
+	}
+	l.summary = nil
+	l.mu.Unlock()
+
+	hub := sentry.CurrentHub()
+	if hub.Client() == nil {
+		return
+	}
+	for _, s := range summaries {
+		hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelWarning)
+			scope.SetTag("source", s.source)
+			hub.CaptureMessage(fmt.Sprintf("suppressed %d duplicates of %s", s.count, s.message))
+		})
+	}
+}
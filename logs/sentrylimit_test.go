@@ -0,0 +1,78 @@
+package logs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestSentryFingerprintLimit(t *testing.T) {
+	// Registered first to run after the recorder delivered the queue
+	t.Cleanup(logs.ResetSentryLimits)
+	rec := recordSentry(t)
+	logs.SetSentryLimits(logs.SentryLimits{
+		PerFingerprint:  5,
+		Window:          time.Minute,
+		PerSecond:       1000,
+		SummaryInterval: 100 * time.Millisecond,
+	})
+
+	q, _ := newTestLogger()
+	err := errors.New("timeout")
+	start := time.Now()
+	for i := 0; i < 10000; i++ {
+		q.Errorf("query %d failed: %v", i, err)
+	}
+	t.Logf("10000 Errorf calls took %v", time.Since(start))
+
+	// The suppressed duplicates are summarized every SummaryInterval
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var errs, summaries int
+		for _, e := range rec.Events() {
+			switch e.Level {
+			case sentry.LevelError:
+				errs++
+			case sentry.LevelWarning:
+				if !strings.HasPrefix(e.Message, "suppressed ") || !strings.Contains(e.Message, "duplicates of query") {
+					t.Fatalf("summary = %q", e.Message)
+				}
+				summaries++
+			}
+		}
+		if errs != 5 {
+			t.Fatalf("%d errors reported, want 5", errs)
+		}
+		if summaries > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no summary of the suppressed events")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSentryOverallLimit(t *testing.T) {
+	t.Cleanup(logs.ResetSentryLimits)
+	rec := recordSentry(t)
+	logs.SetSentryLimits(logs.SentryLimits{
+		PerFingerprint:  1000,
+		PerSecond:       3,
+		SummaryInterval: time.Hour,
+	})
+
+	q, _ := newTestLogger()
+	for i := 0; i < 100; i++ {
+		q.Error(errors.New("disk full"))
+		q.Error(errors.New("disk full elsewhere"))
+	}
+	// The bucket holds 3 tokens and refills by 3 per second meanwhile
+	if events := rec.Events(); len(events) < 3 || len(events) > 4 {
+		t.Fatalf("%d events reported, want 3 within the overall cap", len(events))
+	}
+}
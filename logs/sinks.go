@@ -0,0 +1,221 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sink is an output or hook configured through the package, flushed by Flush
+// and closed by Close
+type sink struct {
+	key   interface{}
+	name  string
+	flush func()
+	close func() error
+}
+
+var (
+	// sinks are the registered outputs and hooks, in registration order
+	sinksMu sync.Mutex
+	sinks   []sink
+
+	exitHandlerOnce sync.Once
+
+	// flushing counts the flushSinks calls in progress, flushStarted is
+	// closed and replaced by every call
+	flushing     atomic.Int32
+	flushMu      sync.Mutex
+	flushStarted = make(chan struct{})
+
+	closeOnce sync.Once
+	closeErr  error
+)
+
+// registerSink has Flush, Fatal and Panic call flush, when not nil, before
+// returning or terminating the process, and Close call close. Registering a
+// key again replaces its functions.
+func registerSink(key interface{}, name string, flush func(), close func() error) {
+	sinksMu.Lock()
+	s := sink{key: key, name: name, flush: flush, close: close}
+	replaced := false
+	for i := range sinks {
+		if sinks[i].key == key {
+			sinks[i] = s
+			replaced = true
+		}
+	}
+	if !replaced {
+		sinks = append(sinks, s)
+	}
+	sinksMu.Unlock()
+	// Fatal writes its entry after flushBeforeExit, right before exiting
+	exitHandlerOnce.Do(func() {
+		logrus.RegisterExitHandler(func() {
+			flushSinks(time.Now().Add(exitTimeout()))
+		})
+	})
+}
+
+func unregisterSink(key interface{}) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	for i := range sinks {
+		if sinks[i].key == key {
+			sinks = append(sinks[:i:i], sinks[i+1:]...)
+			return
+		}
+	}
+}
+
+// registeredSinks returns a copy of the registered sinks
+func registeredSinks() []sink {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	return append([]sink(nil), sinks...)
+}
+
+// flushSinks calls the flush function of every registered sink, giving up
+// on the ones left at deadline. It returns false when it gave up.
+func flushSinks(deadline time.Time) bool {
+	flushing.Add(1)
+	flushMu.Lock()
+	close(flushStarted)
+	flushStarted = make(chan struct{})
+	flushMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer flushing.Add(-1)
+		defer close(done)
+		for _, s := range registeredSinks() {
+			if s.flush != nil {
+				s.flush()
+			}
+		}
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// flushInProgress reports whether flushSinks runs, for the sinks retrying a
+// send to try once only rather than holding the flush up
+func flushInProgress() bool {
+	return flushing.Load() > 0
+}
+
+// flushStarting returns a channel closed when the next flush starts, for the
+// sinks waiting to retry a send to give up
+func flushStarting() <-chan struct{} {
+	flushMu.Lock()
+	defer flushMu.Unlock()
+	return flushStarted
+}
+
+// Close delivers the queued error reports and flushes Sentry, then closes
+// every output and hook configured through the package, the latest first so
+// a buffer is written out before the file under it is closed. The errors are
+// joined in the returned one.
+//
+// When ctx is done before everything is closed, Close gives up on the rest
+// and ctx.Err() is part of the returned error. Without a deadline, the
+// reports are waited for as long as SetExitFlushTimeout allows.
+//
+// Only the first call does the work, the next ones return the same error.
+// The entries logged afterwards go to stderr, after a one time warning.
+func Close(ctx context.Context) error {
+	closeOnce.Do(func() {
+		closeErr = closeAll(ctx)
+		fallBackToStderr()
+	})
+	return closeErr
+}
+
+func closeAll(ctx context.Context) error {
+	var errs []error
+	step := func(name string, f func() error) bool {
+		done := make(chan error, 1)
+		go func() {
+			done <- f()
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("logs: failed to close %s: %w", name, err))
+			}
+			return true
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("logs: closing %s: %w", name, ctx.Err()))
+			return false
+		}
+	}
+
+	timeout := time.Duration(exitFlushTimeout.Load())
+	if timeout <= 0 {
+		timeout = defaultExitFlushTimeout
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	deadline := time.Now().Add(timeout)
+	if !step("error reports", func() error {
+		if !reports.wait(timeout) || !FlushSentry(time.Until(deadline)) {
+			return errors.New("timed out delivering error reports")
+		}
+		return nil
+	}) {
+		return errors.Join(errs...)
+	}
+
+	closing := registeredSinks()
+	for i := len(closing) - 1; i >= 0; i-- {
+		s := closing[i]
+		if s.close == nil {
+			unregisterSink(s.key)
+			continue
+		}
+		if !step(s.name, s.close) {
+			break
+		}
+		unregisterSink(s.key)
+	}
+	return errors.Join(errs...)
+}
+
+// fallBackToStderr replaces the output of every logrus logger whose level
+// was set, the shared one included, the closed outputs being unusable
+func fallBackToStderr() {
+	out := &stderrFallback{}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	NewCommonLog().logger.SetOutput(out)
+	configuredLevels.Range(func(k, v interface{}) bool {
+		k.(*logrus.Logger).SetOutput(out)
+		return true
+	})
+}
+
+// stderrFallback is the output of the loggers once closed
+type stderrFallback struct {
+	warning sync.Once
+}
+
+func (w *stderrFallback) Write(p []byte) (int, error) {
+	w.warning.Do(func() {
+		fmt.Fprintln(os.Stderr, "logs: logging after Close, entries are written to stderr")
+	})
+	return os.Stderr.Write(p)
+}
@@ -0,0 +1,140 @@
+package logs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// closeRecorder records the order the fake sinks are closed in
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed []string
+}
+
+func (r *closeRecorder) sink(name string, err error) {
+	logs.RegisterSink(new(int), name, nil, func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.closed = append(r.closed, name)
+		return err
+	})
+}
+
+func (r *closeRecorder) Closed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.closed...)
+}
+
+// useClose lets the test call Close with only its own sinks registered, the
+// shared logger being captured so its output is restored afterwards
+func useClose(t *testing.T) *closeRecorder {
+	t.Helper()
+	captureShared(t)
+	t.Cleanup(logs.ResetClose())
+	return &closeRecorder{}
+}
+
+func TestCloseOrder(t *testing.T) {
+	rec := useClose(t)
+	for _, name := range []string{"file", "buffer", "fluentd"} {
+		rec.sink(name, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := logs.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := strings.Join(rec.Closed(), ","); got != "fluentd,buffer,file" {
+		t.Fatalf("closed %s, want the latest first", got)
+	}
+	if err := logs.Close(ctx); err != nil || len(rec.Closed()) != 3 {
+		t.Fatalf("second Close = %v, closed %v, want nothing closed again", err, rec.Closed())
+	}
+}
+
+func TestCloseErrors(t *testing.T) {
+	rec := useClose(t)
+	errDisk, errConn := errors.New("disk full"), errors.New("connection reset")
+	rec.sink("file", errDisk)
+	rec.sink("buffer", nil)
+	rec.sink("fluentd", errConn)
+
+	err := logs.Close(context.Background())
+	if !errors.Is(err, errDisk) || !errors.Is(err, errConn) {
+		t.Fatalf("Close = %v, want both errors joined", err)
+	}
+	if !strings.Contains(err.Error(), "file") || !strings.Contains(err.Error(), "fluentd") {
+		t.Fatalf("Close = %v, want the failed sinks named", err)
+	}
+	if len(rec.Closed()) != 3 {
+		t.Fatalf("closed %v, want every sink closed despite the errors", rec.Closed())
+	}
+	if again := logs.Close(context.Background()); again != err {
+		t.Fatalf("second Close = %v, want the first error", again)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	rec := useClose(t)
+	release := make(chan struct{})
+	defer close(release)
+	rec.sink("file", nil)
+	logs.RegisterSink(new(int), "stuck", nil, func() error {
+		<-release
+		return nil
+	})
+	rec.sink("buffer", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := logs.Close(ctx)
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("Close returned after %v, want the deadline respected", d)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stuck") {
+		t.Fatalf("Close = %v, want the deadline exceeded closing stuck", err)
+	}
+	if got := rec.Closed(); len(got) != 1 || got[0] != "buffer" {
+		t.Fatalf("closed %v, want the sinks after the stuck one left open", got)
+	}
+}
+
+func TestLogAfterClose(t *testing.T) {
+	useClose(t)
+	if err := logs.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	q := logs.NewCommonLog()
+	q.Info("first after close")
+	q.Info("second after close")
+	os.Stderr = stderr
+	w.Close()
+	out, _ := io.ReadAll(r)
+
+	if n := strings.Count(string(out), "logging after Close"); n != 1 {
+		t.Fatalf("warned %d times, want once:\n%s", n, out)
+	}
+	for _, msg := range []string{"first after close", "second after close"} {
+		if !strings.Contains(string(out), msg) {
+			t.Fatalf("stderr misses %q:\n%s", msg, out)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxStackDepth caps the frames of the stack_trace field
+const maxStackDepth = 32
+
+var errorStackTraces atomic.Bool
+
+// SetErrorStackTraces adds a stack_trace field to the error, fatal and panic
+// entries, the lines of the stack separated by \n, frames of this package
+// excluded, at most 32 frames. The stack of an error carrying its own, like
+// the ones of github.com/pkg/errors, is preferred to the one of the logging
+// call. Off by default.
+func SetErrorStackTraces(enabled bool) {
+	errorStackTraces.Store(enabled)
+}
+
+// WithErrorStackTraces is the option form of SetErrorStackTraces(true)
+func WithErrorStackTraces() Option {
+	return func(q *CommonLogger) error {
+		if q.dryRun {
+			return nil
+		}
+		SetErrorStackTraces(true)
+		return nil
+	}
+}
+
+// errorLog is decorateLog for the error, fatal and panic entries, err being
+// the error logged if any
+func (q *CommonLogger) errorLog(err error) *logrus.Entry {
+	e := q.decorateLog()
+	if !errorStackTraces.Load() {
+		return e
+	}
+	if err == nil {
+		err, _ = q.fields[logrus.ErrorKey].(error)
+	}
+	pcs := errorStack(err)
+	if pcs == nil {
+		var buf [maxCallerDepth + 16]uintptr
+		// Skip runtime.Callers and errorLog
+		pcs = buf[:runtime.Callers(2, buf[:])]
+	}
+	return e.WithField("stack_trace", formatStack(pcs))
+}
+
+// errorStack returns the stack carried by the innermost error of the chain
+// of err having one, through a StackTrace method returning a slice of
+// program counters like github.com/pkg/errors does
+func errorStack(err error) []uintptr {
+	var pcs []uintptr
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := reflect.ValueOf(err).MethodByName("StackTrace")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		st := m.Call(nil)[0]
+		if st.Kind() != reflect.Slice || st.Type().Elem().Kind() != reflect.Uintptr {
+			continue
+		}
+		pcs = make([]uintptr, st.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(st.Index(i).Uint())
+		}
+	}
+	return pcs
+}
+
+// formatStack renders the frames of pcs outside this package and the
+// runtime, one "function (file:line)" per line
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	n := 0
+	for n < maxStackDepth {
+		f, more := frames.Next()
+		if f.Function != "" && !inLogsPackage(f.Function) && !strings.HasPrefix(f.Function, "runtime.") {
+			if n > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%s (%s:%d)", f.Function, f.File, f.Line)
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
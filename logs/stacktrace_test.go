@@ -0,0 +1,149 @@
+package logs_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// stackError stands for the errors of github.com/pkg/errors, carrying the
+// stack of their creation
+type (
+	frame      uintptr
+	stackTrace []frame
+	stackError struct {
+		msg   string
+		stack stackTrace
+	}
+)
+
+func (e *stackError) Error() string          { return e.msg }
+func (e *stackError) StackTrace() stackTrace { return e.stack }
+
+// newStackError returns an error carrying the stack of its caller
+func newStackError(msg string) error {
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	st := make(stackTrace, n)
+	for i, pc := range pcs[:n] {
+		st[i] = frame(pc)
+	}
+	return &stackError{msg: msg, stack: st}
+}
+
+// failInLibrary stands for a library returning an error with its stack
+func failInLibrary() error {
+	return newStackError("library failure")
+}
+
+func useErrorStackTraces(t *testing.T) {
+	t.Helper()
+	logs.SetErrorStackTraces(true)
+	t.Cleanup(func() { logs.SetErrorStackTraces(false) })
+}
+
+func stackOf(t *testing.T, entry map[string]interface{}) []string {
+	t.Helper()
+	stack, ok := entry["stack_trace"].(string)
+	if !ok || stack == "" {
+		t.Fatalf("entry %v has no stack_trace", entry)
+	}
+	return strings.Split(stack, "\n")
+}
+
+func TestErrorStackTraces(t *testing.T) {
+	useErrorStackTraces(t)
+	q, buf := newTestLogger()
+	q.SetExitFunc(func(int) {})
+
+	for name, log := range map[string]func(){
+		"Error":     func() { q.Error(errors.New("boom")) },
+		"Errorf":    func() { q.Errorf("failed: %v", errors.New("boom")) },
+		"Errorj":    func() { q.Errorj(gommonLog.JSON{"msg": "boom"}) },
+		"WithError": func() { q.WithError(errors.New("boom")).Error("failed") },
+		"Fatal":     func() { q.Fatal("crash") },
+		"Panic": func() {
+			defer func() { recover() }()
+			q.Panic("crash")
+		},
+	} {
+		buf.Reset()
+		log()
+		lines := stackOf(t, lastEntry(t, buf))
+		if !strings.Contains(lines[0], "TestErrorStackTraces") || !strings.Contains(lines[0], "stacktrace_test.go:") {
+			t.Errorf("%s: stack starts at %q, want the logging call", name, lines[0])
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "github.com/rohanchauhan02/common/logs.") || strings.HasPrefix(line, "runtime.") {
+				t.Errorf("%s: stack keeps frame %q", name, line)
+			}
+		}
+	}
+}
+
+// recurse logs an error depth calls deep
+func recurse(q *logs.CommonLogger, depth int) {
+	if depth == 0 {
+		q.Error(errors.New("deep"))
+		return
+	}
+	recurse(q, depth-1)
+}
+
+func TestErrorStackTracesDepth(t *testing.T) {
+	useErrorStackTraces(t)
+	q, buf := newTestLogger()
+	recurse(q, 100)
+	lines := stackOf(t, lastEntry(t, buf))
+	if len(lines) != 32 {
+		t.Fatalf("stack of %d frames, want capped to 32", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "recurse") {
+			t.Fatalf("frame %q, want the innermost frames", line)
+		}
+	}
+}
+
+func TestErrorStackTracesCarried(t *testing.T) {
+	useErrorStackTraces(t)
+	q, buf := newTestLogger()
+
+	err := fmt.Errorf("charge: %w", failInLibrary())
+	for name, log := range map[string]func(){
+		"Error":     func() { q.Error(err) },
+		"WithError": func() { q.WithError(err).Errorf("failed") },
+	} {
+		buf.Reset()
+		log()
+		lines := stackOf(t, lastEntry(t, buf))
+		if !strings.Contains(lines[0], "failInLibrary") {
+			t.Errorf("%s: stack starts at %q, want the one carried by the error", name, lines[0])
+		}
+	}
+}
+
+func TestErrorStackTracesInfo(t *testing.T) {
+	useErrorStackTraces(t)
+	q, buf := newTestLogger()
+
+	q.Info("charged")
+	q.Warn("slow")
+	q.WithError(errors.New("boom")).Warn("retrying")
+	for _, entry := range decodeEntries(t, buf) {
+		if _, ok := entry["stack_trace"]; ok {
+			t.Fatalf("%s entry has a stack_trace", entry["level"])
+		}
+	}
+
+	logs.SetErrorStackTraces(false)
+	q.Error(errors.New("boom"))
+	if _, ok := lastEntry(t, buf)["stack_trace"]; ok {
+		t.Fatal("stack_trace added while disabled")
+	}
+}
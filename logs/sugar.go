@@ -0,0 +1,88 @@
+package logs
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// badKeyField holds the arguments of the *w methods that are not valid
+// key-value pairs
+const badKeyField = "!BADKEY"
+
+var badKeyWarning sync.Once
+
+// Debugw logs msg at level debug with fields paired up from keysAndValues,
+// e.g. Debugw("fetched user", "user_id", 42, "latency_ms", 18)
+func (q *CommonLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	if !q.enabled(logrus.DebugLevel) || q.suppressed(logrus.DebugLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(q.sweeten(keysAndValues)).Debug(msg)
+}
+
+// Infow is the info level of Debugw
+func (q *CommonLogger) Infow(msg string, keysAndValues ...interface{}) {
+	if !q.enabled(logrus.InfoLevel) || q.suppressed(logrus.InfoLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(q.sweeten(keysAndValues)).Info(msg)
+}
+
+// Warnw is the warn level of Debugw
+func (q *CommonLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	if !q.enabled(logrus.WarnLevel) || q.suppressed(logrus.WarnLevel, msg) {
+		return
+	}
+	q.decorateLog().WithFields(q.sweeten(keysAndValues)).Warn(msg)
+}
+
+// Errorw is the error level of Debugw, reported like Error with the first
+// error value
+func (q *CommonLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	if q.enabled(logrus.ErrorLevel) && !q.suppressed(logrus.ErrorLevel, msg) {
+		q.errorLog(findError(keysAndValues)).WithFields(q.sweeten(keysAndValues)).Error(msg)
+	}
+	q.report(logrus.ErrorLevel, msg, findError(keysAndValues), nil)
+}
+
+// Fatalw is the fatal level of Debugw
+func (q *CommonLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	q.report(logrus.FatalLevel, msg, findError(keysAndValues), nil)
+	flushBeforeExit()
+	q.errorLog(findError(keysAndValues)).WithFields(q.sweeten(keysAndValues)).Fatal(msg)
+}
+
+// sweeten pairs up keysAndValues into fields. Keys that are not strings and
+// a trailing key without value are kept under !BADKEY, and a warning is
+// logged the first time it happens.
+func (q *CommonLogger) sweeten(keysAndValues []interface{}) logrus.Fields {
+	fields := make(logrus.Fields, len(keysAndValues)/2)
+	var bad []interface{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			bad = append(bad, keysAndValues[i])
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			bad = append(bad, keysAndValues[i], keysAndValues[i+1])
+			continue
+		}
+		value := keysAndValues[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[key] = value
+	}
+	if len(bad) > 0 {
+		fields[badKeyField] = bad
+		badKeyWarning.Do(func() {
+			if !q.enabled(logrus.WarnLevel) {
+				return
+			}
+			q.logger.WithField(badKeyField, bad).Warn("Ignored malformed key-value pairs, keys must be strings and have a value")
+		})
+	}
+	return withoutReserved(fields)
+}
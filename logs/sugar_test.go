@@ -0,0 +1,93 @@
+package logs_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestInfow(t *testing.T) {
+	q, buf := newTestLogger("users")
+	q.WithRequestID("req-1").Infow("fetched user",
+		"user_id", 42,
+		"latency_ms", 18.5,
+		"cause", errors.New("cache miss"),
+		"note", nil,
+		"prefix", "spoofed",
+	)
+	e := lastEntry(t, buf)
+	for key, want := range map[string]interface{}{
+		"msg":           "fetched user",
+		"user_id":       float64(42),
+		"latency_ms":    18.5,
+		"cause":         "cache miss",
+		"note":          nil,
+		"prefix":        "users",
+		"requestID":     "req-1",
+		"fields.prefix": "spoofed",
+	} {
+		if e[key] != want {
+			t.Errorf("%s = %#v, want %#v", key, e[key], want)
+		}
+	}
+	if _, ok := e["note"]; !ok {
+		t.Error("nil value dropped")
+	}
+}
+
+func TestInfowBadKeys(t *testing.T) {
+	logs.ResetBadKeyWarning()
+	q, buf := newTestLogger()
+	for i := 0; i < 2; i++ {
+		q.Infow("odd", "user_id", 42, 7, "seven", "trailing")
+	}
+
+	entries := decodeEntries(t, buf)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want the 2 calls and a single warning", len(entries))
+	}
+	warning, odd := entries[0], entries[1]
+	if warning["level"] != "warning" || warning["!BADKEY"] == nil {
+		t.Fatalf("first entry = %v, want the malformed pairs warning", warning)
+	}
+	if odd["msg"] != "odd" || odd["user_id"] != float64(42) {
+		t.Fatalf("entry = %v", odd)
+	}
+	if bad := fmt.Sprint(odd["!BADKEY"]); bad != "[7 seven trailing]" {
+		t.Fatalf("!BADKEY = %s", bad)
+	}
+}
+
+func TestInfowNoArgs(t *testing.T) {
+	q, buf := newTestLogger()
+	q.Infow("plain")
+	q.Infow("nil", nil)
+	var entries []map[string]interface{}
+	for _, e := range decodeEntries(t, buf) {
+		// The malformed pairs warning, unless an earlier test logged it
+		if e["level"] != "warning" {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) != 2 || entries[0]["msg"] != "plain" {
+		t.Fatalf("entries = %v", entries)
+	}
+	if bad := fmt.Sprint(entries[1]["!BADKEY"]); bad != "[<nil>]" {
+		t.Fatalf("!BADKEY = %s, want the lone nil", bad)
+	}
+}
+
+func TestErrorw(t *testing.T) {
+	sentry := recordSentry(t)
+	q, buf := newTestLogger()
+	q.Errorw("charge failed", "order", "o-1", "error", errors.New("declined"))
+	if e := lastEntry(t, buf); e["order"] != "o-1" || e["level"] != "error" {
+		t.Fatalf("entry = %v", e)
+	}
+	events := sentry.Events()
+	if len(events) != 1 || len(events[0].Exception) == 0 || events[0].Exception[0].Value != "declined" {
+		t.Fatalf("events = %v, want the error reported", events)
+	}
+}
@@ -0,0 +1,14 @@
+package logs
+
+// SyslogOptions configures WithSyslog
+type SyslogOptions struct {
+	// Network and Addr are the syslog daemon to connect to, e.g. "udp" and
+	// "localhost:514", the local daemon when both are empty
+	Network string
+	Addr    string
+	// Tag prefixes every message, the program name by default
+	Tag string
+	// Facility is the syslog facility name, e.g. "daemon" or "local0", user
+	// by default
+	Facility string
+}
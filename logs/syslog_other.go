@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package logs
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// WithSyslog fails, syslog is not available on this platform
+func WithSyslog(opts SyslogOptions) Option {
+	return func(q *CommonLogger) error {
+		return fmt.Errorf("logs: syslog is not supported on %s", runtime.GOOS)
+	}
+}
@@ -0,0 +1,164 @@
+//go:build !windows && !plan9
+
+package logs
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogRedialInterval spaces the dials while the daemon is unreachable
+const syslogRedialInterval = time.Second
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// WithSyslog also sends every entry to a syslog daemon, at the severity
+// matching its level, debug for trace and debug entries up to crit for fatal
+// and panic ones. The output is kept as is.
+//
+// A daemon unreachable at startup is logged as a warning only, the entries
+// being logged locally meanwhile, and the connection is dialed again as
+// entries come, as it is when the daemon restarts. Only an unknown facility
+// fails the option.
+func WithSyslog(opts SyslogOptions) Option {
+	return func(q *CommonLogger) error {
+		facility := syslog.LOG_USER
+		if opts.Facility != "" {
+			f, ok := syslogFacilities[strings.ToLower(opts.Facility)]
+			if !ok {
+				return fmt.Errorf("logs: unknown syslog facility %q", opts.Facility)
+			}
+			facility = f
+		}
+		if q.dryRun {
+			return nil
+		}
+		hook := &syslogHook{
+			opts:     opts,
+			facility: facility,
+			formatter: &logrus.TextFormatter{
+				// The daemon timestamps the messages
+				DisableTimestamp: true,
+				DisableColors:    true,
+			},
+		}
+		err := hook.dial()
+		q.addHook(hook)
+		registerSink(hook, "syslog", nil, hook.Close)
+		if err != nil {
+			q.WithError(err).Warnf("Failed to connect to syslog, entries are logged locally until it is reachable")
+		}
+		return nil
+	}
+}
+
+// syslogHook writes the entries to the syslog daemon
+type syslogHook struct {
+	opts      SyslogOptions
+	facility  syslog.Priority
+	formatter logrus.Formatter
+
+	mu     sync.Mutex
+	w      *syslog.Writer
+	closed bool
+	// nextDial is the earliest time to dial again after a failure
+	nextDial time.Time
+}
+
+// dial connects to the daemon, h.mu held or before the hook is registered
+func (h *syslogHook) dial() error {
+	w, err := syslog.Dial(h.opts.Network, h.opts.Addr, h.facility|syslog.LOG_INFO, h.opts.Tag)
+	if err != nil {
+		h.nextDial = time.Now().Add(syslogRedialInterval)
+		return err
+	}
+	h.w = w
+	return nil
+}
+
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *syslogHook) Fire(e *logrus.Entry) error {
+	b, err := h.formatter.Format(e)
+	if err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(string(b), "\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	if h.w == nil {
+		if time.Now().Before(h.nextDial) {
+			return nil
+		}
+		if err := h.dial(); err != nil {
+			return nil
+		}
+	}
+	// The writer dials again once by itself when sending fails
+	if err := writeSyslog(h.w, e.Level, msg); err != nil {
+		h.w.Close()
+		h.w = nil
+		h.nextDial = time.Now().Add(syslogRedialInterval)
+		return fmt.Errorf("logs: failed to write to syslog: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection, the next entries are not sent anymore
+func (h *syslogHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	if h.w == nil {
+		return nil
+	}
+	err := h.w.Close()
+	h.w = nil
+	return err
+}
+
+func writeSyslog(w *syslog.Writer, level logrus.Level, msg string) error {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return w.Debug(msg)
+	case logrus.InfoLevel:
+		return w.Info(msg)
+	case logrus.WarnLevel:
+		return w.Warning(msg)
+	case logrus.ErrorLevel:
+		return w.Err(msg)
+	}
+	return w.Crit(msg)
+}
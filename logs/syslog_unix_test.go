@@ -0,0 +1,144 @@
+//go:build !windows && !plan9
+
+package logs_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	gommonLog "github.com/labstack/gommon/log"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+// fakeSyslog receives the UDP messages sent to a syslog daemon
+func fakeSyslog(t *testing.T) (net.PacketConn, <-chan string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	messages := make(chan string, 16)
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				close(messages)
+				return
+			}
+			messages <- string(buf[:n])
+		}
+	}()
+	return conn, messages
+}
+
+func receive(t *testing.T, messages <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no syslog message received")
+		return ""
+	}
+}
+
+func TestSyslogPriorities(t *testing.T) {
+	conn, messages := fakeSyslog(t)
+	q, buf := newTestLogger("billing")
+	q.SetLevel(gommonLog.DEBUG)
+	err := logs.WithSyslog(logs.SyslogOptions{
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Tag:      "billing-api",
+		Facility: "local0",
+	})(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// local0 is facility 16
+	for _, tt := range []struct {
+		log      func(...interface{})
+		priority string
+	}{
+		{q.Debug, "<135>"},
+		{q.Info, "<134>"},
+		{q.Warn, "<132>"},
+		{q.WithoutSentry().Error, "<131>"},
+	} {
+		tt.log("charged card")
+		msg := receive(t, messages)
+		if !strings.HasPrefix(msg, tt.priority) {
+			t.Errorf("message %q, want priority %s", msg, tt.priority)
+		}
+		if !strings.Contains(msg, "billing-api[") || !strings.Contains(msg, `msg="charged card"`) || !strings.Contains(msg, "prefix=billing") {
+			t.Errorf("message %q lacks the tag or the entry", msg)
+		}
+	}
+	// The local output is kept
+	if n := len(decodeEntries(t, buf)); n != 4 {
+		t.Fatalf("logged %d entries locally, want 4", n)
+	}
+}
+
+func TestSyslogUnknownFacility(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithSyslog(logs.SyslogOptions{Network: "udp", Addr: "127.0.0.1:514", Facility: "nope"})(q); err == nil {
+		t.Fatal("unknown facility accepted")
+	}
+}
+
+func TestSyslogReconnect(t *testing.T) {
+	// A free port nothing listens on yet
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	q, buf := newTestLogger()
+	if err := logs.WithSyslog(logs.SyslogOptions{Network: "tcp", Addr: addr})(q); err != nil {
+		t.Fatalf("unreachable daemon failed the option: %v", err)
+	}
+	if e := lastEntry(t, buf); e["level"] != "warning" || !strings.Contains(e["msg"].(string), "Failed to connect to syslog") {
+		t.Fatalf("entry = %v, want the connection warning", e)
+	}
+	q.Info("local only")
+	if lastEntry(t, buf)["msg"] != "local only" {
+		t.Fatal("not logging locally without the daemon")
+	}
+
+	// The daemon starts
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("port %s taken meanwhile: %v", addr, err)
+	}
+	defer l.Close()
+	lines := make(chan string, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		line, _ := bufio.NewReader(c).ReadString('\n')
+		lines <- line
+	}()
+
+	time.Sleep(1100 * time.Millisecond)
+	q.Info("reconnected")
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, "msg=reconnected") {
+			t.Fatalf("daemon got %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message after the daemon started")
+	}
+}
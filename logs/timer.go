@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultSlowThreshold = time.Second
+
+var slowThreshold atomic.Int64
+
+// SetSlowThreshold sets the duration above which StartTimer logs operations
+// at level warn, 1s by default. Zero or less restores the default.
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+func loadSlowThreshold() time.Duration {
+	if d := time.Duration(slowThreshold.Load()); d > 0 {
+		return d
+	}
+	return defaultSlowThreshold
+}
+
+// StartTimer starts timing operation and returns the function ending it, to
+// defer. It logs the operation, its duration_ms and outcome along with the
+// fields paired up from keysAndValues, like Infow does: at level info, warn
+// when slower than the threshold of SetSlowThreshold, and error when given a
+// non nil error.
+//
+//	done := logger.StartTimer("load-user-profile", "user_id", id)
+//	defer func() { done(err) }()
+func (q *CommonLogger) StartTimer(operation string, keysAndValues ...interface{}) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		elapsed := time.Since(start)
+		fields := q.sweeten(keysAndValues)
+		fields["operation"] = operation
+		fields["duration_ms"] = float64(elapsed) / float64(time.Millisecond)
+
+		switch {
+		case err != nil:
+			fields["outcome"] = "error"
+			l := q.WithError(err)
+			if l.enabled(logrus.ErrorLevel) {
+				l.decorateLog().WithFields(fields).Errorf("%s failed", operation)
+			}
+			l.report(logrus.ErrorLevel, operation+" failed", err, nil)
+		case elapsed > loadSlowThreshold():
+			fields["outcome"] = "slow"
+			if q.enabled(logrus.WarnLevel) {
+				q.decorateLog().WithFields(fields).Warnf("%s was slow", operation)
+			}
+		default:
+			fields["outcome"] = "success"
+			if q.enabled(logrus.InfoLevel) {
+				q.decorateLog().WithFields(fields).Infof("%s done", operation)
+			}
+		}
+	}
+}
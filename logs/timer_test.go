@@ -0,0 +1,67 @@
+package logs_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestStartTimer(t *testing.T) {
+	q, buf := newTestLogger()
+	done := q.StartTimer("load-user-profile", "user_id", 42)
+	time.Sleep(10 * time.Millisecond)
+	done(nil)
+
+	e := lastEntry(t, buf)
+	if e["level"] != "info" || e["msg"] != "load-user-profile done" || e["outcome"] != "success" {
+		t.Fatalf("entry = %v", e)
+	}
+	if e["operation"] != "load-user-profile" || e["user_id"] != float64(42) {
+		t.Fatalf("entry = %v, want the operation and start fields", e)
+	}
+	if d, ok := e["duration_ms"].(float64); !ok || d < 10 || d > 1000 {
+		t.Fatalf("duration_ms = %v, want about 10", e["duration_ms"])
+	}
+}
+
+func TestStartTimerSlow(t *testing.T) {
+	logs.SetSlowThreshold(5 * time.Millisecond)
+	defer logs.SetSlowThreshold(0)
+	q, buf := newTestLogger()
+
+	done := q.StartTimer("query")
+	time.Sleep(10 * time.Millisecond)
+	done(nil)
+	if e := lastEntry(t, buf); e["level"] != "warning" || e["outcome"] != "slow" || e["msg"] != "query was slow" {
+		t.Fatalf("entry = %v, want the slow warning", e)
+	}
+
+	q.StartTimer("query")(nil)
+	if e := lastEntry(t, buf); e["level"] != "info" {
+		t.Fatalf("entry = %v, fast operation escalated", e)
+	}
+}
+
+func TestStartTimerError(t *testing.T) {
+	sentry := recordSentry(t)
+	q, buf := newTestLogger()
+	run := func() (err error) {
+		done := q.StartTimer("charge")
+		defer func() { done(err) }()
+		return errors.New("declined")
+	}
+	run()
+
+	e := lastEntry(t, buf)
+	if e["level"] != "error" || e["outcome"] != "error" || e["error"] != "declined" || e["msg"] != "charge failed" {
+		t.Fatalf("entry = %v", e)
+	}
+	if _, ok := e["duration_ms"].(float64); !ok {
+		t.Fatalf("duration_ms = %v, want a number", e["duration_ms"])
+	}
+	if events := sentry.Events(); len(events) != 1 {
+		t.Fatalf("got %d events, want the failure reported", len(events))
+	}
+}
@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+var timeLocation atomic.Pointer[time.Location]
+
+// WithTimestampFormat sets the time layout of the text and JSON formats, to
+// apply after WithFormat
+func WithTimestampFormat(layout string) Option {
+	return func(q *CommonLogger) error {
+		if layout == "" {
+			return errors.New("logs: timestamp format must not be empty")
+		}
+		switch f := q.logger.Formatter.(type) {
+		case *prefixed.TextFormatter:
+			text := copyTextFormatter(f)
+			text.TimestampFormat = layout
+			q.setFormatter(text)
+		case *logrus.JSONFormatter:
+			jf := *f
+			jf.TimestampFormat = layout
+			q.setFormatter(&jf)
+		default:
+			return fmt.Errorf("logs: formatter %T has no timestamp format", f)
+		}
+		return nil
+	}
+}
+
+// WithTimeLocation converts the time of every entry to loc before it is
+// formatted, time.UTC typically
+func WithTimeLocation(loc *time.Location) Option {
+	return func(q *CommonLogger) error {
+		if loc == nil {
+			return errors.New("logs: time location must not be nil")
+		}
+		if q.dryRun {
+			return nil
+		}
+		timeLocation.Store(loc)
+		return nil
+	}
+}
+
+// copyTextFormatter returns a new formatter with the settings of f,
+// which holds a sync.Once and can not be copied
+func copyTextFormatter(f *prefixed.TextFormatter) *prefixed.TextFormatter {
+	return &prefixed.TextFormatter{
+		ForceColors:      f.ForceColors,
+		DisableColors:    f.DisableColors,
+		ForceFormatting:  f.ForceFormatting,
+		DisableTimestamp: f.DisableTimestamp,
+		DisableUppercase: f.DisableUppercase,
+		FullTimestamp:    f.FullTimestamp,
+		TimestampFormat:  f.TimestampFormat,
+		DisableSorting:   f.DisableSorting,
+		QuoteEmptyFields: f.QuoteEmptyFields,
+		QuoteCharacter:   f.QuoteCharacter,
+		SpacePadding:     f.SpacePadding,
+	}
+}
+
+// locationHook converts the entry times to the location set with
+// WithTimeLocation
+type locationHook struct{}
+
+func (h *locationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *locationHook) Fire(e *logrus.Entry) error {
+	if loc := timeLocation.Load(); loc != nil {
+		e.Time = e.Time.In(loc)
+	}
+	return nil
+}
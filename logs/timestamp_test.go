@@ -0,0 +1,86 @@
+package logs_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/rohanchauhan02/common/logs"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+func TestTimestampUTCJSON(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	_, buf := captureShared(t)
+	defer logs.WithTimeLocation(time.Local)(logs.NewCommonLog())
+
+	q, err := logs.NewCommonLogWithConfig(logs.Config{
+		Prefix:          "ingest",
+		Format:          logs.FormatJSON,
+		TimestampFormat: time.RFC3339Nano,
+		UTC:             true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	q.WithRequestID("req-1").Info("stamped")
+	entry := lastEntry(t, buf)
+
+	stamp, _ := entry["time"].(string)
+	at, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		t.Fatalf("time %q: %v", stamp, err)
+	}
+	if _, offset := at.Zone(); offset != 0 || stamp[len(stamp)-1] != 'Z' {
+		t.Fatalf("time %q not in UTC", stamp)
+	}
+	if d := at.Sub(before); d < 0 || d > time.Second {
+		t.Fatalf("time %v, logged at %v", at, before)
+	}
+	if !regexp.MustCompile(`\.\d+Z$`).MatchString(stamp) {
+		t.Fatalf("time %q lost the sub-second precision", stamp)
+	}
+	if entry["prefix"] != "ingest" || entry["requestID"] != "req-1" || entry["source"] == nil {
+		t.Fatalf("entry = %v, decorated fields changed", entry)
+	}
+}
+
+func TestTimestampText(t *testing.T) {
+	zone := time.FixedZone("UTC+1", 3600)
+	buf := &bytes.Buffer{}
+	q := logs.NewCommonLogWithOutput(buf, "ingest")
+	logs.WithFormatter(&prefixed.TextFormatter{DisableColors: true, FullTimestamp: true})(q)
+	if err := logs.WithTimestampFormat(time.RFC3339Nano)(q); err != nil {
+		t.Fatal(err)
+	}
+	logs.WithTimeLocation(zone)(q)
+	defer logs.WithTimeLocation(time.Local)(q)
+
+	q.Info("stamped")
+	m := regexp.MustCompile(`time="([^"]+)"`).FindStringSubmatch(buf.String())
+	if m == nil {
+		t.Fatalf("line %q has no time", buf.String())
+	}
+	at, err := time.Parse(time.RFC3339Nano, m[1])
+	if err != nil {
+		t.Fatalf("time %q: %v", m[1], err)
+	}
+	if _, offset := at.Zone(); offset != 3600 {
+		t.Fatalf("time %q not converted to the location", m[1])
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("prefix=ingest")) {
+		t.Fatalf("line %q lost the prefix", buf.String())
+	}
+}
+
+func TestTimestampOptionErrors(t *testing.T) {
+	q, _ := newTestLogger()
+	if err := logs.WithTimestampFormat("")(q); err == nil {
+		t.Error("empty timestamp format accepted")
+	}
+	if err := logs.WithTimeLocation(nil)(q); err == nil {
+		t.Error("nil location accepted")
+	}
+}
@@ -0,0 +1,48 @@
+package logs
+
+import (
+	"strings"
+)
+
+const (
+	headerTraceparent = "traceparent"
+	headerTracestate  = "tracestate"
+)
+
+// parseTraceparent extracts the trace and parent span IDs from a W3C
+// traceparent header, version-traceid-spanid-flags. ok is false for missing or
+// malformed headers and for the all-zero IDs the spec declares invalid.
+func parseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, 2) || version == "ff" || !isLowerHex(flags, 2) {
+		return "", "", false
+	}
+	// Version 00 has exactly four fields, later versions may append more
+	if version == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	if !isLowerHex(traceID, 32) || !isLowerHex(spanID, 16) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package logs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/rohanchauhan02/common/logs"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		ok     bool
+	}{
+		{"00-" + testTraceID + "-" + testSpanID + "-01", true},
+		{" 00-" + testTraceID + "-" + testSpanID + "-00 ", true},
+		{"01-" + testTraceID + "-" + testSpanID + "-01-extra", true},
+		{"", false},
+		{"garbage", false},
+		{"00-" + testTraceID + "-" + testSpanID + "-01-extra", false},
+		{"ff-" + testTraceID + "-" + testSpanID + "-01", false},
+		{"00-" + "4BF92F3577B34DA6A3CE929D0E0E4736" + "-" + testSpanID + "-01", false},
+		{"00-" + testTraceID[:31] + "-" + testSpanID + "-01", false},
+		{"00-00000000000000000000000000000000-" + testSpanID + "-01", false},
+		{"00-" + testTraceID + "-0000000000000000-01", false},
+		{"00-" + testTraceID + "-" + testSpanID + "-1", false},
+	} {
+		traceID, spanID, ok := logs.ParseTraceparent(tt.header)
+		if ok != tt.ok {
+			t.Errorf("ParseTraceparent(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+			continue
+		}
+		if ok && (traceID != testTraceID || spanID != testSpanID) {
+			t.Errorf("ParseTraceparent(%q) = %s, %s", tt.header, traceID, spanID)
+		}
+	}
+}
+
+func TestMiddlewareTraceparent(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{"valid", "00-" + testTraceID + "-" + testSpanID + "-01", true},
+		{"missing", "", false},
+		{"malformed", "00-nothex-" + testSpanID + "-01", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sentry := recordSentry(t)
+			q, buf := newTestLogger("api")
+			e := echo.New()
+			e.Use(q.MiddlewareLoggerRequestID())
+			e.GET("/", func(c echo.Context) error {
+				logs.FromEchoContext(c).Error("failed")
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("traceparent", tt.header)
+				req.Header.Set("tracestate", "vendor=value")
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+
+			entry := lastEntry(t, buf)
+			events := sentry.Events()
+			if len(events) != 1 {
+				t.Fatalf("got %d Sentry events, want 1", len(events))
+			}
+			tags := events[0].Tags
+			if !tt.ok {
+				if _, ok := entry["trace_id"]; ok {
+					t.Fatalf("trace_id logged for %q: %v", tt.header, entry)
+				}
+				if _, ok := tags["trace_id"]; ok {
+					t.Fatalf("trace_id tagged for %q: %v", tt.header, tags)
+				}
+				return
+			}
+			if entry["trace_id"] != testTraceID || entry["span_id"] != testSpanID {
+				t.Fatalf("entry = %v", entry)
+			}
+			if tags["trace_id"] != testTraceID || tags["span_id"] != testSpanID {
+				t.Fatalf("Sentry tags = %v", tags)
+			}
+			if events[0].Extra["tracestate"] != "vendor=value" {
+				t.Fatalf("Sentry extra = %v", events[0].Extra)
+			}
+		})
+	}
+}
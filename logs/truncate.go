@@ -0,0 +1,44 @@
+package logs
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const truncatedMarker = "…(truncated)"
+
+var maxMessageLength atomic.Int64
+
+// SetMaxMessageLength truncates the formatted message of every logger,
+// including the one sent to Sentry, to at most n bytes followed by a
+// truncation marker. A value of zero or less disables truncation.
+func SetMaxMessageLength(n int) {
+	maxMessageLength.Store(int64(n))
+}
+
+func truncate(message string) string {
+	n := int(maxMessageLength.Load())
+	if n <= 0 || len(message) <= n {
+		return message
+	}
+	// Do not cut a multi-byte character in half
+	for n > 0 && !utf8.RuneStart(message[n]) {
+		n--
+	}
+	return message[:n] + truncatedMarker
+}
+
+// truncateHook applies the configured maximum length to entries before they
+// are formatted
+type truncateHook struct{}
+
+func (h *truncateHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *truncateHook) Fire(e *logrus.Entry) error {
+	e.Message = truncate(e.Message)
+	return nil
+}
@@ -0,0 +1,53 @@
+package logs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rohanchauhan02/common/logs"
+)
+
+func TestTruncate(t *testing.T) {
+	q, buf := newTestLogger()
+	logs.SetMaxMessageLength(10)
+	defer logs.SetMaxMessageLength(0)
+
+	q.Info(strings.Repeat("a", 100))
+	msg, _ := lastEntry(t, buf)["msg"].(string)
+	if want := strings.Repeat("a", 10) + logs.TruncatedMarker; msg != want {
+		t.Fatalf("msg = %q, want %q", msg, want)
+	}
+
+	buf.Reset()
+	q.Info("short")
+	if msg := lastEntry(t, buf)["msg"]; msg != "short" {
+		t.Fatalf("msg = %q, short messages must be kept", msg)
+	}
+}
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	logs.SetMaxMessageLength(4)
+	defer logs.SetMaxMessageLength(0)
+
+	// é is 2 bytes, the 4th byte is in the middle of the second one
+	got := logs.Truncate("aéé")
+	if want := "aé" + logs.TruncatedMarker; got != want {
+		t.Fatalf("truncate = %q, want %q", got, want)
+	}
+}
+
+func TestTruncateSentry(t *testing.T) {
+	rec := recordSentry(t)
+	q, _ := newTestLogger()
+	logs.SetMaxMessageLength(8)
+	defer logs.SetMaxMessageLength(0)
+
+	q.Errorf("oversized %s", strings.Repeat("x", 100))
+	messages := rec.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d events, want 1", len(messages))
+	}
+	if want := "oversize" + logs.TruncatedMarker; messages[0] != want {
+		t.Fatalf("Sentry message = %q, want %q", messages[0], want)
+	}
+}